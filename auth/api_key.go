@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"urllite/service"
+	"urllite/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApiKeyOrUserAuthentication accepts a personal api key from the X-API-Key
+// header or an "Authorization: Bearer ulk_..." header and falls back to the
+// JWT based UserAuthentication for every other request.
+func ApiKeyOrUserAuthentication(apiKeyService service.ApiKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := strings.TrimSpace(c.GetHeader("X-API-Key"))
+		authHeader := c.GetHeader("Authorization")
+		if rawKey == "" && strings.HasPrefix(authHeader, "Bearer "+types.ApiKeyPrefix) {
+			rawKey = strings.TrimSpace(authHeader[7:])
+		}
+
+		if rawKey == "" {
+			UserAuthentication(c)
+			return
+		}
+
+		apiKey, user, appErr := apiKeyService.Authenticate(rawKey)
+		if appErr != nil {
+			appErr.HttpResponse(c)
+			c.Abort()
+			return
+		}
+
+		c.Set("current_username", user.Name)
+		c.Set("current_user_email", user.Email)
+		c.Set("current_user_id", user.ID.String())
+		c.Set("current_user_role", user.Role)
+		c.Set("current_api_key_id", apiKey.ID.String())
+		c.Set("current_api_key_scopes", apiKey.Scopes)
+		c.Next()
+	}
+}
+
+// RequireScope only restricts api key requests, users logged in with a token
+// keep full access to their own resources.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, ok := c.Get("current_api_key_scopes")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		for _, s := range scopes.([]string) {
+			if s == scope {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "message": "Api key is missing the " + scope + " scope"})
+	}
+}
+
+func RejectApiKey(c *gin.Context) {
+	if _, ok := c.Get("current_api_key_id"); ok {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "message": "This action is not allowed with an api key"})
+		return
+	}
+	c.Next()
+}
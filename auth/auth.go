@@ -3,8 +3,11 @@ package auth
 import (
 	"errors"
 	"net/http"
-	"os"
 	"strings"
+	"sync"
+	"time"
+	"urllite/security"
+	"urllite/service"
 	"urllite/store"
 	"urllite/types"
 	"urllite/types/dtos"
@@ -21,13 +24,9 @@ func UserAuthentication(c *gin.Context) {
 		return
 	}
 
-	jwtKey := os.Getenv("ACCESS_TOKEN_SECRET_KEY")
-
 	tokenString := strings.TrimSpace(authHeader[7:])
 	claims := &dtos.JWTClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(jwtKey), nil
-	})
+	token, err := security.JWTKeys().Parse(tokenString, claims)
 
 	if err != nil {
 		// Token might be expired or malformed
@@ -42,21 +41,40 @@ func UserAuthentication(c *gin.Context) {
 		return
 	}
 
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	if service.IsUserSessionRevoked(claims.UserId, issuedAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "Account is suspended or deleted"})
+		c.Abort()
+		return
+	}
+
+	// The revocation lives in redis and expires, the users table has the
+	// final say on the status.
+	user, err := authStore().GetUserByID(claims.UserId)
+	if err != nil || user == nil || !user.IsActive() {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "Account is suspended or deleted"})
+		c.Abort()
+		return
+	}
+
 	c.Set("current_username", claims.Username)
 	c.Set("current_user_email", claims.Email)
 	c.Set("current_user_id", claims.UserId)
-	c.Set("current_user_role", claims.Role)
+	c.Set("current_user_role", user.Role)
 	c.Next()
 }
 
 func AdminAuthentication(c *gin.Context) {
-	currentUserRole, ok := c.Get("current_user_role")
+	currentUserRole, ok := currentRole(c)
 	if !ok {
 		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"status": "failed", "Message": "role not found in the context"})
 		return
 	}
 
-	if currentUserRole.(string) == "admin" {
+	if types.IsAdminRole(currentUserRole) {
 		c.Next()
 	} else {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "failed", "Message": "You are not an admin"})
@@ -64,6 +82,71 @@ func AdminAuthentication(c *gin.Context) {
 	}
 }
 
+// RequirePermission allows the request only when the role of the current
+// user grants the permission.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentUserRole, ok := currentRole(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"status": "failed", "message": "role not found in the context"})
+			return
+		}
+
+		if !types.HasPermission(currentUserRole, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "message": "You do not have the " + permission + " permission"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSelfOrPermission lets users reach their own record through the :id
+// route parameter, other records need the permission.
+func RequireSelfOrPermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentUserRole, ok := currentRole(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"status": "failed", "message": "role not found in the context"})
+			return
+		}
+
+		if c.Param("id") != c.GetString("current_user_id") && !types.HasPermission(currentUserRole, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "failed", "message": "You do not have the " + permission + " permission"})
+			return
+		}
+		c.Next()
+	}
+}
+
+var (
+	roleStore     store.Store
+	roleStoreOnce sync.Once
+)
+
+func authStore() store.Store {
+	roleStoreOnce.Do(func() {
+		roleStore = store.NewStore()
+	})
+	return roleStore
+}
+
+// currentRole reads the role from the users table rather than the token
+// claims, so a revoked role stops working before the token expires.
+func currentRole(c *gin.Context) (string, bool) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		return "", false
+	}
+
+	user, err := authStore().GetUserByID(currentUserID.(string))
+	if err != nil || user == nil {
+		return "", false
+	}
+
+	c.Set("current_user_role", user.Role)
+	return user.Role, true
+}
+
 func CurrentUserFromContext(c *gin.Context) *types.User {
 	store := store.NewStore()
 
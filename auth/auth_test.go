@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"urllite/store"
+	"urllite/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gocql/gocql"
+)
+
+type fakeRoleStore struct {
+	store.Store
+	users map[string]*types.User
+}
+
+func (s *fakeRoleStore) GetUserByID(id string) (*types.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return user, nil
+}
+
+// useRoles makes currentRole read the users from memory.
+func useRoles(t *testing.T, users ...*types.User) {
+	t.Helper()
+	roleStoreOnce.Do(func() {})
+	previous := roleStore
+	fake := &fakeRoleStore{users: map[string]*types.User{}}
+	for _, user := range users {
+		fake.users[user.ID.String()] = user
+	}
+	roleStore = fake
+	t.Cleanup(func() { roleStore = previous })
+}
+
+// serve runs the middleware for a request on /user/:id made by the caller.
+func serve(middleware gin.HandlerFunc, callerID, targetID string) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/user/:id", func(c *gin.Context) {
+		c.Set("current_user_id", callerID)
+		c.Next()
+	}, middleware, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/"+targetID, nil))
+	return w.Code
+}
+
+func TestRequireSelfOrPermission(t *testing.T) {
+	user := &types.User{ID: gocql.TimeUUID(), Role: types.RoleUser}
+	other := &types.User{ID: gocql.TimeUUID(), Role: types.RoleUser}
+	support := &types.User{ID: gocql.TimeUUID(), Role: types.RoleSupport}
+	admin := &types.User{ID: gocql.TimeUUID(), Role: types.RoleAdmin}
+	useRoles(t, user, other, support, admin)
+
+	tests := []struct {
+		name       string
+		permission string
+		caller     *types.User
+		target     *types.User
+		wantStatus int
+	}{
+		{name: "own record", permission: types.PermissionUsersWrite, caller: user, target: user, wantStatus: http.StatusOK},
+		{name: "record of another user", permission: types.PermissionUsersRead, caller: user, target: other, wantStatus: http.StatusForbidden},
+		{name: "support reads another user", permission: types.PermissionUsersRead, caller: support, target: other, wantStatus: http.StatusOK},
+		{name: "support cannot write another user", permission: types.PermissionUsersWrite, caller: support, target: other, wantStatus: http.StatusForbidden},
+		{name: "admin writes another user", permission: types.PermissionUsersWrite, caller: admin, target: other, wantStatus: http.StatusOK},
+		{name: "unknown caller", permission: types.PermissionUsersRead, caller: &types.User{ID: gocql.TimeUUID()}, target: other, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := serve(RequireSelfOrPermission(tt.permission), tt.caller.ID.String(), tt.target.ID.String())
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	users := map[string]*types.User{}
+	for _, role := range []string{types.RoleUser, types.RoleAnalyst, types.RoleSupport, types.RoleAdmin, types.RoleOwner} {
+		users[role] = &types.User{ID: gocql.TimeUUID(), Role: role}
+	}
+	useRoles(t, users[types.RoleUser], users[types.RoleAnalyst], users[types.RoleSupport], users[types.RoleAdmin], users[types.RoleOwner])
+
+	tests := []struct {
+		role       string
+		permission string
+		wantStatus int
+	}{
+		{role: types.RoleUser, permission: types.PermissionStatsRead, wantStatus: http.StatusForbidden},
+		{role: types.RoleAnalyst, permission: types.PermissionStatsRead, wantStatus: http.StatusOK},
+		{role: types.RoleAnalyst, permission: types.PermissionUsersRead, wantStatus: http.StatusForbidden},
+		{role: types.RoleSupport, permission: types.PermissionUsersRead, wantStatus: http.StatusOK},
+		{role: types.RoleSupport, permission: types.PermissionStatsRead, wantStatus: http.StatusForbidden},
+		{role: types.RoleAdmin, permission: types.PermissionRolesManage, wantStatus: http.StatusOK},
+		{role: types.RoleOwner, permission: types.PermissionUsersDelete, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.permission, func(t *testing.T) {
+			caller := users[tt.role].ID.String()
+			if status := serve(RequirePermission(tt.permission), caller, caller); status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
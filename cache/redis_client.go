@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,6 +15,33 @@ type RedisClient interface {
 	Set(key string, value string, expiration time.Duration) error
 	Get(key string) (string, error)
 	Exists(key string) (bool, error)
+	Delete(key string) error
+	DeleteMatching(pattern string) error
+	Increment(key string, expiration time.Duration) (int64, error)
+	SetIfAbsent(key string, value string, expiration time.Duration) (bool, error)
+	AddUnique(key string, element string, expiration time.Duration) error
+	CountUnique(keys ...string) (int64, error)
+	Publish(channel string, message string) error
+	Subscribe(channels ...string) (Subscription, error)
+	IncrementScore(key string, member string, by float64) error
+	TopScores(key string, limit int64) ([]ScoredMember, error)
+	SetScore(key string, member string, score float64) error
+	RemoveMember(key string, member string) error
+	PushCapped(key string, value string, limit int64) error
+	Range(key string, limit int64) ([]string, error)
+}
+
+// ScoredMember is a member of a sorted set with its score.
+type ScoredMember struct {
+	Member string
+	Score  float64
+}
+
+// Subscription delivers the messages published on the subscribed channels
+// until it is closed.
+type Subscription interface {
+	Messages() <-chan string
+	Close() error
 }
 
 type redisClient struct {
@@ -24,7 +52,7 @@ type redisClient struct {
 func InitRedis(ctx context.Context) RedisClient {
 	db, err := strconv.Atoi(os.Getenv("REDIS_DB"))
 	if err != nil {
-		log.Panic("Failed to get redis db from env: %v", err)
+		log.Panicf("Failed to get redis db from env: %v", err)
 		return &redisClient{}
 	}
 	client := redis.NewClient(&redis.Options{
@@ -37,7 +65,7 @@ func InitRedis(ctx context.Context) RedisClient {
 	// Ping to test connection
 	_, err = client.Ping(ctx).Result()
 	if err != nil {
-		log.Panic("Failed to connect to Redis: %v", err)
+		log.Panicf("Failed to connect to Redis: %v", err)
 		return &redisClient{}
 	}
 
@@ -56,3 +84,138 @@ func (rc *redisClient) Exists(key string) (bool, error) {
 	count, err := rc.Client.Exists(rc.Context, key).Result()
 	return count > 0, err
 }
+
+func (rc *redisClient) Delete(key string) error {
+	return rc.Client.Del(rc.Context, key).Err()
+}
+
+// DeleteMatching deletes the keys matching the glob pattern, it scans the
+// keyspace so it is meant for rare cleanups.
+func (rc *redisClient) DeleteMatching(pattern string) error {
+	iter := rc.Client.Scan(rc.Context, 0, pattern, 100).Iterator()
+	for iter.Next(rc.Context) {
+		if err := rc.Client.Del(rc.Context, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// Increment adds one to the counter, the expiration is set when the counter
+// is created so it counts within a fixed window.
+func (rc *redisClient) Increment(key string, expiration time.Duration) (int64, error) {
+	count, err := rc.Client.Incr(rc.Context, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		err = rc.Client.Expire(rc.Context, key, expiration).Err()
+	}
+	return count, err
+}
+
+func (rc *redisClient) SetIfAbsent(key string, value string, expiration time.Duration) (bool, error) {
+	return rc.Client.SetNX(rc.Context, key, value, expiration).Result()
+}
+
+// AddUnique adds the element to a HyperLogLog, an expiration of zero keeps
+// the key forever.
+func (rc *redisClient) AddUnique(key string, element string, expiration time.Duration) error {
+	err := rc.Client.PFAdd(rc.Context, key, element).Err()
+	if err != nil || expiration == 0 {
+		return err
+	}
+	return rc.Client.Expire(rc.Context, key, expiration).Err()
+}
+
+// CountUnique estimates the number of distinct elements in the union of the
+// HyperLogLogs.
+func (rc *redisClient) CountUnique(keys ...string) (int64, error) {
+	return rc.Client.PFCount(rc.Context, keys...).Result()
+}
+
+func (rc *redisClient) Publish(channel string, message string) error {
+	return rc.Client.Publish(rc.Context, channel, message).Err()
+}
+
+func (rc *redisClient) Subscribe(channels ...string) (Subscription, error) {
+	pubsub := rc.Client.Subscribe(rc.Context, channels...)
+	// Wait for the confirmation so no message published afterwards is lost.
+	if _, err := pubsub.Receive(rc.Context); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	sub := &subscription{pubsub: pubsub, messages: make(chan string), done: make(chan struct{})}
+	go func() {
+		defer close(sub.messages)
+		for message := range pubsub.Channel() {
+			select {
+			case sub.messages <- message.Payload:
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func (rc *redisClient) IncrementScore(key string, member string, by float64) error {
+	return rc.Client.ZIncrBy(rc.Context, key, by, member).Err()
+}
+
+// TopScores returns the members of a sorted set with the highest scores.
+func (rc *redisClient) TopScores(key string, limit int64) ([]ScoredMember, error) {
+	results, err := rc.Client.ZRevRangeWithScores(rc.Context, key, 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	members := make([]ScoredMember, 0, len(results))
+	for _, result := range results {
+		member, _ := result.Member.(string)
+		members = append(members, ScoredMember{Member: member, Score: result.Score})
+	}
+	return members, nil
+}
+
+// SetScore adds the member to a sorted set or replaces its score.
+func (rc *redisClient) SetScore(key string, member string, score float64) error {
+	return rc.Client.ZAdd(rc.Context, key, redis.Z{Score: score, Member: member}).Err()
+}
+
+func (rc *redisClient) RemoveMember(key string, member string) error {
+	return rc.Client.ZRem(rc.Context, key, member).Err()
+}
+
+// PushCapped prepends the value to a list and drops the oldest entries
+// beyond the limit.
+func (rc *redisClient) PushCapped(key string, value string, limit int64) error {
+	pipe := rc.Client.TxPipeline()
+	pipe.LPush(rc.Context, key, value)
+	pipe.LTrim(rc.Context, key, 0, limit-1)
+	_, err := pipe.Exec(rc.Context)
+	return err
+}
+
+// Range returns up to limit entries from the head of a list.
+func (rc *redisClient) Range(key string, limit int64) ([]string, error) {
+	return rc.Client.LRange(rc.Context, key, 0, limit-1).Result()
+}
+
+type subscription struct {
+	pubsub    *redis.PubSub
+	messages  chan string
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *subscription) Messages() <-chan string {
+	return s.messages
+}
+
+func (s *subscription) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return s.pubsub.Close()
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"urllite/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deepLinkPage tries to open the app and falls back to the web destination
+// when nothing handled the app uri in time.
+var deepLinkPage = template.Must(template.New("deep_link").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Opening…</title>
+</head>
+<body style="font-family: sans-serif; text-align: center; padding: 48px 16px;">
+<p>Opening the app…</p>
+<p><a href="{{.AppHref}}">Open in app</a></p>
+{{if .StoreURL}}<p><a href="{{.StoreURL}}">Get the app</a></p>{{end}}
+<p><a href="{{.WebURL}}">Continue to the website</a></p>
+<p style="font-size: small;"><a href="{{.ReportURL}}" rel="nofollow">Report this link</a></p>
+<script>
+(function () {
+	var fallback = setTimeout(function () { window.location.replace({{.WebURL}}); }, 1500);
+	document.addEventListener("visibilitychange", function () {
+		if (document.hidden) { clearTimeout(fallback); }
+	});
+	window.location.href = {{.AppURI}};
+})();
+</script>
+</body>
+</html>
+`))
+
+type deepLinkPageData struct {
+	AppURI    string
+	AppHref   template.URL
+	StoreURL  string
+	WebURL    string
+	ReportURL string
+}
+
+// renderDeepLinkPage serves the interstitial. The app uri was checked for
+// unsafe schemes when the link was saved, so it may be used as a link target.
+func renderDeepLinkPage(c *gin.Context, url *types.URL, appLink *types.AppLink, webURL string) {
+	appHref := template.URL(appLink.URI)
+	if appLink.UniversalLink != "" {
+		appHref = template.URL(appLink.UniversalLink)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	deepLinkPage.Execute(c.Writer, deepLinkPageData{
+		AppURI:    appLink.URI,
+		AppHref:   appHref,
+		StoreURL:  appLink.StoreURL,
+		WebURL:    webURL,
+		ReportURL: reportUrl(url),
+	})
+}
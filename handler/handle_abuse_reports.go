@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AbuseReportHandler interface {
+	ReportPage(c *gin.Context)
+	Report(c *gin.Context)
+	GetReports(c *gin.Context)
+	GetReportByID(c *gin.Context)
+	Review(c *gin.Context)
+}
+
+type abuseReportHandler struct {
+	abuseReportService service.AbuseReportService
+	auditLogService    service.AuditLogService
+}
+
+func NewAbuseReportHandler() AbuseReportHandler {
+	abuseReportService := service.NewAbuseReportService()
+	auditLogService := service.NewAuditLogService()
+	return &abuseReportHandler{abuseReportService: abuseReportService, auditLogService: auditLogService}
+}
+
+func (h *abuseReportHandler) ReportPage(c *gin.Context) {
+	renderReportPage(c, c.Param("short_url"), false)
+}
+
+// Report takes a json body from api clients and the form of the report page,
+// which gets the page back instead of json.
+func (h *abuseReportHandler) Report(c *gin.Context) {
+	var reportDto dtos.AbuseReportDTO
+	err := c.ShouldBind(&reportDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	report, appErr := h.abuseReportService.Report(c.Request.Host, c.Param("short_url"), c.ClientIP(), reportDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	if c.ContentType() == "application/x-www-form-urlencoded" {
+		renderReportPage(c, report.ShortUrl, true)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "Report received, thank you"})
+}
+
+func (h *abuseReportHandler) GetReports(c *gin.Context) {
+	var filter dtos.AbuseReportFilterDTO
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid query parameters", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	reports, appErr := h.abuseReportService.GetReports(filter)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Abuse reports fetched successfully", "result": gin.H{"reports": reports}})
+}
+
+func (h *abuseReportHandler) GetReportByID(c *gin.Context) {
+	report, appErr := h.abuseReportService.GetReportByID(c.Param("id"))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Abuse report fetched successfully", "result": gin.H{"report": report}})
+}
+
+func (h *abuseReportHandler) Review(c *gin.Context) {
+	var reviewDto dtos.AbuseReviewDTO
+	err := c.ShouldBindJSON(&reviewDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	reportID := c.Param("id")
+	before, _ := h.abuseReportService.GetReportByID(reportID)
+	report, appErr := h.abuseReportService.Review(reportID, currentUserID.(string), reviewDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	h.auditLogService.Record(auditEntry(c, types.AuditAbuseReportReviewed, types.AuditTargetAbuseReport, reportID), before, report)
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Abuse report reviewed successfully", "result": gin.H{"report": report}})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler interface {
+	GetStats(c *gin.Context)
+	GetTopStats(c *gin.Context)
+	GetQueueStats(c *gin.Context)
+	GetRecentErrors(c *gin.Context)
+}
+
+type adminHandler struct {
+	adminService service.AdminService
+}
+
+func NewAdminHandler() AdminHandler {
+	adminService := service.NewAdminService()
+	return &adminHandler{adminService: adminService}
+}
+
+// RecordServerErrors keeps the 5xx responses for the admin dashboard, with
+// the error the handler gave when there is one.
+func RecordServerErrors(c *gin.Context) {
+	c.Next()
+
+	if c.Writer.Status() < http.StatusInternalServerError {
+		return
+	}
+	message := http.StatusText(c.Writer.Status())
+	if lastErr := c.Errors.Last(); lastErr != nil {
+		message = lastErr.Error()
+		if description, ok := lastErr.Meta.(string); ok {
+			message = description + ": " + message
+		}
+	}
+	service.RecordError(c.Request.Method+" "+c.FullPath(), message)
+}
+
+func (h *adminHandler) bindFilter(c *gin.Context) (dtos.AdminStatsFilterDTO, bool) {
+	var filter dtos.AdminStatsFilterDTO
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid query parameters", "result": gin.H{"error": err.Error()}})
+		return filter, false
+	}
+	return filter, true
+}
+
+func (h *adminHandler) GetStats(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	stats, appErr := h.adminService.GetStats(filter)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Stats fetched successfully", "result": gin.H{"stats": stats}})
+}
+
+func (h *adminHandler) GetTopStats(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	topLinks, appErr := h.adminService.GetTopLinks(filter)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	topUsers, appErr := h.adminService.GetTopUsers(filter)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Top links and users fetched successfully", "result": gin.H{"links": topLinks, "users": topUsers}})
+}
+
+func (h *adminHandler) GetQueueStats(c *gin.Context) {
+	queueStats, appErr := h.adminService.GetQueueStats()
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Queue stats fetched successfully", "result": gin.H{"queue": queueStats}})
+}
+
+func (h *adminHandler) GetRecentErrors(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	recordedErrors, appErr := h.adminService.GetRecentErrors(filter)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Recent errors fetched successfully", "result": gin.H{"errors": recordedErrors}})
+}
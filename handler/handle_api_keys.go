@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ApiKeyHandler interface {
+	Create(c *gin.Context)
+	GetApiKeys(c *gin.Context)
+	Revoke(c *gin.Context)
+}
+
+type apiKeyHandler struct {
+	apiKeyService   service.ApiKeyService
+	auditLogService service.AuditLogService
+}
+
+func NewApiKeyHandler() ApiKeyHandler {
+	apiKeyService := service.NewApiKeyService()
+	auditLogService := service.NewAuditLogService()
+	return &apiKeyHandler{apiKeyService: apiKeyService, auditLogService: auditLogService}
+}
+
+func (h *apiKeyHandler) Create(c *gin.Context) {
+	var apiKeyDto dtos.ApiKeyDTO
+	err := c.ShouldBindJSON(&apiKeyDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	apiKey, rawKey, appErr := h.apiKeyService.Create(currentUserID.(string), apiKeyDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	h.auditLogService.Record(auditEntry(c, types.AuditApiKeyCreated, types.AuditTargetApiKey, apiKey.ID.String()), nil, apiKey)
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "message": "Api key created successfully. Store it safely, it will not be shown again", "result": gin.H{"api_key": apiKey, "key": rawKey}})
+}
+
+func (h *apiKeyHandler) GetApiKeys(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	apiKeys, appErr := h.apiKeyService.GetApiKeysOfUser(currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Api keys fetched successfully", "result": gin.H{"api_keys": apiKeys}})
+}
+
+func (h *apiKeyHandler) Revoke(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	apiKeyID := c.Param("id")
+	before := h.apiKeyOfUser(apiKeyID, currentUserID.(string))
+	appErr := h.apiKeyService.Revoke(apiKeyID, currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	h.auditLogService.Record(auditEntry(c, types.AuditApiKeyRevoked, types.AuditTargetApiKey, apiKeyID), before, h.apiKeyOfUser(apiKeyID, currentUserID.(string)))
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Api key revoked successfully"})
+}
+
+// apiKeyOfUser returns the key for the audit log, nil when the user has no
+// key with the id.
+func (h *apiKeyHandler) apiKeyOfUser(id, user_id string) *types.ApiKey {
+	apiKeys, appErr := h.apiKeyService.GetApiKeysOfUser(user_id)
+	if appErr != nil {
+		return nil
+	}
+	for _, apiKey := range apiKeys {
+		if apiKey.ID.String() == id {
+			return apiKey
+		}
+	}
+	return nil
+}
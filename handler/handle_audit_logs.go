@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gocql/gocql"
+)
+
+type AuditLogHandler interface {
+	GetAuditLogs(c *gin.Context)
+}
+
+type auditLogHandler struct {
+	auditLogService service.AuditLogService
+}
+
+func NewAuditLogHandler() AuditLogHandler {
+	auditLogService := service.NewAuditLogService()
+	return &auditLogHandler{auditLogService: auditLogService}
+}
+
+// auditEntry starts an audit log entry for the request, the logged in user
+// is the actor when there is one.
+func auditEntry(c *gin.Context, action, targetType, targetID string) *types.AuditLog {
+	auditLog := &types.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+	if currentUserID, ok := c.Get("current_user_id"); ok {
+		auditLog.ActorID, _ = gocql.ParseUUID(currentUserID.(string))
+	}
+	return auditLog
+}
+
+func (h *auditLogHandler) GetAuditLogs(c *gin.Context) {
+	var filter dtos.AuditLogFilterDTO
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid query parameters", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	auditLogs, appErr := h.auditLogService.GetAuditLogs(filter)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Audit logs fetched successfully", "result": gin.H{"audit_logs": auditLogs}})
+}
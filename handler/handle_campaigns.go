@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CampaignHandler interface {
+	Create(c *gin.Context)
+	GetCampaigns(c *gin.Context)
+	GetCampaignByID(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	AddUrls(c *gin.Context)
+	RemoveUrl(c *gin.Context)
+	GetReport(c *gin.Context)
+}
+
+type campaignHandler struct {
+	campaignService service.CampaignService
+}
+
+func NewCampaignHandler() CampaignHandler {
+	campaignService := service.NewCampaignService()
+	return &campaignHandler{campaignService: campaignService}
+}
+
+func (h *campaignHandler) Create(c *gin.Context) {
+	var campaignDto dtos.CampaignDTO
+	err := c.ShouldBindJSON(&campaignDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	campaign, appErr := h.campaignService.Create(campaignDto, currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "message": "Campaign created successfully", "result": gin.H{"campaign": campaign}})
+}
+
+func (h *campaignHandler) GetCampaigns(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	campaigns, appErr := h.campaignService.GetCampaignsOfUser(currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Campaigns fetched successfully", "result": gin.H{"campaigns": campaigns}})
+}
+
+func (h *campaignHandler) GetCampaignByID(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	campaign, urls, appErr := h.campaignService.GetCampaignByID(c.Param("id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Campaign fetched successfully", "result": gin.H{"campaign": campaign, "urls": urls}})
+}
+
+func (h *campaignHandler) Update(c *gin.Context) {
+	var campaignDto dtos.CampaignDTO
+	err := c.ShouldBindJSON(&campaignDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	campaign, appErr := h.campaignService.Update(c.Param("id"), currentUserID.(string), campaignDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Campaign updated successfully", "result": gin.H{"campaign": campaign}})
+}
+
+func (h *campaignHandler) Delete(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.campaignService.Delete(c.Param("id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Campaign deleted successfully"})
+}
+
+func (h *campaignHandler) AddUrls(c *gin.Context) {
+	var urlsDto dtos.CampaignUrlsDTO
+	err := c.ShouldBindJSON(&urlsDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.campaignService.AddUrls(c.Param("id"), currentUserID.(string), urlsDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Urls added to campaign successfully"})
+}
+
+func (h *campaignHandler) RemoveUrl(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.campaignService.RemoveUrl(c.Param("id"), currentUserID.(string), c.Param("url_id"))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Url removed from campaign successfully"})
+}
+
+func (h *campaignHandler) GetReport(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	var filter dtos.AnalyticsFilterDTO
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid query parameters", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	report, appErr := h.campaignService.GetReport(c.Param("id"), currentUserID.(string), filter)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Campaign report fetched successfully", "result": gin.H{"report": report}})
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DomainHandler interface {
+	Create(c *gin.Context)
+	GetDomains(c *gin.Context)
+	Verify(c *gin.Context)
+	Delete(c *gin.Context)
+	UpdateApps(c *gin.Context)
+}
+
+type domainHandler struct {
+	domainService service.DomainService
+}
+
+func NewDomainHandler() DomainHandler {
+	domainService := service.NewDomainService()
+	return &domainHandler{domainService: domainService}
+}
+
+func (h *domainHandler) Create(c *gin.Context) {
+	var domainDto dtos.DomainDTO
+	err := c.ShouldBindJSON(&domainDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	domain, appErr := h.domainService.Create(domainDto, currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "message": "Domain added, add the TXT record and verify it", "result": gin.H{"domain": domain, "verification": verificationRecord(domain)}})
+}
+
+func (h *domainHandler) GetDomains(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	domains, appErr := h.domainService.GetDomainsOfUser(currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Domains fetched successfully", "result": gin.H{"domains": domains}})
+}
+
+func (h *domainHandler) Verify(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	domain, appErr := h.domainService.Verify(c.Param("id"), currentUserID.(string), c.Request.Context())
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Domain verified successfully", "result": gin.H{"domain": domain}})
+}
+
+func (h *domainHandler) Delete(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.domainService.Delete(c.Param("id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Domain deleted successfully"})
+}
+
+func (h *domainHandler) UpdateApps(c *gin.Context) {
+	var appsDto dtos.DomainAppsDTO
+	err := c.ShouldBindJSON(&appsDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	domain, appErr := h.domainService.UpdateApps(c.Param("id"), currentUserID.(string), appsDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Domain apps updated successfully", "result": gin.H{"domain": domain}})
+}
+
+func verificationRecord(domain *types.Domain) gin.H {
+	name, value := domain.VerificationRecord()
+	return gin.H{"type": "TXT", "name": name, "value": value}
+}
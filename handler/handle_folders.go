@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FolderHandler interface {
+	Create(c *gin.Context)
+	GetFolders(c *gin.Context)
+	Rename(c *gin.Context)
+	Delete(c *gin.Context)
+	GetFolderAnalytics(c *gin.Context)
+}
+
+type folderHandler struct {
+	folderService service.FolderService
+}
+
+func NewFolderHandler() FolderHandler {
+	folderService := service.NewFolderService()
+	return &folderHandler{folderService: folderService}
+}
+
+func (h *folderHandler) Create(c *gin.Context) {
+	var folderDto dtos.FolderDTO
+	err := c.ShouldBindJSON(&folderDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	folder, appErr := h.folderService.Create(folderDto, currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "message": "Folder created successfully", "result": gin.H{"folder": folder}})
+}
+
+func (h *folderHandler) GetFolders(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	folders, appErr := h.folderService.GetFoldersOfUser(currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Folders fetched successfully", "result": gin.H{"folders": folders}})
+}
+
+func (h *folderHandler) Rename(c *gin.Context) {
+	var folderDto dtos.FolderDTO
+	err := c.ShouldBindJSON(&folderDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	folder, appErr := h.folderService.Rename(c.Param("id"), currentUserID.(string), folderDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Folder renamed successfully", "result": gin.H{"folder": folder}})
+}
+
+func (h *folderHandler) Delete(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.folderService.Delete(c.Param("id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Folder deleted successfully"})
+}
+
+func (h *folderHandler) GetFolderAnalytics(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	var filter dtos.AnalyticsFilterDTO
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid query parameters", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	analytics, appErr := h.folderService.GetFolderAnalytics(c.Param("id"), currentUserID.(string), filter)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Folder analytics fetched successfully", "result": gin.H{"analytics": analytics}})
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"time"
+	"urllite/cache"
+	"urllite/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	liveHeartbeatInterval = 15 * time.Second
+	// Streams are closed after an hour so clients reconnect and their
+	// credentials are checked again.
+	liveStreamMaxDuration = time.Hour
+)
+
+type LiveHandler interface {
+	UrlClicks(c *gin.Context)
+	UserClicks(c *gin.Context)
+}
+
+type liveHandler struct {
+	urlService       service.UrlService
+	liveClickService service.LiveClickService
+}
+
+func NewLiveHandler() LiveHandler {
+	urlService := service.NewUrlService()
+	liveClickService := service.NewLiveClickService()
+	return &liveHandler{urlService: urlService, liveClickService: liveClickService}
+}
+
+func (h *liveHandler) UrlClicks(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	url, appErr := h.urlService.GetUrlByID(c.Param("id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	subscription, appErr := h.liveClickService.SubscribeUrl(url)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	streamClicks(c, subscription)
+}
+
+func (h *liveHandler) UserClicks(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	subscription, appErr := h.liveClickService.SubscribeUser(currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	streamClicks(c, subscription)
+}
+
+// streamClicks sends every published log as a "click" event and a
+// "heartbeat" event while the stream is idle so proxies keep it open.
+func streamClicks(c *gin.Context, subscription cache.Subscription) {
+	defer subscription.Close()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(liveHeartbeatInterval)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(liveStreamMaxDuration)
+	defer deadline.Stop()
+
+	c.SSEvent("ready", gin.H{"heartbeat_interval": int(liveHeartbeatInterval.Seconds())})
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-deadline.C:
+			return false
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", time.Now().Unix())
+			return true
+		case message, ok := <-subscription.Messages():
+			if !ok {
+				return false
+			}
+			c.SSEvent("click", message)
+			heartbeat.Reset(liveHeartbeatInterval)
+			return true
+		}
+	})
+}
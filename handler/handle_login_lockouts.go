@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginLockoutHandler interface {
+	GetLockouts(c *gin.Context)
+	GetLockout(c *gin.Context)
+	ClearLockout(c *gin.Context)
+}
+
+type loginLockoutHandler struct {
+	loginProtectionService service.LoginProtectionService
+	auditLogService        service.AuditLogService
+}
+
+func NewLoginLockoutHandler() LoginLockoutHandler {
+	loginProtectionService := service.NewLoginProtectionService()
+	auditLogService := service.NewAuditLogService()
+	return &loginLockoutHandler{loginProtectionService: loginProtectionService, auditLogService: auditLogService}
+}
+
+func (h *loginLockoutHandler) GetLockouts(c *gin.Context) {
+	lockouts, appErr := h.loginProtectionService.GetLockouts()
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Lockouts fetched successfully", "result": gin.H{"lockouts": lockouts}})
+}
+
+func (h *loginLockoutHandler) GetLockout(c *gin.Context) {
+	lockout, appErr := h.loginProtectionService.GetLockout(c.Param("user_id"))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Lockout fetched successfully", "result": gin.H{"lockout": lockout}})
+}
+
+func (h *loginLockoutHandler) ClearLockout(c *gin.Context) {
+	userID := c.Param("user_id")
+	before, appErr := h.loginProtectionService.GetLockout(userID)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	appErr = h.loginProtectionService.ClearLockout(userID)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	after, _ := h.loginProtectionService.GetLockout(userID)
+	h.auditLogService.Record(auditEntry(c, types.AuditLockoutCleared, types.AuditTargetUser, userID), before, after)
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Lockout cleared successfully"})
+}
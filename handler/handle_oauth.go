@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthHandler interface {
+	Login(c *gin.Context)
+	Callback(c *gin.Context)
+}
+
+type oauthHandler struct {
+	oauthService           service.OAuthService
+	userService            service.UserService
+	twoFactorService       service.TwoFactorService
+	auditLogService        service.AuditLogService
+	loginProtectionService service.LoginProtectionService
+}
+
+func NewOAuthHandler() OAuthHandler {
+	oauthService := service.NewOAuthService()
+	userService := service.NewUserService()
+	twoFactorService := service.NewTwoFactorService()
+	auditLogService := service.NewAuditLogService()
+	loginProtectionService := service.NewLoginProtectionService()
+	return &oauthHandler{oauthService: oauthService, userService: userService, twoFactorService: twoFactorService, auditLogService: auditLogService, loginProtectionService: loginProtectionService}
+}
+
+func (h *oauthHandler) Login(c *gin.Context) {
+	redirectUrl, appErr := h.oauthService.BeginLogin(c.Param("provider"), c.Request.Context())
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectUrl)
+}
+
+func (h *oauthHandler) Callback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "Login was cancelled or denied", "result": gin.H{"error": providerErr}})
+		return
+	}
+
+	user, appErr := h.oauthService.CompleteLogin(c.Param("provider"), c.Query("state"), c.Query("code"), c.Request.Context())
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	twoFactorEnabled, appErr := h.twoFactorService.IsEnabled(user.ID.String())
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	if twoFactorEnabled {
+		challengeToken, appErr := h.twoFactorService.CreateChallenge(user, c.Request.Context())
+		if appErr != nil {
+			appErr.HttpResponse(c)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Two factor authentication required", "two_factor_required": true, "challenge_token": challengeToken})
+		return
+	}
+
+	accessToken, appErr := h.userService.GenerateUserAccessToken(user, c.Request.Context())
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	auditLog := auditEntry(c, types.AuditLoginSucceeded, types.AuditTargetUser, user.ID.String())
+	auditLog.ActorID, auditLog.Detail = user.ID, "oauth "+c.Param("provider")
+	h.auditLogService.Record(auditLog, nil, nil)
+	h.loginProtectionService.NotifyNewLoginSource(user, c.ClientIP(), c.Request.UserAgent())
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Token generated", "access_token": accessToken, "verified_email": user.Email == user.VerifiedEmail})
+}
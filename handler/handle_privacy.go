@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PrivacyHandler interface {
+	GetSettings(c *gin.Context)
+	UpdateSettings(c *gin.Context)
+}
+
+type privacyHandler struct {
+	privacyService service.PrivacyService
+}
+
+func NewPrivacyHandler() PrivacyHandler {
+	privacyService := service.NewPrivacyService()
+	return &privacyHandler{privacyService: privacyService}
+}
+
+func (h *privacyHandler) GetSettings(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	settings, appErr := h.privacyService.GetSettings(currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Privacy settings fetched successfully", "result": gin.H{"settings": settings}})
+}
+
+func (h *privacyHandler) UpdateSettings(c *gin.Context) {
+	var settingsDto dtos.PrivacySettingsDTO
+	err := c.ShouldBindJSON(&settingsDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	settings, appErr := h.privacyService.UpdateSettings(currentUserID.(string), settingsDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Privacy settings updated successfully", "result": gin.H{"settings": settings}})
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagHandler works on personal links by default, a workspace_id query
+// parameter switches to the links of that workspace.
+type TagHandler interface {
+	GetTags(c *gin.Context)
+	RenameTag(c *gin.Context)
+	DeleteTag(c *gin.Context)
+	GetTagAnalytics(c *gin.Context)
+}
+
+type tagHandler struct {
+	tagService service.TagService
+}
+
+func NewTagHandler() TagHandler {
+	tagService := service.NewTagService()
+	return &tagHandler{tagService: tagService}
+}
+
+func (h *tagHandler) GetTags(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	tags, appErr := h.tagService.GetTags(currentUserID.(string), c.Query("workspace_id"))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Tags fetched successfully", "result": gin.H{"tags": tags}})
+}
+
+func (h *tagHandler) RenameTag(c *gin.Context) {
+	var tagDto dtos.TagDTO
+	err := c.ShouldBindJSON(&tagDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.tagService.RenameTag(currentUserID.(string), c.Query("workspace_id"), c.Param("tag"), tagDto.Name)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Tag renamed successfully"})
+}
+
+func (h *tagHandler) DeleteTag(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.tagService.DeleteTag(currentUserID.(string), c.Query("workspace_id"), c.Param("tag"))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Tag removed from all links"})
+}
+
+func (h *tagHandler) GetTagAnalytics(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	var filter dtos.AnalyticsFilterDTO
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid query parameters", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	analytics, appErr := h.tagService.GetTagAnalytics(currentUserID.(string), c.Query("workspace_id"), c.Param("tag"), filter)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Tag analytics fetched successfully", "result": gin.H{"analytics": analytics}})
+}
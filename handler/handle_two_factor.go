@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler interface {
+	Enroll(c *gin.Context)
+	Confirm(c *gin.Context)
+	Disable(c *gin.Context)
+	Login(c *gin.Context)
+}
+
+type twoFactorHandler struct {
+	twoFactorService       service.TwoFactorService
+	userService            service.UserService
+	auditLogService        service.AuditLogService
+	loginProtectionService service.LoginProtectionService
+}
+
+func NewTwoFactorHandler() TwoFactorHandler {
+	twoFactorService := service.NewTwoFactorService()
+	userService := service.NewUserService()
+	auditLogService := service.NewAuditLogService()
+	loginProtectionService := service.NewLoginProtectionService()
+	return &twoFactorHandler{twoFactorService: twoFactorService, userService: userService, auditLogService: auditLogService, loginProtectionService: loginProtectionService}
+}
+
+func (h *twoFactorHandler) Enroll(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	secret, provisioningUri, appErr := h.twoFactorService.Enroll(currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Scan the QR code with your authenticator app and confirm with a code", "result": gin.H{"secret": secret, "provisioning_uri": provisioningUri}})
+}
+
+func (h *twoFactorHandler) Confirm(c *gin.Context) {
+	var codeDto dtos.TwoFactorCodeDTO
+	err := c.ShouldBindJSON(&codeDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	before := h.twoFactorState(currentUserID.(string))
+	recoveryCodes, appErr := h.twoFactorService.Confirm(currentUserID.(string), codeDto.Code)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	h.auditLogService.Record(auditEntry(c, types.AuditTwoFactorEnabled, types.AuditTargetUser, currentUserID.(string)), before, h.twoFactorState(currentUserID.(string)))
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Two factor authentication enabled. Store the recovery codes safely, they will not be shown again", "result": gin.H{"recovery_codes": recoveryCodes}})
+}
+
+func (h *twoFactorHandler) Disable(c *gin.Context) {
+	var disableDto dtos.TwoFactorDisableDTO
+	err := c.ShouldBindJSON(&disableDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	before := h.twoFactorState(currentUserID.(string))
+	appErr := h.twoFactorService.Disable(currentUserID.(string), disableDto.Password)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	h.auditLogService.Record(auditEntry(c, types.AuditTwoFactorDisabled, types.AuditTargetUser, currentUserID.(string)), before, h.twoFactorState(currentUserID.(string)))
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Two factor authentication disabled"})
+}
+
+// twoFactorState is the audited value of the two factor settings, the
+// secret and the recovery codes stay out of the audit log.
+func (h *twoFactorHandler) twoFactorState(user_id string) gin.H {
+	enabled, appErr := h.twoFactorService.IsEnabled(user_id)
+	if appErr != nil {
+		return nil
+	}
+	return gin.H{"two_factor_enabled": enabled}
+}
+
+func (h *twoFactorHandler) Login(c *gin.Context) {
+	var loginDto dtos.TwoFactorLoginDTO
+	err := c.ShouldBindJSON(&loginDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	user, appErr := h.twoFactorService.CompleteChallenge(loginDto.ChallengeToken, loginDto.Code, loginDto.RecoveryCode, c.Request.Context())
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	accessToken, appErr := h.userService.GenerateUserAccessToken(user, c.Request.Context())
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	auditLog := auditEntry(c, types.AuditLoginSucceeded, types.AuditTargetUser, user.ID.String())
+	auditLog.ActorID, auditLog.Detail = user.ID, "two factor"
+	h.auditLogService.Record(auditLog, nil, nil)
+	h.loginProtectionService.NotifyNewLoginSource(user, c.ClientIP(), c.Request.UserAgent())
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Token generated", "access_token": accessToken, "verified_email": user.Email == user.VerifiedEmail})
+}
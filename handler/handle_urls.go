@@ -3,7 +3,9 @@ package handler
 import (
 	"net/http"
 	"urllite/service"
+	"urllite/types"
 	"urllite/types/dtos"
+	"urllite/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,18 +15,22 @@ type UrlHandler interface {
 	RedirectToLongUrl(c *gin.Context)
 	GetUrlByID(c *gin.Context)
 	GetURLs(c *gin.Context)
+	UpdateURLById(c *gin.Context)
 	DeleteURLById(c *gin.Context)
 	GetUrlLogsByUrl(c *gin.Context)
+	TransferUrl(c *gin.Context)
 }
 type urlHandler struct {
-	urlService    service.UrlService
-	urlLogService service.UrlLogService
+	urlService      service.UrlService
+	urlLogService   service.UrlLogService
+	auditLogService service.AuditLogService
 }
 
 func NewUrlHandler() UrlHandler {
 	urlService := service.NewUrlService()
 	logService := service.NewUrlLogService()
-	return &urlHandler{urlService: urlService, urlLogService: logService}
+	auditLogService := service.NewAuditLogService()
+	return &urlHandler{urlService: urlService, urlLogService: logService, auditLogService: auditLogService}
 }
 
 func (u *urlHandler) Create(c *gin.Context) {
@@ -39,18 +45,19 @@ func (u *urlHandler) Create(c *gin.Context) {
 	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "No user data found in the context"})
 	}
-	url, appErr := u.urlService.CreateUrl(urlDto.LongUrl, curent_user.(string))
+	url, appErr := u.urlService.CreateUrl(urlDto, curent_user.(string))
 	if appErr != nil {
 		appErr.HttpResponse(c)
 		return
 	}
+	u.auditLogService.Record(auditEntry(c, types.AuditUrlCreated, types.AuditTargetUrl, url.ID.String()), nil, url)
 
 	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "Url created successfully", "result": gin.H{"url": url}})
 }
 
 func (u *urlHandler) RedirectToLongUrl(c *gin.Context) {
 	shortUrl := c.Param("short_url")
-	url, appErr := u.urlService.GetUrlByShortUrl(shortUrl)
+	url, appErr := u.urlService.GetUrlByShortUrl(c.Request.Host, shortUrl)
 	if appErr != nil {
 		appErr.HttpResponse(c)
 		return
@@ -60,36 +67,78 @@ func (u *urlHandler) RedirectToLongUrl(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"status": "failed", "message": "No url found"})
 		return
 	}
+	switch url.Status {
+	case types.UrlStatusPaused:
+		c.JSON(http.StatusForbidden, gin.H{"status": "failed", "message": "This link is paused"})
+		return
+	case types.UrlStatusDisabled:
+		c.JSON(http.StatusGone, gin.H{"status": "failed", "message": "This link has been disabled"})
+		return
+	}
 
-	u.urlLogService.CreateUrlLogByUrl(url, c.ClientIP())
-	c.Redirect(http.StatusFound, url.LongUrl)
+	visit := newVisit(c)
+	visit.AssignedVariant, _ = c.Cookie(url.VariantCookieName())
+	target := u.urlService.ResolveDestination(url, visit)
+	if target.Variant != "" {
+		c.SetCookie(url.VariantCookieName(), target.Variant, 90*24*60*60, "/"+url.ShortUrl, "", c.Request.TLS != nil, true)
+	}
+	u.urlLogService.CreateUrlLogByUrl(url, visit, target)
+
+	// Visitors on a platform with an app uri get the interstitial, links
+	// with only a universal link are opened by the OS before reaching us.
+	if appLink := url.DeepLink.ForOS(visit.OS); appLink != nil && appLink.URI != "" && c.Request.Method == http.MethodGet {
+		renderDeepLinkPage(c, url, appLink, target.Destination)
+		return
+	}
+	redirectToDestination(c, url, target.Destination)
 }
 
-func (u *urlHandler) GetUrlByID(c *gin.Context) {
-	urlId := c.Param("id")
+func (u *urlHandler) UpdateURLById(c *gin.Context) {
+	var updateDto dtos.UrlUpdateDTO
+	err := c.ShouldBindJSON(&updateDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
 	current_user_id, ok := c.Get("current_user_id")
 	if !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "failed", "message": "Unable to get current user id from context"})
 		return
 	}
-	url, appErr := u.urlService.GetUrlByID(urlId, current_user_id.(string))
+
+	before, _ := u.urlService.GetUrlByID(c.Param("id"), current_user_id.(string))
+	url, appErr := u.urlService.UpdateUrl(c.Param("id"), current_user_id.(string), updateDto)
 	if appErr != nil {
 		appErr.HttpResponse(c)
 		return
 	}
+	u.auditLogService.Record(auditEntry(c, types.AuditUrlUpdated, types.AuditTargetUrl, url.ID.String()), before, url)
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Url updated successfully", "result": gin.H{"url": url}})
+}
 
-	currentUserID, ok := c.Get("current_user_id")
+func (u *urlHandler) GetUrlByID(c *gin.Context) {
+	urlId := c.Param("id")
+	current_user_id, ok := c.Get("current_user_id")
 	if !ok {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "failed", "message": "Unable to get current user id from context"})
 		return
 	}
+	url, appErr := u.urlService.GetUrlByID(urlId, current_user_id.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
 
-	if url.UserID.String() != currentUserID.(string) {
-		c.JSON(http.StatusNotFound, gin.H{"status": "failed", "message": "No url found"})
+	var filter dtos.AnalyticsFilterDTO
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid query parameters", "result": gin.H{"error": err.Error()}})
 		return
 	}
 
-	urlMetadata, appErr := u.urlService.GetUrlDatas(url)
+	urlMetadata, appErr := u.urlService.GetUrlDatas(url, filter)
 	if appErr != nil {
 		appErr.HttpResponse(c)
 		return
@@ -103,7 +152,14 @@ func (u *urlHandler) GetURLs(c *gin.Context) {
 	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "No user data found in the context"})
 	}
-	urls, appErr := u.urlService.GetUrlsOfUser(user_id.(string))
+	var filter dtos.UrlFilterDTO
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid query parameters", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	urls, appErr := u.urlService.GetUrlsOfUser(user_id.(string), filter)
 	if appErr != nil {
 		appErr.HttpResponse(c)
 		return
@@ -119,12 +175,14 @@ func (u *urlHandler) DeleteURLById(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": "failed", "message": "Unable to get current user id from context"})
 		return
 	}
-	appErr := u.urlLogService.DeleteUrlLogByUrl(urlId)
+	before, _ := u.urlService.GetUrlByID(urlId, current_user_id.(string))
+	appErr := u.urlService.DeleteUrlById(urlId, current_user_id.(string))
 	if appErr != nil {
 		appErr.HttpResponse(c)
 		return
 	}
-	appErr = u.urlService.DeleteUrlById(urlId, current_user_id.(string))
+	u.auditLogService.Record(auditEntry(c, types.AuditUrlDeleted, types.AuditTargetUrl, urlId), before, nil)
+	appErr = u.urlLogService.DeleteUrlLogByUrl(urlId)
 	if appErr != nil {
 		appErr.HttpResponse(c)
 		return
@@ -143,11 +201,13 @@ func (u *urlHandler) GetUrlLogsByUrl(c *gin.Context) {
 	url, appErr := u.urlService.GetUrlByID(urlId, userID.(string))
 	if appErr != nil {
 		appErr.HttpResponse(c)
+		return
 	}
 
 	logs, appErr := u.urlService.GetUrlLogsByUrl(url)
 	if appErr != nil {
 		appErr.HttpResponse(c)
+		return
 	}
 
 	responseMessage := "Logs successfully fetched"
@@ -158,3 +218,44 @@ func (u *urlHandler) GetUrlLogsByUrl(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "success", "message": responseMessage, "result": gin.H{"logs": logs}})
 
 }
+
+func (u *urlHandler) TransferUrl(c *gin.Context) {
+	var transferDto dtos.UrlTransferDTO
+	err := c.ShouldBindJSON(&transferDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	current_user_id, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "failed", "message": "Unable to get current user id from context"})
+		return
+	}
+
+	before, _ := u.urlService.GetUrlByID(c.Param("id"), current_user_id.(string))
+	url, appErr := u.urlService.TransferUrl(c.Param("id"), current_user_id.(string), transferDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	u.auditLogService.Record(auditEntry(c, types.AuditUrlUpdated, types.AuditTargetUrl, url.ID.String()), before, url)
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Url transferred successfully", "result": gin.H{"url": url}})
+}
+
+// newVisit collects what the routing rules need to know about the visitor.
+func newVisit(c *gin.Context) *types.Visit {
+	device, os := utils.ParseUserAgent(c.Request.UserAgent())
+	return &types.Visit{
+		ClientIP:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Device:    device,
+		OS:        os,
+		Language:  utils.PreferredLanguage(c.GetHeader("Accept-Language")),
+		Query:     c.Request.URL.Query(),
+		Referrer:  c.Request.Referer(),
+
+		DoNotTrack: c.GetHeader("DNT") == "1" || c.GetHeader("Sec-GPC") == "1",
+	}
+}
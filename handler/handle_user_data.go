@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserDataHandler interface {
+	RequestExport(c *gin.Context)
+	DownloadExport(c *gin.Context)
+	Erase(c *gin.Context)
+}
+
+type userDataHandler struct {
+	userDataService service.UserDataService
+	auditLogService service.AuditLogService
+}
+
+func NewUserDataHandler() UserDataHandler {
+	userDataService := service.NewUserDataService()
+	auditLogService := service.NewAuditLogService()
+	return &userDataHandler{userDataService: userDataService, auditLogService: auditLogService}
+}
+
+func (h *userDataHandler) RequestExport(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.userDataService.RequestExport(currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "Your export is being prepared, a download link will be emailed to you"})
+}
+
+// DownloadExport is reached from the emailed link, the token in the path is
+// the only credential.
+func (h *userDataHandler) DownloadExport(c *gin.Context) {
+	archive, appErr := h.userDataService.GetExport(c.Param("token"))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="urllite-export.zip"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+func (h *userDataHandler) Erase(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	var eraseDto dtos.EraseAccountDTO
+	err := c.ShouldBindJSON(&eraseDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	appErr := h.userDataService.Erase(currentUserID.(string), eraseDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	// Nothing of the erased account is kept, not even in the diff.
+	auditLog := auditEntry(c, types.AuditUserDeleted, types.AuditTargetUser, currentUserID.(string))
+	auditLog.Detail = "erased by the user"
+	h.auditLogService.Record(auditLog, nil, nil)
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Account erased successfully"})
+}
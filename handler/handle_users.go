@@ -24,22 +24,33 @@ type UserHandler interface {
 	SendEmailVerificationOtp(c *gin.Context)
 	VerifyEmail(c *gin.Context)
 	MakeAdmin(c *gin.Context)
+	AssignRole(c *gin.Context)
+	RevokeRole(c *gin.Context)
+	GetRoleChanges(c *gin.Context)
 	Profile(c *gin.Context)
 	SignupAndLogin(c *gin.Context)
 	SendForgetPasswordOtp(c *gin.Context)
 	ChangePasswordUsingOtp(c *gin.Context)
 	VerifyForgetPasswordOtp(c *gin.Context)
+	SuspendUser(c *gin.Context)
+	ReinstateUser(c *gin.Context)
 }
 
 type userHandler struct {
-	userService     service.UserService
-	passwordService service.PasswordService
+	userService            service.UserService
+	passwordService        service.PasswordService
+	twoFactorService       service.TwoFactorService
+	auditLogService        service.AuditLogService
+	loginProtectionService service.LoginProtectionService
 }
 
 func NewUserHandler() UserHandler {
 	userService := service.NewUserService()
 	passwordService := service.NewPasswordService()
-	return &userHandler{userService: userService, passwordService: passwordService}
+	twoFactorService := service.NewTwoFactorService()
+	auditLogService := service.NewAuditLogService()
+	loginProtectionService := service.NewLoginProtectionService()
+	return &userHandler{userService: userService, passwordService: passwordService, twoFactorService: twoFactorService, auditLogService: auditLogService, loginProtectionService: loginProtectionService}
 }
 
 func (h *userHandler) CreateUser(c *gin.Context) {
@@ -103,6 +114,12 @@ func (h *userHandler) UpdateUserByID(c *gin.Context) {
 		return
 	}
 
+	// Only user admins may mark an email verified, everyone else goes
+	// through the verification otp.
+	if !types.HasPermission(c.GetString("current_user_role"), types.PermissionUsersWrite) {
+		user.VerifiedEmail = ""
+	}
+
 	appErr := h.userService.UpdateUserByID(id, user)
 	if appErr != nil {
 		appErr.HttpResponse(c)
@@ -113,12 +130,21 @@ func (h *userHandler) UpdateUserByID(c *gin.Context) {
 }
 
 func (h *userHandler) DeleteUserByID(c *gin.Context) {
+	currentUserId, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
 	id := c.Param("id")
-	appErr := h.userService.DeleteUserByID(id)
+	before, _ := h.userService.GetUserByID(id)
+	appErr := h.userService.DeleteUserByID(currentUserId.(string), id)
 	if appErr != nil {
 		appErr.HttpResponse(c)
 		return
 	}
+	after, _ := h.userService.GetUserByID(id)
+	h.auditLogService.Record(auditEntry(c, types.AuditUserDeleted, types.AuditTargetUser, id), before, after)
 
 	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "User deleted successfully!"})
 }
@@ -195,6 +221,19 @@ func (h *userHandler) Login(c *gin.Context) {
 
 	user, appErr := h.userService.GetUserByEmail(loginReq.Email)
 	if appErr != nil {
+		if appErr.HttpStatusCode == http.StatusNotFound {
+			auditLog := auditEntry(c, types.AuditLoginFailed, types.AuditTargetEmail, loginReq.Email)
+			auditLog.Detail = "unknown email"
+			h.auditLogService.Record(auditLog, nil, nil)
+		}
+		appErr.HttpResponse(c)
+		return
+	}
+
+	// Counted per account, password spraying comes from many ips.
+	appErr = h.loginProtectionService.CheckAllowed(user)
+	if appErr != nil {
+		h.recordLogin(c, types.AuditLoginFailed, user, appErr.Message)
 		appErr.HttpResponse(c)
 		return
 	}
@@ -207,13 +246,33 @@ func (h *userHandler) Login(c *gin.Context) {
 	isPasswordValid := h.passwordService.VerifyPassword(loginReq.Password, password)
 
 	if isPasswordValid {
+		h.loginProtectionService.RecordSuccess(user)
+		twoFactorEnabled, appErr := h.twoFactorService.IsEnabled(user.ID.String())
+		if appErr != nil {
+			appErr.HttpResponse(c)
+			return
+		}
+		if twoFactorEnabled {
+			challengeToken, appErr := h.twoFactorService.CreateChallenge(user, c.Request.Context())
+			if appErr != nil {
+				appErr.HttpResponse(c)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Two factor authentication required", "two_factor_required": true, "challenge_token": challengeToken})
+			return
+		}
+
 		accessToken, appErr := h.userService.GenerateUserAccessToken(user, c.Request.Context())
 		if appErr != nil {
 			appErr.HttpResponse(c)
 			return
 		}
+		h.recordLogin(c, types.AuditLoginSucceeded, user, "password")
+		h.loginProtectionService.NotifyNewLoginSource(user, c.ClientIP(), c.Request.UserAgent())
 		c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Token generated", "access_token": accessToken, "verified_email": user.Email == user.VerifiedEmail})
 	} else {
+		h.loginProtectionService.RecordFailure(user)
+		h.recordLogin(c, types.AuditLoginFailed, user, "incorrect password")
 		c.JSON(http.StatusNotAcceptable, gin.H{"status": "failed", "message": "Incorrect Password"})
 	}
 }
@@ -234,6 +293,7 @@ func (h *userHandler) ChangePassword(c *gin.Context) {
 		appErr.HttpResponse(c)
 		return
 	}
+	h.auditLogService.Record(auditEntry(c, types.AuditPasswordChanged, types.AuditTargetUser, current_user.ID.String()), nil, nil)
 
 	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "Password changed successfully"})
 }
@@ -262,6 +322,9 @@ func (h *userHandler) SendEmailVerificationOtp(c *gin.Context) {
 		appErr.HttpResponse(c)
 		return
 	}
+	auditLog := auditEntry(c, types.AuditOtpSent, types.AuditTargetUser, user.ID.String())
+	auditLog.Detail = "email verification"
+	h.auditLogService.Record(auditLog, nil, nil)
 	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "OTP Delivered successfully"})
 }
 
@@ -299,15 +362,121 @@ func (h *userHandler) MakeAdmin(c *gin.Context) {
 		return
 	}
 
-	appErr := h.userService.MakeAdmin(userId)
+	currentUserId, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	before, _ := h.userService.GetUserByID(userId)
+	appErr := h.userService.MakeAdmin(currentUserId.(string), userId)
 	if appErr != nil {
 		appErr.HttpResponse(c)
 		return
 	}
+	after, _ := h.userService.GetUserByID(userId)
+	h.auditLogService.Record(auditEntry(c, types.AuditUserMadeAdmin, types.AuditTargetUser, userId), before, after)
 
 	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "User role changed to admin"})
 }
 
+func (h *userHandler) SuspendUser(c *gin.Context) {
+	currentUserId, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	userId := c.Param("id")
+	before, _ := h.userService.GetUserByID(userId)
+	appErr := h.userService.SuspendUser(currentUserId.(string), userId)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	after, _ := h.userService.GetUserByID(userId)
+	h.auditLogService.Record(auditEntry(c, types.AuditUserSuspended, types.AuditTargetUser, userId), before, after)
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "User suspended successfully"})
+}
+
+func (h *userHandler) ReinstateUser(c *gin.Context) {
+	currentUserId, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	userId := c.Param("id")
+	before, _ := h.userService.GetUserByID(userId)
+	appErr := h.userService.ReinstateUser(currentUserId.(string), userId)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	after, _ := h.userService.GetUserByID(userId)
+	h.auditLogService.Record(auditEntry(c, types.AuditUserReinstated, types.AuditTargetUser, userId), before, after)
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "User reinstated successfully"})
+}
+
+func (h *userHandler) AssignRole(c *gin.Context) {
+	var roleDto dtos.RoleDTO
+	err := c.ShouldBindJSON(&roleDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserId, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	userId := c.Param("id")
+	before, _ := h.userService.GetUserByID(userId)
+	appErr := h.userService.AssignRole(currentUserId.(string), userId, strings.TrimSpace(roleDto.Role))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	after, _ := h.userService.GetUserByID(userId)
+	h.auditLogService.Record(auditEntry(c, types.AuditRoleAssigned, types.AuditTargetUser, userId), before, after)
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "User role changed to " + strings.TrimSpace(roleDto.Role)})
+}
+
+func (h *userHandler) RevokeRole(c *gin.Context) {
+	currentUserId, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	userId := c.Param("id")
+	before, _ := h.userService.GetUserByID(userId)
+	appErr := h.userService.RevokeRole(currentUserId.(string), userId)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	after, _ := h.userService.GetUserByID(userId)
+	h.auditLogService.Record(auditEntry(c, types.AuditRoleRevoked, types.AuditTargetUser, userId), before, after)
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "User role revoked"})
+}
+
+func (h *userHandler) GetRoleChanges(c *gin.Context) {
+	roleChanges, appErr := h.userService.GetRoleChanges(c.Param("id"))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Role changes fetched successfully", "result": gin.H{"role_changes": roleChanges}})
+}
+
 func (h *userHandler) Profile(c *gin.Context) {
 	currentUserId, ok := c.Get("current_user_id")
 	if !ok {
@@ -335,6 +504,9 @@ func (h *userHandler) SendForgetPasswordOtp(c *gin.Context) {
 		appErr.HttpResponse(c)
 		return
 	}
+	auditLog := auditEntry(c, types.AuditOtpSent, types.AuditTargetEmail, emailReq.Email)
+	auditLog.Detail = "forget password"
+	h.auditLogService.Record(auditLog, nil, nil)
 	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Otp delivered successfully"})
 }
 
@@ -380,6 +552,14 @@ func (h *userHandler) ChangePasswordUsingOtp(c *gin.Context) {
 		appErr.HttpResponse(c)
 		return
 	}
+	h.auditLogService.Record(auditEntry(c, types.AuditPasswordReset, types.AuditTargetEmail, changPasswordDto.Email), nil, nil)
 
 	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "Password changed successfully"})
 }
+
+// recordLogin audits a login attempt, the user logging in is the actor.
+func (h *userHandler) recordLogin(c *gin.Context, action string, user *types.User, detail string) {
+	auditLog := auditEntry(c, action, types.AuditTargetUser, user.ID.String())
+	auditLog.ActorID, auditLog.Detail = user.ID, detail
+	h.auditLogService.Record(auditLog, nil, nil)
+}
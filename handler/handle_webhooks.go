@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler interface {
+	Create(c *gin.Context)
+	GetWebhooks(c *gin.Context)
+	GetWebhookByID(c *gin.Context)
+	Update(c *gin.Context)
+	Delete(c *gin.Context)
+	GetDeliveries(c *gin.Context)
+	Redeliver(c *gin.Context)
+}
+
+type webhookHandler struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookHandler() WebhookHandler {
+	webhookService := service.NewWebhookService()
+	return &webhookHandler{webhookService: webhookService}
+}
+
+func (h *webhookHandler) Create(c *gin.Context) {
+	var webhookDto dtos.WebhookDTO
+	err := c.ShouldBindJSON(&webhookDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	webhook, appErr := h.webhookService.Create(webhookDto, currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	// The signing secret is only shown once.
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "message": "Webhook created successfully", "result": gin.H{"webhook": webhook, "secret": webhook.Secret}})
+}
+
+func (h *webhookHandler) GetWebhooks(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	webhooks, appErr := h.webhookService.GetWebhooksOfUser(currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Webhooks fetched successfully", "result": gin.H{"webhooks": webhooks}})
+}
+
+func (h *webhookHandler) GetWebhookByID(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	webhook, appErr := h.webhookService.GetWebhookByID(c.Param("id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Webhook fetched successfully", "result": gin.H{"webhook": webhook}})
+}
+
+func (h *webhookHandler) Update(c *gin.Context) {
+	var updateDto dtos.WebhookUpdateDTO
+	err := c.ShouldBindJSON(&updateDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	webhook, appErr := h.webhookService.Update(c.Param("id"), currentUserID.(string), updateDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Webhook updated successfully", "result": gin.H{"webhook": webhook}})
+}
+
+func (h *webhookHandler) Delete(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.webhookService.Delete(c.Param("id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Webhook deleted successfully"})
+}
+
+func (h *webhookHandler) GetDeliveries(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	deliveries, appErr := h.webhookService.GetDeliveries(c.Param("id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Webhook deliveries fetched successfully", "result": gin.H{"deliveries": deliveries}})
+}
+
+func (h *webhookHandler) Redeliver(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.webhookService.Redeliver(c.Param("id"), c.Param("delivery_id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "Webhook redelivery queued"})
+}
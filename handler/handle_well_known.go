@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/security"
+	"urllite/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WellKnownHandler interface {
+	JWKS(c *gin.Context)
+	AppleAppSiteAssociation(c *gin.Context)
+	AssetLinks(c *gin.Context)
+}
+
+type wellKnownHandler struct {
+	keySet        security.JWTKeySet
+	domainService service.DomainService
+}
+
+func NewWellKnownHandler() WellKnownHandler {
+	domainService := service.NewDomainService()
+	return &wellKnownHandler{keySet: security.JWTKeys(), domainService: domainService}
+}
+
+func (h *wellKnownHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keySet.JWKS())
+}
+
+// AppleAppSiteAssociation lets iOS open links of a verified custom domain in
+// the apps configured for it.
+func (h *wellKnownHandler) AppleAppSiteAssociation(c *gin.Context) {
+	domain, appErr := h.domainService.ResolveHost(c.Request.Host)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	if domain == nil || len(domain.AppleAppIDs) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": "failed", "message": "No apps configured for this domain"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, gin.H{
+		"applinks": gin.H{
+			"details": []gin.H{{
+				"appIDs":     domain.AppleAppIDs,
+				"components": []gin.H{{"/": "/*"}},
+			}},
+		},
+	})
+}
+
+// AssetLinks is the Android counterpart of AppleAppSiteAssociation.
+func (h *wellKnownHandler) AssetLinks(c *gin.Context) {
+	domain, appErr := h.domainService.ResolveHost(c.Request.Host)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+	if domain == nil || domain.AndroidPackageName == "" {
+		c.JSON(http.StatusNotFound, gin.H{"status": "failed", "message": "No apps configured for this domain"})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, []gin.H{{
+		"relation": []string{"delegate_permission/common.handle_all_urls"},
+		"target": gin.H{
+			"namespace":                "android_app",
+			"package_name":             domain.AndroidPackageName,
+			"sha256_cert_fingerprints": domain.AndroidCertFingerprints,
+		},
+	}})
+}
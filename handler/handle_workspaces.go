@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"net/http"
+	"urllite/service"
+	"urllite/types/dtos"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkspaceHandler interface {
+	Create(c *gin.Context)
+	GetWorkspaces(c *gin.Context)
+	GetWorkspaceByID(c *gin.Context)
+	DeleteWorkspaceByID(c *gin.Context)
+	ChangeMemberRole(c *gin.Context)
+	RemoveMember(c *gin.Context)
+	Invite(c *gin.Context)
+	AcceptInvitation(c *gin.Context)
+	GetWorkspaceUrls(c *gin.Context)
+}
+
+type workspaceHandler struct {
+	workspaceService service.WorkspaceService
+	urlService       service.UrlService
+}
+
+func NewWorkspaceHandler() WorkspaceHandler {
+	workspaceService := service.NewWorkspaceService()
+	urlService := service.NewUrlService()
+	return &workspaceHandler{workspaceService: workspaceService, urlService: urlService}
+}
+
+func (h *workspaceHandler) Create(c *gin.Context) {
+	var workspaceDto dtos.WorkspaceDTO
+	err := c.ShouldBindJSON(&workspaceDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	workspace, appErr := h.workspaceService.Create(workspaceDto, currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "message": "Workspace created successfully", "result": gin.H{"workspace": workspace}})
+}
+
+func (h *workspaceHandler) GetWorkspaces(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	workspaces, appErr := h.workspaceService.GetWorkspacesOfUser(currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Workspaces fetched successfully", "result": gin.H{"workspaces": workspaces}})
+}
+
+func (h *workspaceHandler) GetWorkspaceByID(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	workspace, members, appErr := h.workspaceService.GetWorkspaceByID(c.Param("id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Workspace fetched successfully", "result": gin.H{"workspace": workspace, "members": members}})
+}
+
+func (h *workspaceHandler) DeleteWorkspaceByID(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.workspaceService.Delete(c.Param("id"), currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Workspace deleted successfully"})
+}
+
+func (h *workspaceHandler) ChangeMemberRole(c *gin.Context) {
+	var memberDto dtos.WorkspaceMemberDTO
+	err := c.ShouldBindJSON(&memberDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.workspaceService.ChangeMemberRole(c.Param("id"), currentUserID.(string), c.Param("user_id"), memberDto.Role)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "Member role changed to " + memberDto.Role})
+}
+
+func (h *workspaceHandler) RemoveMember(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	appErr := h.workspaceService.RemoveMember(c.Param("id"), currentUserID.(string), c.Param("user_id"))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Member removed successfully"})
+}
+
+func (h *workspaceHandler) Invite(c *gin.Context) {
+	var invitationDto dtos.WorkspaceInvitationDTO
+	err := c.ShouldBindJSON(&invitationDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	invitation, appErr := h.workspaceService.Invite(c.Param("id"), currentUserID.(string), invitationDto)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "message": "Invitation sent successfully", "result": gin.H{"invitation": invitation}})
+}
+
+func (h *workspaceHandler) AcceptInvitation(c *gin.Context) {
+	var acceptDto dtos.AcceptInvitationDTO
+	err := c.ShouldBindJSON(&acceptDto)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid request body", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	member, appErr := h.workspaceService.AcceptInvitation(acceptDto.Token, currentUserID.(string))
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Joined the workspace", "result": gin.H{"member": member}})
+}
+
+func (h *workspaceHandler) GetWorkspaceUrls(c *gin.Context) {
+	currentUserID, ok := c.Get("current_user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"status": "failed", "message": "No user id available in context"})
+		return
+	}
+
+	var filter dtos.UrlFilterDTO
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": "failed", "message": "Invalid query parameters", "result": gin.H{"error": err.Error()}})
+		return
+	}
+
+	urls, appErr := h.urlService.GetUrlsOfWorkspace(c.Param("id"), currentUserID.(string), filter)
+	if appErr != nil {
+		appErr.HttpResponse(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Urls fetched successfully", "result": gin.H{"urls": urls}})
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"urllite/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redirectPage counts down before sending the visitor on, with an optional
+// notice that they are leaving for another site.
+var redirectPage = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<meta http-equiv="refresh" content="{{.Delay}};url={{.Destination}}">
+<title>Redirecting…</title>
+</head>
+<body style="font-family: sans-serif; text-align: center; padding: 48px 16px;">
+{{if .LeavingWarning}}<p><strong>You are leaving this site.</strong></p>
+<p>This link goes to <strong>{{.DestinationHost}}</strong>, which we do not control.</p>{{end}}
+<p>Redirecting in <span id="countdown">{{.Delay}}</span> seconds…</p>
+<p><a href="{{.Destination}}" rel="noopener noreferrer">Continue to {{.DestinationHost}}</a></p>
+<p style="font-size: small;"><a href="{{.ReportURL}}" rel="nofollow">Report this link</a></p>
+<script>
+(function () {
+	var remaining = {{.Delay}};
+	var countdown = document.getElementById("countdown");
+	var timer = setInterval(function () {
+		remaining -= 1;
+		if (remaining <= 0) {
+			clearInterval(timer);
+			window.location.replace({{.Destination}});
+			return;
+		}
+		countdown.textContent = remaining;
+	}, 1000);
+})();
+</script>
+</body>
+</html>
+`))
+
+type redirectPageData struct {
+	Delay           int
+	Destination     string
+	DestinationHost string
+	LeavingWarning  bool
+	ReportURL       string
+}
+
+// redirectToDestination answers a short link visit the way the link is
+// configured and sets the caching and indexing headers to match.
+func redirectToDestination(c *gin.Context, url *types.URL, destination string) {
+	if url.NoIndex {
+		c.Header("X-Robots-Tag", "noindex, nofollow")
+	}
+
+	if url.RedirectType == types.RedirectInterstitial && c.Request.Method == http.MethodGet {
+		destinationHost := destination
+		if parsedDestination, err := neturl.Parse(destination); err == nil && parsedDestination.Host != "" {
+			destinationHost = parsedDestination.Host
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.Header("X-Robots-Tag", "noindex, nofollow")
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		redirectPage.Execute(c.Writer, redirectPageData{
+			Delay:           url.InterstitialDelay,
+			Destination:     destination,
+			DestinationHost: destinationHost,
+			LeavingWarning:  url.LeavingWarning,
+			ReportURL:       reportUrl(url),
+		})
+		return
+	}
+
+	switch {
+	case types.IsPermanentRedirect(url.RedirectType) && !url.IsPersonalized():
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(redirectCacheMaxAge()))
+	default:
+		// Every click has to reach us to be counted and routed.
+		c.Header("Cache-Control", "private, max-age=0, no-cache")
+	}
+	c.Redirect(types.RedirectStatusCode(url.RedirectType), destination)
+}
+
+// reportUrl is the path of the report page of the link on the host that
+// served it.
+func reportUrl(url *types.URL) string {
+	return "/report/" + neturl.PathEscape(url.ShortUrl)
+}
+
+// redirectCacheMaxAge bounds how long browsers and CDNs keep permanent
+// redirects, clicks served from a cache are not counted.
+func redirectCacheMaxAge() int {
+	maxAge, err := strconv.Atoi(os.Getenv("REDIRECT_CACHE_MAX_AGE"))
+	if err != nil || maxAge < 0 {
+		return types.DefaultRedirectCacheLimit
+	}
+	return maxAge
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"urllite/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reportPage lets visitors flag a short link without using the api, it
+// posts the form back to the same path.
+var reportPage = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>Report a link</title>
+</head>
+<body style="font-family: sans-serif; max-width: 480px; margin: 0 auto; padding: 48px 16px;">
+{{if .Submitted}}<p>Thank you, your report of <strong>/{{.ShortUrl}}</strong> was sent to our moderators.</p>
+{{else}}<h1>Report /{{.ShortUrl}}</h1>
+<form method="post" action="/report/{{.ShortUrl}}">
+<p><label>What is wrong with this link?<br>
+<select name="category" required>
+{{range .Categories}}<option value="{{.}}">{{.}}</option>
+{{end}}</select></label></p>
+<p><label>Details<br><textarea name="details" rows="5" style="width: 100%;"></textarea></label></p>
+<p><label>Your email (optional)<br><input type="email" name="email" style="width: 100%;"></label></p>
+<p><button type="submit">Send report</button></p>
+</form>{{end}}
+</body>
+</html>
+`))
+
+type reportPageData struct {
+	ShortUrl   string
+	Categories []string
+	Submitted  bool
+}
+
+func renderReportPage(c *gin.Context, shortUrl string, submitted bool) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	reportPage.Execute(c.Writer, reportPageData{
+		ShortUrl:   shortUrl,
+		Categories: types.AbuseCategories,
+		Submitted:  submitted,
+	})
+}
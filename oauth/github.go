@@ -0,0 +1,74 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+const (
+	githubAuthorizeURL = "https://github.com/login/oauth/authorize"
+	githubTokenURL     = "https://github.com/login/oauth/access_token"
+	githubUserURL      = "https://api.github.com/user"
+	githubEmailsURL    = "https://api.github.com/user/emails"
+)
+
+// githubProvider speaks plain OAuth2 since GitHub does not issue id tokens
+// for user logins. The verified primary email comes from the emails API.
+type githubProvider struct {
+	config providerConfig
+}
+
+func newGithubProvider(config providerConfig) *githubProvider {
+	if len(config.scopes) == 0 {
+		config.scopes = []string{"read:user", "user:email"}
+	}
+	return &githubProvider{config: config}
+}
+
+func (p *githubProvider) Name() string {
+	return p.config.name
+}
+
+func (p *githubProvider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	return authCodeURL(githubAuthorizeURL, p.config, state, codeChallenge, nil)
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	token, err := exchangeCode(ctx, githubTokenURL, p.config, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("no access token returned by github")
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, githubUserURL, token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, githubEmailsURL, token.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{Provider: p.config.name, Subject: strconv.FormatInt(user.ID, 10), Name: user.Name}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email, identity.EmailVerified = email.Email, email.Verified
+		}
+	}
+	return identity, nil
+}
@@ -0,0 +1,190 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type idTokenClaims struct {
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+	Nonce         string      `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+type oidcProvider struct {
+	config    providerConfig
+	discovery oidcDiscovery
+
+	keysMu        sync.Mutex
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func newOIDCProvider(ctx context.Context, config providerConfig, discoveryURL string) (*oidcProvider, error) {
+	var discovery oidcDiscovery
+	if err := getJSON(ctx, discoveryURL, "", &discovery); err != nil {
+		return nil, fmt.Errorf("unable to load oidc discovery document: %w", err)
+	}
+	if discovery.Issuer == "" || discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksURI == "" {
+		return nil, fmt.Errorf("incomplete oidc discovery document at %s", discoveryURL)
+	}
+
+	if len(config.scopes) == 0 {
+		config.scopes = []string{"openid", "email", "profile"}
+	}
+	return &oidcProvider{config: config, discovery: discovery}, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.config.name
+}
+
+func (p *oidcProvider) AuthCodeURL(state, nonce, codeChallenge string) string {
+	return authCodeURL(p.discovery.AuthorizationEndpoint, p.config, state, codeChallenge, url.Values{"nonce": {nonce}})
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	token, err := exchangeCode(ctx, p.discovery.TokenEndpoint, p.config, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("no id_token returned by %s", p.config.name)
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(token.IDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.verificationKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(p.discovery.Issuer),
+		jwt.WithAudience(p.config.clientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("id_token nonce mismatch")
+	}
+
+	identity := &Identity{
+		Provider:      p.config.name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:          claims.Name,
+	}
+	return identity, nil
+}
+
+// verificationKey refreshes the provider keys when an unknown kid shows up,
+// at most once a minute, so provider side rotation is picked up.
+func (p *oidcProvider) verificationKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.keysMu.Lock()
+	defer p.keysMu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < time.Minute {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, p.discovery.JwksURI, "", &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys, p.keysFetchedAt = keys, time.Now()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
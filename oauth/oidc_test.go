@@ -0,0 +1,195 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "urllite-test"
+	testCodeVerifier = "the-code-verifier"
+	testNonce        = "the-nonce"
+)
+
+// mockIssuer is an OIDC provider answering discovery, jwks and a token
+// endpoint that only hands out an id_token for the right PKCE verifier.
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                m.server.URL,
+			AuthorizationEndpoint: m.server.URL + "/authorize",
+			TokenEndpoint:         m.server.URL + "/token",
+			JwksURI:               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []jsonWebKey{{
+			Kty: "RSA",
+			Kid: "key-1",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "the-code" || CodeChallenge(r.Form.Get("code_verifier")) != CodeChallenge(testCodeVerifier) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(tokenResponse{Error: "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, m.claims)
+		token.Header["kid"] = "key-1"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(tokenResponse{AccessToken: "access", IDToken: idToken, TokenType: "Bearer"})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	m.claims = jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"email":          "owner@example.com",
+		"email_verified": true,
+		"name":           "Owner",
+		"nonce":          testNonce,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+	return m
+}
+
+func (m *mockIssuer) provider(t *testing.T) *oidcProvider {
+	t.Helper()
+	config := providerConfig{name: "mock", clientID: testClientID, redirectURL: "https://urllite.test/callback"}
+	provider, err := newOIDCProvider(context.Background(), config, m.server.URL+"/.well-known/openid-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestNewOIDCProviderDiscovery(t *testing.T) {
+	m := newMockIssuer(t)
+	provider := m.provider(t)
+	if provider.discovery.TokenEndpoint != m.server.URL+"/token" {
+		t.Errorf("token endpoint = %q", provider.discovery.TokenEndpoint)
+	}
+
+	incomplete := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{Issuer: "https://issuer.test"})
+	}))
+	defer incomplete.Close()
+	if _, err := newOIDCProvider(context.Background(), providerConfig{name: "mock"}, incomplete.URL); err == nil {
+		t.Error("expected an error for an incomplete discovery document")
+	}
+}
+
+func TestAuthCodeURLSendsPKCEChallenge(t *testing.T) {
+	provider := newMockIssuer(t).provider(t)
+	authURL, err := url.Parse(provider.AuthCodeURL("the-state", testNonce, CodeChallenge(testCodeVerifier)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := authURL.Query()
+	expected := map[string]string{
+		"state":                 "the-state",
+		"nonce":                 testNonce,
+		"client_id":             testClientID,
+		"code_challenge":        CodeChallenge(testCodeVerifier),
+		"code_challenge_method": "S256",
+	}
+	for key, value := range expected {
+		if query.Get(key) != value {
+			t.Errorf("%s = %q, want %q", key, query.Get(key), value)
+		}
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	tests := []struct {
+		name         string
+		code         string
+		codeVerifier string
+		nonce        string
+		claims       jwt.MapClaims
+		wantErr      bool
+		wantVerified bool
+		wantEmail    string
+		wantSubject  string
+	}{
+		{name: "valid login", code: "the-code", codeVerifier: testCodeVerifier, nonce: testNonce, wantVerified: true, wantEmail: "owner@example.com", wantSubject: "subject-1"},
+		{name: "email verified as string", code: "the-code", codeVerifier: testCodeVerifier, nonce: testNonce, claims: jwt.MapClaims{"email_verified": "true"}, wantVerified: true, wantEmail: "owner@example.com", wantSubject: "subject-1"},
+		{name: "unverified email", code: "the-code", codeVerifier: testCodeVerifier, nonce: testNonce, claims: jwt.MapClaims{"email_verified": false}, wantEmail: "owner@example.com", wantSubject: "subject-1"},
+		{name: "wrong code verifier", code: "the-code", codeVerifier: "another-verifier", nonce: testNonce, wantErr: true},
+		{name: "wrong code", code: "another-code", codeVerifier: testCodeVerifier, nonce: testNonce, wantErr: true},
+		{name: "nonce mismatch", code: "the-code", codeVerifier: testCodeVerifier, nonce: "another-nonce", wantErr: true},
+		{name: "other audience", code: "the-code", codeVerifier: testCodeVerifier, nonce: testNonce, claims: jwt.MapClaims{"aud": "someone-else"}, wantErr: true},
+		{name: "expired token", code: "the-code", codeVerifier: testCodeVerifier, nonce: testNonce, claims: jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockIssuer(t)
+			for key, value := range tt.claims {
+				m.claims[key] = value
+			}
+			provider := m.provider(t)
+
+			identity, err := provider.Exchange(context.Background(), tt.code, tt.codeVerifier, tt.nonce)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got identity %+v", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity.Subject != tt.wantSubject || identity.Email != tt.wantEmail || identity.EmailVerified != tt.wantVerified {
+				t.Errorf("identity = %+v", identity)
+			}
+			if identity.Provider != "mock" {
+				t.Errorf("provider = %q", identity.Provider)
+			}
+		})
+	}
+}
+
+func TestOIDCExchangeRejectsUnknownIssuer(t *testing.T) {
+	m := newMockIssuer(t)
+	m.claims["iss"] = strings.Replace(m.server.URL, "127.0.0.1", "localhost", 1)
+	_, err := m.provider(t).Exchange(context.Background(), "the-code", testCodeVerifier, testNonce)
+	if err == nil {
+		t.Error("expected an error for an id_token from another issuer")
+	}
+}
@@ -0,0 +1,191 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identity is the part of the provider profile we need to find or create a
+// urllite user.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Provider interface {
+	Name() string
+	AuthCodeURL(state, nonce, codeChallenge string) string
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+}
+
+type providerConfig struct {
+	name         string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+}
+
+var (
+	providers   = map[string]Provider{}
+	providersMu sync.Mutex
+	httpClient  = &http.Client{Timeout: 10 * time.Second}
+)
+
+const googleDiscoveryURL = "https://accounts.google.com/.well-known/openid-configuration"
+
+// GetProvider builds the provider from the environment on first use:
+//
+//	OAUTH_<NAME>_CLIENT_ID, OAUTH_<NAME>_CLIENT_SECRET, OAUTH_<NAME>_REDIRECT_URL
+//	OAUTH_<NAME>_DISCOVERY_URL (generic OIDC issuers, optional for google)
+//	OAUTH_<NAME>_SCOPES        (space separated, optional)
+func GetProvider(ctx context.Context, name string) (Provider, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if provider, ok := providers[name]; ok {
+		return provider, nil
+	}
+
+	envPrefix := "OAUTH_" + strings.ToUpper(name) + "_"
+	config := providerConfig{
+		name:         name,
+		clientID:     os.Getenv(envPrefix + "CLIENT_ID"),
+		clientSecret: os.Getenv(envPrefix + "CLIENT_SECRET"),
+		redirectURL:  os.Getenv(envPrefix + "REDIRECT_URL"),
+	}
+	if config.clientID == "" || config.redirectURL == "" {
+		return nil, fmt.Errorf("oauth provider %s is not configured", name)
+	}
+	if scopes := strings.TrimSpace(os.Getenv(envPrefix + "SCOPES")); scopes != "" {
+		config.scopes = strings.Fields(scopes)
+	}
+
+	var provider Provider
+	var err error
+	switch name {
+	case "github":
+		provider = newGithubProvider(config)
+	default:
+		discoveryURL := os.Getenv(envPrefix + "DISCOVERY_URL")
+		if discoveryURL == "" && name == "google" {
+			discoveryURL = googleDiscoveryURL
+		}
+		if discoveryURL == "" {
+			return nil, fmt.Errorf("oauth provider %s has no discovery url", name)
+		}
+		provider, err = newOIDCProvider(ctx, config, discoveryURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	providers[name] = provider
+	return provider, nil
+}
+
+// CodeChallenge derives the S256 PKCE challenge for a verifier.
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func authCodeURL(endpoint string, config providerConfig, state, codeChallenge string, extra url.Values) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {config.clientID},
+		"redirect_uri":          {config.redirectURL},
+		"scope":                 {strings.Join(config.scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	for key, values := range extra {
+		params[key] = values
+	}
+
+	separator := "?"
+	if strings.Contains(endpoint, "?") {
+		separator = "&"
+	}
+	return endpoint + separator + params.Encode()
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	Error       string `json:"error"`
+}
+
+func exchangeCode(ctx context.Context, tokenEndpoint string, config providerConfig, code, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {config.redirectURL},
+		"client_id":     {config.clientID},
+		"code_verifier": {codeVerifier},
+	}
+	if config.clientSecret != "" {
+		form.Set("client_secret", config.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	if err := doJSON(req, &token); err != nil {
+		return nil, err
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("token exchange failed: %s", token.Error)
+	}
+	return &token, nil
+}
+
+func getJSON(ctx context.Context, endpoint, accessToken string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return doJSON(req, target)
+}
+
+func doJSON(req *http.Request, target interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s %s returned %s", req.Method, req.URL.Host, resp.Status)
+	}
+	return json.Unmarshal(body, target)
+}
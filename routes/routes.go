@@ -4,6 +4,8 @@ import (
 	"urllite/auth"
 	"urllite/handler"
 	"urllite/security"
+	"urllite/service"
+	"urllite/types"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,39 +13,177 @@ import (
 func MountHTTPRoutes(r *gin.Engine) {
 	userHandlers := handler.NewUserHandler()
 	urlHandler := handler.NewUrlHandler()
+	apiKeyHandler := handler.NewApiKeyHandler()
+	wellKnownHandler := handler.NewWellKnownHandler()
+	oauthHandler := handler.NewOAuthHandler()
+	twoFactorHandler := handler.NewTwoFactorHandler()
+	workspaceHandler := handler.NewWorkspaceHandler()
+	domainHandler := handler.NewDomainHandler()
+	folderHandler := handler.NewFolderHandler()
+	tagHandler := handler.NewTagHandler()
+	campaignHandler := handler.NewCampaignHandler()
+	webhookHandler := handler.NewWebhookHandler()
+	liveHandler := handler.NewLiveHandler()
+	privacyHandler := handler.NewPrivacyHandler()
+	userDataHandler := handler.NewUserDataHandler()
+	abuseReportHandler := handler.NewAbuseReportHandler()
+	adminHandler := handler.NewAdminHandler()
+	auditLogHandler := handler.NewAuditLogHandler()
+	loginLockoutHandler := handler.NewLoginLockoutHandler()
+	apiKeyAuthentication := auth.ApiKeyOrUserAuthentication(service.NewApiKeyService())
+	r.Use(handler.RecordServerErrors)
 	r.POST("/signup", security.RatelimittingMiddleware, userHandlers.Signup)
 	r.POST("/signup-and-login", security.RatelimittingMiddleware, userHandlers.SignupAndLogin)
 	r.POST("/login", security.RatelimittingMiddleware, userHandlers.Login)
+	r.POST("/login/2fa", security.RatelimittingMiddleware, twoFactorHandler.Login)
+	r.GET("/oauth/:provider/login", security.RatelimittingMiddleware, oauthHandler.Login)
+	r.GET("/oauth/:provider/callback", security.RatelimittingMiddleware, oauthHandler.Callback)
 	r.POST("/send-forget-password-otp", security.OtpRatelimittingMiddleware, userHandlers.SendForgetPasswordOtp)
 	r.POST("/verify-forget-password-otp", userHandlers.VerifyForgetPasswordOtp)
 	r.POST("/change-password-via-otp", userHandlers.ChangePasswordUsingOtp)
 	r.POST("/change-password", auth.UserAuthentication, userHandlers.ChangePassword)
 	r.POST("/verify-email-otp", security.OtpRatelimittingMiddleware, userHandlers.SendEmailVerificationOtp)
 	r.POST("/verify-email", userHandlers.VerifyEmail)
+	r.GET("/.well-known/jwks.json", wellKnownHandler.JWKS)
+	r.GET("/.well-known/apple-app-site-association", wellKnownHandler.AppleAppSiteAssociation)
+	r.GET("/apple-app-site-association", wellKnownHandler.AppleAppSiteAssociation)
+	r.GET("/.well-known/assetlinks.json", wellKnownHandler.AssetLinks)
+	r.GET("/exports/:token", security.RatelimittingMiddleware, userDataHandler.DownloadExport)
+	r.GET("/report/:short_url", abuseReportHandler.ReportPage)
+	r.POST("/report/:short_url", security.RatelimittingMiddleware, abuseReportHandler.Report)
 	r.GET("/:short_url", urlHandler.RedirectToLongUrl)
+	r.POST("/:short_url", urlHandler.RedirectToLongUrl)
 
-	authenticatedApis := r.Group("/api/v1", auth.UserAuthentication)
+	authenticatedApis := r.Group("/api/v1", apiKeyAuthentication)
 	{
 		authenticatedApis.GET("/profile", userHandlers.Profile)
-		userGroup := authenticatedApis.Group("/user")
+		authenticatedApis.DELETE("/profile", auth.RejectApiKey, userDataHandler.Erase)
+		authenticatedApis.GET("/profile/export", auth.RejectApiKey, userDataHandler.RequestExport)
+		authenticatedApis.GET("/profile/privacy", auth.RejectApiKey, privacyHandler.GetSettings)
+		authenticatedApis.PUT("/profile/privacy", auth.RejectApiKey, privacyHandler.UpdateSettings)
+		authenticatedApis.GET("/live", auth.RequireScope(types.ScopeAnalyticsRead), liveHandler.UserClicks)
+		userGroup := authenticatedApis.Group("/user", auth.RejectApiKey)
 		{
-			userGroup.POST("/", userHandlers.CreateUser)
-			userGroup.GET("/:id", userHandlers.GetUserByID)
-			userGroup.PUT("/:id", userHandlers.UpdateUserByID)
+			userGroup.POST("/", auth.RequirePermission(types.PermissionUsersWrite), userHandlers.CreateUser)
+			userGroup.GET("/:id", auth.RequireSelfOrPermission(types.PermissionUsersRead), userHandlers.GetUserByID)
+			userGroup.PUT("/:id", auth.RequireSelfOrPermission(types.PermissionUsersWrite), userHandlers.UpdateUserByID)
 
-			userGroup.GET("/", auth.AdminAuthentication, userHandlers.GetUsers)
-			userGroup.DELETE("/:id", auth.AdminAuthentication, userHandlers.DeleteUserByID)
-			userGroup.POST("/:id/make-admin", auth.AdminAuthentication, userHandlers.MakeAdmin)
+			userGroup.GET("/", auth.RequirePermission(types.PermissionUsersRead), userHandlers.GetUsers)
+			userGroup.DELETE("/:id", auth.RequirePermission(types.PermissionUsersDelete), userHandlers.DeleteUserByID)
+			userGroup.POST("/:id/suspend", auth.RequirePermission(types.PermissionUsersWrite), userHandlers.SuspendUser)
+			userGroup.POST("/:id/reinstate", auth.RequirePermission(types.PermissionUsersWrite), userHandlers.ReinstateUser)
+			userGroup.POST("/:id/make-admin", auth.RequirePermission(types.PermissionRolesManage), userHandlers.MakeAdmin)
+			userGroup.PUT("/:id/role", auth.RequirePermission(types.PermissionRolesManage), userHandlers.AssignRole)
+			userGroup.DELETE("/:id/role", auth.RequirePermission(types.PermissionRolesManage), userHandlers.RevokeRole)
+			userGroup.GET("/:id/role-changes", auth.RequirePermission(types.PermissionRolesManage), userHandlers.GetRoleChanges)
 		}
 
 		urlGroup := authenticatedApis.Group("/url")
 		{
-			urlGroup.POST("/", security.RatelimittingMiddleware, urlHandler.Create)
-			urlGroup.GET("/", urlHandler.GetURLs)
-			urlGroup.GET("/:id", urlHandler.GetUrlByID)
-			urlGroup.DELETE("/:id", urlHandler.DeleteURLById)
-			urlGroup.GET("/:id/logs", urlHandler.GetUrlLogsByUrl)
+			urlGroup.POST("/", auth.RequireScope(types.ScopeUrlsWrite), security.RatelimittingMiddleware, urlHandler.Create)
+			urlGroup.GET("/", auth.RequireScope(types.ScopeUrlsRead), urlHandler.GetURLs)
+			urlGroup.GET("/:id", auth.RequireScope(types.ScopeUrlsRead), urlHandler.GetUrlByID)
+			urlGroup.PATCH("/:id", auth.RequireScope(types.ScopeUrlsWrite), urlHandler.UpdateURLById)
+			urlGroup.DELETE("/:id", auth.RequireScope(types.ScopeUrlsWrite), urlHandler.DeleteURLById)
+			urlGroup.GET("/:id/logs", auth.RequireScope(types.ScopeAnalyticsRead), urlHandler.GetUrlLogsByUrl)
+			urlGroup.GET("/:id/live", auth.RequireScope(types.ScopeAnalyticsRead), liveHandler.UrlClicks)
+			urlGroup.POST("/:id/transfer", auth.RejectApiKey, urlHandler.TransferUrl)
 
 		}
+
+		folderGroup := authenticatedApis.Group("/folders")
+		{
+			folderGroup.POST("/", auth.RequireScope(types.ScopeUrlsWrite), folderHandler.Create)
+			folderGroup.GET("/", auth.RequireScope(types.ScopeUrlsRead), folderHandler.GetFolders)
+			folderGroup.PUT("/:id", auth.RequireScope(types.ScopeUrlsWrite), folderHandler.Rename)
+			folderGroup.DELETE("/:id", auth.RequireScope(types.ScopeUrlsWrite), folderHandler.Delete)
+			folderGroup.GET("/:id/analytics", auth.RequireScope(types.ScopeAnalyticsRead), folderHandler.GetFolderAnalytics)
+		}
+
+		tagGroup := authenticatedApis.Group("/tags")
+		{
+			tagGroup.GET("/", auth.RequireScope(types.ScopeUrlsRead), tagHandler.GetTags)
+			tagGroup.PUT("/:tag", auth.RequireScope(types.ScopeUrlsWrite), tagHandler.RenameTag)
+			tagGroup.DELETE("/:tag", auth.RequireScope(types.ScopeUrlsWrite), tagHandler.DeleteTag)
+			tagGroup.GET("/:tag/analytics", auth.RequireScope(types.ScopeAnalyticsRead), tagHandler.GetTagAnalytics)
+		}
+
+		campaignGroup := authenticatedApis.Group("/campaigns")
+		{
+			campaignGroup.POST("/", auth.RequireScope(types.ScopeUrlsWrite), campaignHandler.Create)
+			campaignGroup.GET("/", auth.RequireScope(types.ScopeUrlsRead), campaignHandler.GetCampaigns)
+			campaignGroup.GET("/:id", auth.RequireScope(types.ScopeUrlsRead), campaignHandler.GetCampaignByID)
+			campaignGroup.PUT("/:id", auth.RequireScope(types.ScopeUrlsWrite), campaignHandler.Update)
+			campaignGroup.DELETE("/:id", auth.RequireScope(types.ScopeUrlsWrite), campaignHandler.Delete)
+			campaignGroup.POST("/:id/urls", auth.RequireScope(types.ScopeUrlsWrite), campaignHandler.AddUrls)
+			campaignGroup.DELETE("/:id/urls/:url_id", auth.RequireScope(types.ScopeUrlsWrite), campaignHandler.RemoveUrl)
+			campaignGroup.GET("/:id/report", auth.RequireScope(types.ScopeAnalyticsRead), campaignHandler.GetReport)
+		}
+
+		workspaceGroup := authenticatedApis.Group("/workspaces", auth.RejectApiKey)
+		{
+			workspaceGroup.POST("/", workspaceHandler.Create)
+			workspaceGroup.GET("/", workspaceHandler.GetWorkspaces)
+			workspaceGroup.POST("/invitations/accept", workspaceHandler.AcceptInvitation)
+			workspaceGroup.GET("/:id", workspaceHandler.GetWorkspaceByID)
+			workspaceGroup.DELETE("/:id", workspaceHandler.DeleteWorkspaceByID)
+			workspaceGroup.GET("/:id/urls", workspaceHandler.GetWorkspaceUrls)
+			workspaceGroup.POST("/:id/invitations", workspaceHandler.Invite)
+			workspaceGroup.PUT("/:id/members/:user_id", workspaceHandler.ChangeMemberRole)
+			workspaceGroup.DELETE("/:id/members/:user_id", workspaceHandler.RemoveMember)
+		}
+
+		domainGroup := authenticatedApis.Group("/domains", auth.RejectApiKey)
+		{
+			domainGroup.POST("/", domainHandler.Create)
+			domainGroup.GET("/", domainHandler.GetDomains)
+			domainGroup.POST("/:id/verify", domainHandler.Verify)
+			domainGroup.DELETE("/:id", domainHandler.Delete)
+			domainGroup.PUT("/:id/apps", domainHandler.UpdateApps)
+		}
+
+		webhookGroup := authenticatedApis.Group("/webhooks", auth.RejectApiKey)
+		{
+			webhookGroup.POST("/", webhookHandler.Create)
+			webhookGroup.GET("/", webhookHandler.GetWebhooks)
+			webhookGroup.GET("/:id", webhookHandler.GetWebhookByID)
+			webhookGroup.PUT("/:id", webhookHandler.Update)
+			webhookGroup.DELETE("/:id", webhookHandler.Delete)
+			webhookGroup.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+			webhookGroup.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+		}
+
+		statsGroup := authenticatedApis.Group("/admin/stats", auth.RejectApiKey, auth.RequirePermission(types.PermissionStatsRead))
+		{
+			statsGroup.GET("", adminHandler.GetStats)
+			statsGroup.GET("/top", adminHandler.GetTopStats)
+		}
+
+		adminGroup := authenticatedApis.Group("/admin", auth.RejectApiKey, auth.AdminAuthentication)
+		{
+			adminGroup.GET("/abuse-reports", abuseReportHandler.GetReports)
+			adminGroup.GET("/abuse-reports/:id", abuseReportHandler.GetReportByID)
+			adminGroup.POST("/abuse-reports/:id/review", abuseReportHandler.Review)
+			adminGroup.GET("/queue", adminHandler.GetQueueStats)
+			adminGroup.GET("/errors", adminHandler.GetRecentErrors)
+			adminGroup.GET("/audit-logs", auditLogHandler.GetAuditLogs)
+			adminGroup.GET("/lockouts", loginLockoutHandler.GetLockouts)
+			adminGroup.GET("/lockouts/:user_id", loginLockoutHandler.GetLockout)
+			adminGroup.DELETE("/lockouts/:user_id", loginLockoutHandler.ClearLockout)
+		}
+
+		twoFactorGroup := authenticatedApis.Group("/2fa", auth.RejectApiKey)
+		{
+			twoFactorGroup.POST("/enroll", twoFactorHandler.Enroll)
+			twoFactorGroup.POST("/confirm", twoFactorHandler.Confirm)
+			twoFactorGroup.POST("/disable", twoFactorHandler.Disable)
+		}
+
+		apiKeyGroup := authenticatedApis.Group("/api-keys", auth.RejectApiKey)
+		{
+			apiKeyGroup.POST("/", apiKeyHandler.Create)
+			apiKeyGroup.GET("/", apiKeyHandler.GetApiKeys)
+			apiKeyGroup.DELETE("/:id", apiKeyHandler.Revoke)
+		}
 	}
 }
@@ -0,0 +1,248 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTKeySet holds the key used to sign new access tokens and every key that
+// is still accepted while verifying them. Keeping retired keys in the
+// verification set lets us rotate the signing key without logging users out.
+//
+// It is configured through the environment:
+//
+//	JWT_SIGNING_ALG          HS256 (default), RS256 or EdDSA
+//	JWT_SIGNING_KID          kid header of the signing key
+//	JWT_SIGNING_KEY_PATH     PEM encoded private key used for signing
+//	JWT_VERIFICATION_KEYS    comma separated kid:path pairs of extra PEM keys
+//	JWT_ACCEPT_LEGACY_HS256  keep accepting ACCESS_TOKEN_SECRET_KEY tokens
+type JWTKeySet interface {
+	Sign(claims jwt.Claims) (string, error)
+	Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error)
+	JWKS() map[string]interface{}
+}
+
+type verificationKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	publicKey crypto.PublicKey
+}
+
+type jwtKeySet struct {
+	signingMethod jwt.SigningMethod
+	signingKid    string
+	signingKey    interface{}
+	legacySecret  []byte
+	keys          map[string]*verificationKey
+	validMethods  []string
+}
+
+var (
+	keySet     JWTKeySet
+	keySetOnce sync.Once
+)
+
+func JWTKeys() JWTKeySet {
+	keySetOnce.Do(func() {
+		ks, err := loadJWTKeySet()
+		if err != nil {
+			log.Fatal("Unable to load jwt keys:", err.Error())
+		}
+		keySet = ks
+	})
+	return keySet
+}
+
+func loadJWTKeySet() (*jwtKeySet, error) {
+	ks := &jwtKeySet{keys: map[string]*verificationKey{}}
+	alg := strings.TrimSpace(os.Getenv("JWT_SIGNING_ALG"))
+	if alg == "" || alg == jwt.SigningMethodHS256.Alg() {
+		ks.signingMethod = jwt.SigningMethodHS256
+		ks.signingKey = []byte(os.Getenv("ACCESS_TOKEN_SECRET_KEY"))
+		ks.legacySecret = ks.signingKey.([]byte)
+		ks.validMethods = []string{jwt.SigningMethodHS256.Alg()}
+		return ks, nil
+	}
+
+	method := jwt.GetSigningMethod(alg)
+	if method != jwt.SigningMethodRS256 && method != jwt.SigningMethodEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm %s", alg)
+	}
+	kid := strings.TrimSpace(os.Getenv("JWT_SIGNING_KID"))
+	if kid == "" {
+		return nil, fmt.Errorf("JWT_SIGNING_KID is required for %s", alg)
+	}
+	privateKey, err := readPrivateKey(os.Getenv("JWT_SIGNING_KEY_PATH"))
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok || methodForKey(signer.Public()) != method {
+		return nil, fmt.Errorf("signing key does not match %s", alg)
+	}
+	ks.signingMethod, ks.signingKid, ks.signingKey = method, kid, privateKey
+	ks.keys[kid] = &verificationKey{kid: kid, method: method, publicKey: signer.Public()}
+
+	for _, pair := range strings.Split(os.Getenv("JWT_VERIFICATION_KEYS"), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid verification key %q, expected kid:path", pair)
+		}
+		publicKey, err := readPublicKey(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		keyMethod := methodForKey(publicKey)
+		if keyMethod == nil {
+			return nil, fmt.Errorf("unsupported verification key type for kid %s", parts[0])
+		}
+		ks.keys[parts[0]] = &verificationKey{kid: parts[0], method: keyMethod, publicKey: publicKey}
+	}
+
+	if os.Getenv("JWT_ACCEPT_LEGACY_HS256") == "true" && os.Getenv("ACCESS_TOKEN_SECRET_KEY") != "" {
+		ks.legacySecret = []byte(os.Getenv("ACCESS_TOKEN_SECRET_KEY"))
+	}
+
+	seen := map[string]bool{}
+	for _, key := range ks.keys {
+		if !seen[key.method.Alg()] {
+			seen[key.method.Alg()] = true
+			ks.validMethods = append(ks.validMethods, key.method.Alg())
+		}
+	}
+	if ks.legacySecret != nil {
+		ks.validMethods = append(ks.validMethods, jwt.SigningMethodHS256.Alg())
+	}
+
+	return ks, nil
+}
+
+func (ks *jwtKeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signingMethod, claims)
+	if ks.signingKid != "" {
+		token.Header["kid"] = ks.signingKid
+	}
+	return token.SignedString(ks.signingKey)
+}
+
+// Parse only accepts the algorithms of the configured keys, so a token can
+// never pick its own verification method.
+func (ks *jwtKeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+			if ks.legacySecret == nil {
+				return nil, jwt.ErrTokenSignatureInvalid
+			}
+			return ks.legacySecret, nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.keys[kid]
+		if !ok || key.method.Alg() != token.Method.Alg() {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key.publicKey, nil
+	}, jwt.WithValidMethods(ks.validMethods))
+}
+
+// JWKS returns the public verification keys in RFC 7517 format. Symmetric
+// secrets are never published.
+func (ks *jwtKeySet) JWKS() map[string]interface{} {
+	keys := []map[string]string{}
+	for _, key := range ks.keys {
+		switch publicKey := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": key.method.Alg(),
+				"kid": key.kid,
+				"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "OKP",
+				"crv": "Ed25519",
+				"use": "sig",
+				"alg": key.method.Alg(),
+				"kid": key.kid,
+				"x":   base64.RawURLEncoding.EncodeToString(publicKey),
+			})
+		}
+	}
+	return map[string]interface{}{"keys": keys}
+}
+
+func methodForKey(publicKey crypto.PublicKey) jwt.SigningMethod {
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA
+	}
+	return nil
+}
+
+func readPEMBlock(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	return block, nil
+}
+
+func readPrivateKey(path string) (crypto.PrivateKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// readPublicKey accepts either a public key or a private key, in which case
+// only its public half is kept.
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	privateKey, err := readPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key in %s", path)
+	}
+	return signer.Public(), nil
+}
@@ -0,0 +1,137 @@
+package security
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeEd25519Key stores a fresh private key as PEM and returns its path.
+func writeEd25519Key(t *testing.T, name string) (string, ed25519.PrivateKey) {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, privateKey
+}
+
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{Subject: "user-1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	oldPath, oldKey := writeEd25519Key(t, "old")
+	newPath, newKey := writeEd25519Key(t, "new")
+	_, strayKey := writeEd25519Key(t, "stray")
+
+	tests := []struct {
+		name             string
+		verificationKeys string
+		acceptLegacy     string
+		token            func(t *testing.T) string
+		wantValid        bool
+	}{
+		{name: "current key", token: func(t *testing.T) string { return signWith(t, jwt.SigningMethodEdDSA, "new", newKey) }, wantValid: true},
+		{name: "retired key still listed", verificationKeys: "old:" + oldPath, token: func(t *testing.T) string { return signWith(t, jwt.SigningMethodEdDSA, "old", oldKey) }, wantValid: true},
+		{name: "retired key dropped", token: func(t *testing.T) string { return signWith(t, jwt.SigningMethodEdDSA, "old", oldKey) }},
+		{name: "unknown key under a known kid", token: func(t *testing.T) string { return signWith(t, jwt.SigningMethodEdDSA, "new", strayKey) }},
+		{name: "legacy secret accepted", acceptLegacy: "true", token: func(t *testing.T) string { return signWith(t, jwt.SigningMethodHS256, "", []byte("legacy-secret")) }, wantValid: true},
+		{name: "legacy secret refused", token: func(t *testing.T) string { return signWith(t, jwt.SigningMethodHS256, "", []byte("legacy-secret")) }},
+		{name: "public key used as hmac secret", token: func(t *testing.T) string {
+			return signWith(t, jwt.SigningMethodHS256, "new", []byte(newKey.Public().(ed25519.PublicKey)))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SIGNING_ALG", "EdDSA")
+			t.Setenv("JWT_SIGNING_KID", "new")
+			t.Setenv("JWT_SIGNING_KEY_PATH", newPath)
+			t.Setenv("JWT_VERIFICATION_KEYS", tt.verificationKeys)
+			t.Setenv("JWT_ACCEPT_LEGACY_HS256", tt.acceptLegacy)
+			t.Setenv("ACCESS_TOKEN_SECRET_KEY", "legacy-secret")
+			ks, err := loadJWTKeySet()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = ks.Parse(tt.token(t), &jwt.RegisteredClaims{})
+			if (err == nil) != tt.wantValid {
+				t.Errorf("Parse() error = %v, want valid %v", err, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestJWTKeySetSignsWithCurrentKey(t *testing.T) {
+	oldPath, _ := writeEd25519Key(t, "old")
+	newPath, _ := writeEd25519Key(t, "new")
+	t.Setenv("JWT_SIGNING_ALG", "EdDSA")
+	t.Setenv("JWT_SIGNING_KID", "new")
+	t.Setenv("JWT_SIGNING_KEY_PATH", newPath)
+	t.Setenv("JWT_VERIFICATION_KEYS", "old:"+oldPath)
+	t.Setenv("JWT_ACCEPT_LEGACY_HS256", "")
+	ks, err := loadJWTKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := ks.Sign(jwt.RegisteredClaims{Subject: "user-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := ks.Parse(signed, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Header["kid"] != "new" {
+		t.Errorf("kid = %v, want new", token.Header["kid"])
+	}
+
+	kids := map[string]bool{}
+	for _, key := range ks.JWKS()["keys"].([]map[string]string) {
+		if key["kty"] != "OKP" {
+			t.Errorf("unexpected key type %q in the jwks", key["kty"])
+		}
+		kids[key["kid"]] = true
+	}
+	if len(kids) != 2 || !kids["old"] || !kids["new"] {
+		t.Errorf("jwks kids = %v, want old and new", kids)
+	}
+}
+
+func TestLoadJWTKeySetRejectsMismatchedKey(t *testing.T) {
+	path, _ := writeEd25519Key(t, "key")
+	t.Setenv("JWT_SIGNING_ALG", "RS256")
+	t.Setenv("JWT_SIGNING_KID", "key")
+	t.Setenv("JWT_SIGNING_KEY_PATH", path)
+	t.Setenv("JWT_VERIFICATION_KEYS", "")
+	if _, err := loadJWTKeySet(); err == nil {
+		t.Error("expected an error for an ed25519 key with RS256")
+	}
+}
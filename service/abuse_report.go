@@ -0,0 +1,240 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"urllite/store"
+	"urllite/types"
+	"urllite/types/dtos"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	abuseReportDetailsLimit = 2000
+	// A reporter is counted once per link in this period.
+	abuseReportWindow = 24 * time.Hour
+)
+
+type abuseReportService struct {
+	store       store.Store
+	urlService  UrlService
+	userService UserService
+}
+
+// AbuseReportService takes reports of phishing, malware and spam links from
+// anyone and lets admins act on them.
+type AbuseReportService interface {
+	Report(host, short_url, reporterIP string, reportDto dtos.AbuseReportDTO) (*types.AbuseReport, *types.ApplicationError)
+	GetReports(filter dtos.AbuseReportFilterDTO) ([]*types.AbuseReport, *types.ApplicationError)
+	GetReportByID(id string) (*types.AbuseReport, *types.ApplicationError)
+	Review(id, actor_id string, reviewDto dtos.AbuseReviewDTO) (*types.AbuseReport, *types.ApplicationError)
+}
+
+func NewAbuseReportService() AbuseReportService {
+	s := store.NewStore()
+	return &abuseReportService{store: s, urlService: NewUrlService(), userService: NewUserService()}
+}
+
+func (a *abuseReportService) Report(host, short_url, reporterIP string, reportDto dtos.AbuseReportDTO) (*types.AbuseReport, *types.ApplicationError) {
+	category := strings.ToLower(strings.TrimSpace(reportDto.Category))
+	if !types.IsValidAbuseCategory(category) {
+		return nil, &types.ApplicationError{
+			Message:        "Category must be one of " + strings.Join(types.AbuseCategories, ", "),
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	details := strings.TrimSpace(reportDto.Details)
+	if len(details) > abuseReportDetailsLimit {
+		return nil, &types.ApplicationError{
+			Message:        "Details are too long",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	email := strings.TrimSpace(reportDto.Email)
+	if email != "" && !utils.EmailValidation(email) {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid Email ID",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	url, appErr := a.urlService.GetUrlByShortUrl(host, short_url)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	report := &types.AbuseReport{
+		UrlID:         url.ID,
+		Domain:        url.Domain,
+		ShortUrl:      url.ShortUrl,
+		Destination:   url.LongUrl,
+		Category:      category,
+		Details:       details,
+		ReporterEmail: email,
+		Status:        types.AbuseReportPending,
+	}
+
+	// Repeated reports from one address would only flood the queue, they
+	// are accepted but not stored.
+	first, err := sharedRedis().SetIfAbsent("abuse_report_"+url.ID.String()+"_"+reporterIP, "1", abuseReportWindow)
+	if err != nil {
+		log.Printf("Unable to check repeated abuse report: %v", err)
+	} else if !first {
+		return report, nil
+	}
+
+	err = a.store.CreateAbuseReport(report)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to save the report",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	go a.notifyAdmins(report)
+	return report, nil
+}
+
+func (a *abuseReportService) notifyAdmins(report *types.AbuseReport) {
+	mailer := utils.NewMailer()
+	for _, role := range []string{types.RoleOwner, types.RoleAdmin} {
+		admins, err := a.store.GetUsersByRole(role)
+		if err != nil {
+			log.Printf("Unable to find admins to notify of abuse report %s: %v", report.ID, err)
+			return
+		}
+		for _, admin := range admins {
+			if err := mailer.SendAbuseReportNotice(admin, report); err != nil {
+				log.Printf("Unable to notify %s of abuse report %s: %v", admin.Email, report.ID, err)
+			}
+		}
+	}
+}
+
+func (a *abuseReportService) GetReports(filter dtos.AbuseReportFilterDTO) ([]*types.AbuseReport, *types.ApplicationError) {
+	reports, err := a.store.GetAbuseReports(filter.Status)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get abuse reports",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	return reports, nil
+}
+
+func (a *abuseReportService) GetReportByID(id string) (*types.AbuseReport, *types.ApplicationError) {
+	report, err := a.store.GetAbuseReportByID(id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get the abuse report",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if report == nil {
+		return nil, &types.ApplicationError{
+			Message:        "Abuse report not found",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+	return report, nil
+}
+
+func (a *abuseReportService) Review(id, actor_id string, reviewDto dtos.AbuseReviewDTO) (*types.AbuseReport, *types.ApplicationError) {
+	report, appErr := a.GetReportByID(id)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if report.Status != types.AbuseReportPending {
+		return nil, &types.ApplicationError{
+			Message:        "Abuse report was already reviewed",
+			HttpStatusCode: http.StatusConflict,
+		}
+	}
+
+	actorID, err := gocql.ParseUUID(actor_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid user id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	switch reviewDto.Action {
+	case types.AbuseActionDismiss:
+		report.Status = types.AbuseReportDismissed
+	case types.AbuseActionDisableLink:
+		if appErr := a.disableLink(report); appErr != nil {
+			return nil, appErr
+		}
+		report.Status = types.AbuseReportLinkDisabled
+	case types.AbuseActionSuspendOwner:
+		url, appErr := a.reportedUrl(report)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if appErr := a.userService.SuspendUser(actor_id, url.UserID.String()); appErr != nil {
+			return nil, appErr
+		}
+		report.Status = types.AbuseReportOwnerSuspended
+	default:
+		return nil, &types.ApplicationError{
+			Message:        "Action must be one of dismiss, disable_link or suspend_owner",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	report.ReviewedBy, report.ReviewNote = actorID, strings.TrimSpace(reviewDto.Note)
+	err = a.store.ReviewAbuseReport(report)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to save the review",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	return report, nil
+}
+
+func (a *abuseReportService) reportedUrl(report *types.AbuseReport) (*types.URL, *types.ApplicationError) {
+	url, err := a.store.GetUrlByID(report.UrlID.String())
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find the reported url",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if url == nil {
+		return nil, &types.ApplicationError{
+			Message:        "The reported url no longer exists",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+	return url, nil
+}
+
+func (a *abuseReportService) disableLink(report *types.AbuseReport) *types.ApplicationError {
+	url, appErr := a.reportedUrl(report)
+	if appErr != nil {
+		return appErr
+	}
+
+	url.Status = types.UrlStatusDisabled
+	err := a.store.UpdateUrlStatus(url)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to disable the url",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"time"
+	"urllite/store"
+	"urllite/tasks"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gocql/gocql"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	topLinksKey  = "top_links"
+	topUsersKey  = "top_users"
+	recentErrors = "recent_errors"
+
+	recentErrorsLimit = 100
+	defaultStatsDays  = 30
+	maxStatsDays      = 365
+	defaultTopLimit   = 10
+	maxTopLimit       = 100
+)
+
+type adminService struct {
+	store store.Store
+}
+
+// AdminService answers the admin dashboard from the counters kept as users,
+// links and clicks are written, nothing here scans a table.
+type AdminService interface {
+	GetStats(filter dtos.AdminStatsFilterDTO) (*types.SystemStats, *types.ApplicationError)
+	GetTopLinks(filter dtos.AdminStatsFilterDTO) ([]*types.TopLink, *types.ApplicationError)
+	GetTopUsers(filter dtos.AdminStatsFilterDTO) ([]*types.TopUser, *types.ApplicationError)
+	GetQueueStats() (*types.QueueStats, *types.ApplicationError)
+	GetRecentErrors(filter dtos.AdminStatsFilterDTO) ([]*types.RecordedError, *types.ApplicationError)
+}
+
+func NewAdminService() AdminService {
+	s := store.NewStore()
+	return &adminService{store: s}
+}
+
+// RecordTopClick counts the click on the leaderboards of links and of the
+// users who created them. Bots and suspicious clicks are left out, like in
+// the rest of the analytics.
+func RecordTopClick(url *types.URL, urlLog *types.UrlLog) {
+	if !types.IsHumanTraffic(urlLog.TrafficClass) {
+		return
+	}
+	if err := sharedRedis().IncrementScore(topLinksKey, url.ID.String(), 1); err != nil {
+		log.Printf("Unable to count click of %s: %v", url.ID, err)
+	}
+	if url.UserID == (gocql.UUID{}) {
+		return
+	}
+	if err := sharedRedis().IncrementScore(topUsersKey, url.UserID.String(), 1); err != nil {
+		log.Printf("Unable to count click of user %s: %v", url.UserID, err)
+	}
+}
+
+// RecordError keeps the error for the dashboard, only the latest ones are
+// kept.
+func RecordError(source, message string) {
+	recorded, err := json.Marshal(types.RecordedError{Source: source, Message: message, OccurredAt: time.Now()})
+	if err != nil {
+		return
+	}
+	if err := sharedRedis().PushCapped(recentErrors, string(recorded), recentErrorsLimit); err != nil {
+		log.Printf("Unable to record error from %s: %v", source, err)
+	}
+}
+
+func statsDays(filter dtos.AdminStatsFilterDTO) int {
+	if filter.Days <= 0 {
+		return defaultStatsDays
+	}
+	if filter.Days > maxStatsDays {
+		return maxStatsDays
+	}
+	return filter.Days
+}
+
+func topLimit(filter dtos.AdminStatsFilterDTO) int64 {
+	if filter.Limit <= 0 {
+		return defaultTopLimit
+	}
+	if filter.Limit > maxTopLimit {
+		return maxTopLimit
+	}
+	return int64(filter.Limit)
+}
+
+// dailyCounts lists every day of the period, oldest first, with zero for the
+// days nothing was counted.
+func (a *adminService) dailyCounts(metric string, days int) ([]*types.DailyCount, error) {
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -(days - 1))
+	counts, err := a.store.GetSystemStatDays(metric, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	dailyCounts := make([]*types.DailyCount, 0, days)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		dailyCounts = append(dailyCounts, &types.DailyCount{Date: date, Count: counts[date]})
+	}
+	return dailyCounts, nil
+}
+
+func (a *adminService) GetStats(filter dtos.AdminStatsFilterDTO) (*types.SystemStats, *types.ApplicationError) {
+	days := statsDays(filter)
+	totals := map[string]int64{}
+	for _, metric := range []string{types.StatUsers, types.StatVerifiedUsers, types.StatLinks, types.StatClicks} {
+		total, err := a.store.GetSystemStatTotal(metric)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get stats",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		totals[metric] = total
+	}
+
+	perDay := map[string][]*types.DailyCount{}
+	for _, metric := range []string{types.StatUsers, types.StatLinks, types.StatClicks} {
+		dailyCounts, err := a.dailyCounts(metric, days)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get stats",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		perDay[metric] = dailyCounts
+	}
+
+	return &types.SystemStats{
+		Users: types.UserStats{
+			Total:         totals[types.StatUsers],
+			Verified:      totals[types.StatVerifiedUsers],
+			Unverified:    totals[types.StatUsers] - totals[types.StatVerifiedUsers],
+			SignupsPerDay: perDay[types.StatUsers],
+		},
+		Links: types.LinkStats{
+			Total:         totals[types.StatLinks],
+			CreatedPerDay: perDay[types.StatLinks],
+		},
+		Clicks: types.ClickStats{
+			Total:  totals[types.StatClicks],
+			PerDay: perDay[types.StatClicks],
+		},
+	}, nil
+}
+
+// GetTopLinks leaves out links that were erased since they were counted.
+func (a *adminService) GetTopLinks(filter dtos.AdminStatsFilterDTO) ([]*types.TopLink, *types.ApplicationError) {
+	scores, err := sharedRedis().TopScores(topLinksKey, topLimit(filter))
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get top links",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	topLinks := []*types.TopLink{}
+	for _, score := range scores {
+		url, err := a.store.GetUrlByID(score.Member)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get top links",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		if url == nil || !url.DeletedAt.IsZero() {
+			continue
+		}
+		topLinks = append(topLinks, &types.TopLink{
+			UrlID:    url.ID.String(),
+			Domain:   url.Domain,
+			ShortUrl: url.ShortUrl,
+			UserID:   url.UserID.String(),
+			Clicks:   int64(score.Score),
+		})
+	}
+	return topLinks, nil
+}
+
+// GetTopUsers leaves out users that were erased since they were counted.
+func (a *adminService) GetTopUsers(filter dtos.AdminStatsFilterDTO) ([]*types.TopUser, *types.ApplicationError) {
+	scores, err := sharedRedis().TopScores(topUsersKey, topLimit(filter))
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get top users",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	topUsers := []*types.TopUser{}
+	for _, score := range scores {
+		user, err := a.store.GetUserByID(score.Member)
+		if err == gocql.ErrNotFound || (err == nil && user == nil) {
+			continue
+		} else if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get top users",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		topUsers = append(topUsers, &types.TopUser{
+			UserID: user.ID.String(),
+			Name:   user.Name,
+			Email:  user.Email,
+			Clicks: int64(score.Score),
+		})
+	}
+	return topUsers, nil
+}
+
+// GetQueueStats reports the queue of the click logs, which exists once the
+// first click was enqueued.
+func (a *adminService) GetQueueStats() (*types.QueueStats, *types.ApplicationError) {
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: os.Getenv("REDIS_ADDR")})
+	defer inspector.Close()
+
+	queues, err := inspector.Queues()
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get queue stats",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	queueStats := &types.QueueStats{Queue: tasks.UrlLogQueue}
+	for _, queue := range queues {
+		if queue != tasks.UrlLogQueue {
+			continue
+		}
+		info, err := inspector.GetQueueInfo(queue)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get queue stats",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		queueStats.Size = info.Size
+		queueStats.Pending = info.Pending
+		queueStats.Active = info.Active
+		queueStats.Scheduled = info.Scheduled
+		queueStats.Retry = info.Retry
+		queueStats.Archived = info.Archived
+		queueStats.LatencyMs = info.Latency.Milliseconds()
+	}
+	return queueStats, nil
+}
+
+func (a *adminService) GetRecentErrors(filter dtos.AdminStatsFilterDTO) ([]*types.RecordedError, *types.ApplicationError) {
+	limit := topLimit(filter)
+	if filter.Limit <= 0 {
+		limit = recentErrorsLimit
+	}
+	entries, err := sharedRedis().Range(recentErrors, limit)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get recent errors",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	recordedErrors := []*types.RecordedError{}
+	for _, entry := range entries {
+		var recorded types.RecordedError
+		if err := json.Unmarshal([]byte(entry), &recorded); err != nil {
+			continue
+		}
+		recordedErrors = append(recordedErrors, &recorded)
+	}
+	return recordedErrors, nil
+}
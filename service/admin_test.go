@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+func TestRecordTopClickCountsHumanTraffic(t *testing.T) {
+	tests := []struct {
+		trafficClass string
+		want         float64
+	}{
+		{trafficClass: types.TrafficHuman, want: 1},
+		{trafficClass: "", want: 1},
+		{trafficClass: types.TrafficBot, want: 0},
+		{trafficClass: types.TrafficSuspicious, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run("class "+tt.trafficClass, func(t *testing.T) {
+			redis := useFakeRedis(t)
+			url := &types.URL{ID: gocql.MustRandomUUID(), UserID: gocql.MustRandomUUID()}
+
+			RecordTopClick(url, &types.UrlLog{TrafficClass: tt.trafficClass})
+			if got := redis.scores[topLinksKey][url.ID.String()]; got != tt.want {
+				t.Errorf("link score = %v, want %v", got, tt.want)
+			}
+			if got := redis.scores[topUsersKey][url.UserID.String()]; got != tt.want {
+				t.Errorf("user score = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
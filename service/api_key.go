@@ -0,0 +1,183 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"urllite/store"
+	"urllite/types"
+	"urllite/types/dtos"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+)
+
+const apiKeySecretLength = 40
+
+type apiKeyService struct {
+	store store.Store
+}
+
+type ApiKeyService interface {
+	Create(user_id string, apiKeyDto dtos.ApiKeyDTO) (*types.ApiKey, string, *types.ApplicationError)
+	GetApiKeysOfUser(user_id string) ([]*types.ApiKey, *types.ApplicationError)
+	Revoke(id, user_id string) *types.ApplicationError
+	Authenticate(rawKey string) (*types.ApiKey, *types.User, *types.ApplicationError)
+}
+
+func NewApiKeyService() ApiKeyService {
+	s := store.NewStore()
+	return &apiKeyService{store: s}
+}
+
+func (a *apiKeyService) Create(user_id string, apiKeyDto dtos.ApiKeyDTO) (*types.ApiKey, string, *types.ApplicationError) {
+	name := strings.TrimSpace(apiKeyDto.Name)
+	if name == "" {
+		return nil, "", &types.ApplicationError{
+			Message:        "Api key name is required",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	if len(apiKeyDto.Scopes) == 0 {
+		return nil, "", &types.ApplicationError{
+			Message:        "At least one scope is required",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	for _, scope := range apiKeyDto.Scopes {
+		if !types.IsValidApiKeyScope(scope) {
+			return nil, "", &types.ApplicationError{
+				Message:        "Invalid scope: " + scope,
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+	}
+
+	if !apiKeyDto.ExpiresAt.IsZero() && apiKeyDto.ExpiresAt.Before(time.Now()) {
+		return nil, "", &types.ApplicationError{
+			Message:        "Expiry should be in the future",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	userID, err := gocql.ParseUUID(user_id)
+	if err != nil {
+		return nil, "", &types.ApplicationError{
+			Message:        "Invalid user id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	secret, err := utils.GenerateRandomString(apiKeySecretLength)
+	if err != nil {
+		return nil, "", &types.ApplicationError{
+			Message:        "Unable to generate api key",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	rawKey := types.ApiKeyPrefix + secret
+
+	apiKey := &types.ApiKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    rawKey[:len(types.ApiKeyPrefix)+6],
+		HashedKey: utils.HashToken(rawKey),
+		Scopes:    apiKeyDto.Scopes,
+		Status:    "active",
+		ExpiresAt: apiKeyDto.ExpiresAt,
+	}
+	err = a.store.CreateApiKey(apiKey)
+	if err != nil {
+		return nil, "", &types.ApplicationError{
+			Message:        "Unable to create api key",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return apiKey, rawKey, nil
+}
+
+func (a *apiKeyService) GetApiKeysOfUser(user_id string) ([]*types.ApiKey, *types.ApplicationError) {
+	apiKeys, err := a.store.GetApiKeysOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get api keys",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return apiKeys, nil
+}
+
+func (a *apiKeyService) Revoke(id, user_id string) *types.ApplicationError {
+	apiKey, err := a.store.GetApiKeyByID(id)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to find the api key",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	if apiKey == nil || apiKey.UserID.String() != user_id {
+		return &types.ApplicationError{
+			Message:        "No api key found with given id",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	err = a.store.RevokeApiKey(apiKey)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to revoke api key",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+func (a *apiKeyService) Authenticate(rawKey string) (*types.ApiKey, *types.User, *types.ApplicationError) {
+	if !strings.HasPrefix(rawKey, types.ApiKeyPrefix) {
+		return nil, nil, &types.ApplicationError{
+			Message:        "Invalid api key",
+			HttpStatusCode: http.StatusUnauthorized,
+		}
+	}
+
+	apiKey, err := a.store.GetApiKeyByHash(utils.HashToken(rawKey))
+	if err != nil {
+		return nil, nil, &types.ApplicationError{
+			Message:        "Unable to verify api key",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	if apiKey == nil || !apiKey.IsActive() {
+		return nil, nil, &types.ApplicationError{
+			Message:        "Invalid api key",
+			HttpStatusCode: http.StatusUnauthorized,
+		}
+	}
+
+	user, err := a.store.GetUserByID(apiKey.UserID.String())
+	if err != nil || user == nil || !user.IsActive() {
+		return nil, nil, &types.ApplicationError{
+			Message:        "Invalid api key",
+			HttpStatusCode: http.StatusUnauthorized,
+		}
+	}
+
+	if err := a.store.TouchApiKey(apiKey); err != nil {
+		log.Printf("Unable to record use of api key %s: %v", apiKey.ID, err)
+	}
+	return apiKey, user, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+	"urllite/types"
+	"urllite/types/dtos"
+)
+
+func TestAuthenticateApiKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		rawKey     func(rawKey string) string
+		prepare    func(apiKey *types.ApiKey, user *types.User)
+		touchErr   error
+		wantStatus int
+	}{
+		{name: "valid key"},
+		{name: "valid key whose use cannot be recorded", touchErr: errors.New("timeout")},
+		{name: "missing prefix", rawKey: func(rawKey string) string { return rawKey[len(types.ApiKeyPrefix):] }, wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", rawKey: func(rawKey string) string { return rawKey + "x" }, wantStatus: http.StatusUnauthorized},
+		{name: "revoked key", prepare: func(apiKey *types.ApiKey, user *types.User) { apiKey.Status, apiKey.RevokedAt = "revoked", time.Now() }, wantStatus: http.StatusUnauthorized},
+		{name: "expired key", prepare: func(apiKey *types.ApiKey, user *types.User) { apiKey.ExpiresAt = time.Now().Add(-time.Minute) }, wantStatus: http.StatusUnauthorized},
+		{name: "suspended user", prepare: func(apiKey *types.ApiKey, user *types.User) { user.Status = types.UserStatusSuspended }, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			store.touchErr = tt.touchErr
+			user := &types.User{Email: "owner@example.com"}
+			store.CreateUser(user)
+			service := &apiKeyService{store: store}
+
+			apiKey, rawKey, appErr := service.Create(user.ID.String(), dtos.ApiKeyDTO{Name: "ci", Scopes: []string{types.ScopeUrlsRead}})
+			if appErr != nil {
+				t.Fatal(appErr)
+			}
+			if tt.prepare != nil {
+				tt.prepare(apiKey, user)
+			}
+			if tt.rawKey != nil {
+				rawKey = tt.rawKey(rawKey)
+			}
+
+			authenticated, authenticatedUser, appErr := service.Authenticate(rawKey)
+			if tt.wantStatus != 0 {
+				if appErr == nil || appErr.HttpStatusCode != tt.wantStatus {
+					t.Fatalf("Authenticate = %v, want status %d", appErr, tt.wantStatus)
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatal(appErr)
+			}
+			if authenticated.ID != apiKey.ID || authenticatedUser.ID != user.ID {
+				t.Errorf("authenticated key %s of %s", authenticated.ID, authenticatedUser.ID)
+			}
+			if authenticated.LastUsedAt.IsZero() {
+				t.Error("the use of the key must be recorded")
+			}
+		})
+	}
+}
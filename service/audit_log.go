@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"time"
+	"urllite/store"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gocql/gocql"
+)
+
+type auditLogService struct {
+	store store.Store
+}
+
+// AuditLogService keeps the audit trail of security relevant and
+// administrative actions.
+type AuditLogService interface {
+	Record(auditLog *types.AuditLog, before, after interface{})
+	GetAuditLogs(filter dtos.AuditLogFilterDTO) ([]*types.AuditLog, *types.ApplicationError)
+}
+
+func NewAuditLogService() AuditLogService {
+	s := store.NewStore()
+	return &auditLogService{store: s}
+}
+
+// Record stores the entry with the fields that differ between before and
+// after, either may be nil for a creation or a deletion. A failure is logged,
+// the action it records has already happened.
+func (a *auditLogService) Record(auditLog *types.AuditLog, before, after interface{}) {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		log.Printf("Unable to diff audit log of %s: %v", auditLog.Action, err)
+	}
+	auditLog.Changes = changes
+
+	if err := a.store.CreateAuditLog(auditLog); err != nil {
+		log.Printf("Unable to record audit log of %s on %s %s: %v", auditLog.Action, auditLog.TargetType, auditLog.TargetID, err)
+	}
+}
+
+// auditChanges compares the json fields of the two values, updated_at is
+// left out as every edit changes it.
+func auditChanges(before, after interface{}) (types.AuditChanges, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := types.AuditChanges{}
+	for field, value := range beforeFields {
+		if afterValue, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, afterValue) {
+			changes[field] = types.AuditChange{Before: value, After: afterValue}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = types.AuditChange{After: value}
+		}
+	}
+	delete(changes, "updated_at")
+	return changes, nil
+}
+
+func auditFields(value interface{}) (map[string]interface{}, error) {
+	if value == nil || reflect.ValueOf(value).IsZero() {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+func (a *auditLogService) GetAuditLogs(filterDto dtos.AuditLogFilterDTO) ([]*types.AuditLog, *types.ApplicationError) {
+	if filterDto.ActorID != "" {
+		if _, err := gocql.ParseUUID(filterDto.ActorID); err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Invalid actor id",
+				HttpStatusCode: http.StatusBadRequest,
+				Err:            err,
+			}
+		}
+	}
+
+	filter := types.AuditLogFilter{
+		ActorID:  filterDto.ActorID,
+		TargetID: filterDto.TargetID,
+		From:     filterDto.From,
+		To:       filterDto.To,
+		Limit:    filterDto.Limit,
+	}
+	if filter.To.IsZero() {
+		filter.To = time.Now()
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.Add(-types.DefaultAuditLogPeriod)
+	}
+	if filter.From.After(filter.To) {
+		return nil, &types.ApplicationError{
+			Message:        "from has to be before to",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	if filter.To.Sub(filter.From) > types.MaxAuditLogPeriod {
+		return nil, &types.ApplicationError{
+			Message:        "The period can be at most 366 days",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = types.DefaultAuditLogLimit
+	} else if filter.Limit > types.MaxAuditLogLimit {
+		filter.Limit = types.MaxAuditLogLimit
+	}
+
+	auditLogs, err := a.store.GetAuditLogs(filter)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get audit logs",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	return auditLogs, nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"urllite/types"
+)
+
+func TestAuditChanges(t *testing.T) {
+	active := &types.ApiKey{Name: "ci", Status: "active"}
+	revoked := &types.ApiKey{Name: "ci", Status: "revoked"}
+
+	tests := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+		want   types.AuditChanges
+	}{
+		{name: "revoked api key", before: active, after: revoked, want: types.AuditChanges{"status": {Before: "active", After: "revoked"}}},
+		{name: "two factor enabled", before: map[string]interface{}{"two_factor_enabled": false}, after: map[string]interface{}{"two_factor_enabled": true}, want: types.AuditChanges{"two_factor_enabled": {Before: false, After: true}}},
+		{name: "nothing changed", before: active, after: active, want: types.AuditChanges{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := auditChanges(tt.before, tt.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(changes, tt.want) {
+				t.Errorf("changes = %+v, want %+v", changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditChangesOfCreation(t *testing.T) {
+	changes, err := auditChanges((*types.ApiKey)(nil), &types.ApiKey{Name: "ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := changes["name"]; change.Before != nil || change.After != "ci" {
+		t.Errorf("name change = %+v", change)
+	}
+}
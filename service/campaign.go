@@ -0,0 +1,439 @@
+package service
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"urllite/store"
+	"urllite/types"
+	"urllite/types/dtos"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+)
+
+const maximumCampaignTopLinks = 10
+
+type campaignService struct {
+	store store.Store
+}
+
+type CampaignService interface {
+	Create(campaignDto dtos.CampaignDTO, user_id string) (*types.Campaign, *types.ApplicationError)
+	GetCampaignsOfUser(user_id string) ([]*types.Campaign, *types.ApplicationError)
+	GetCampaignByID(id, user_id string) (*types.Campaign, []*types.URL, *types.ApplicationError)
+	Update(id, user_id string, campaignDto dtos.CampaignDTO) (*types.Campaign, *types.ApplicationError)
+	Delete(id, user_id string) *types.ApplicationError
+	AddUrls(id, user_id string, urlsDto dtos.CampaignUrlsDTO) *types.ApplicationError
+	RemoveUrl(id, user_id, url_id string) *types.ApplicationError
+	GetReport(id, user_id string, filter dtos.AnalyticsFilterDTO) (*types.CampaignReport, *types.ApplicationError)
+}
+
+func NewCampaignService() CampaignService {
+	s := store.NewStore()
+	return &campaignService{store: s}
+}
+
+func (cs *campaignService) Create(campaignDto dtos.CampaignDTO, user_id string) (*types.Campaign, *types.ApplicationError) {
+	userID, err := gocql.ParseUUID(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid user id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	campaign := &types.Campaign{UserID: userID}
+	appErr := applyCampaignDto(campaign, campaignDto)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if campaignDto.WorkspaceID != "" {
+		member, err := cs.store.GetWorkspaceMember(campaignDto.WorkspaceID, user_id)
+		if err != nil || !member.CanAct(types.WorkspaceRoleEditor) {
+			return nil, &types.ApplicationError{
+				Message:        "Only workspace editors can add workspace campaigns",
+				HttpStatusCode: http.StatusForbidden,
+				Err:            err,
+			}
+		}
+		campaign.WorkspaceID = member.WorkspaceID
+	}
+
+	err = cs.store.CreateCampaign(campaign)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to create campaign",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return campaign, nil
+}
+
+// GetCampaignsOfUser lists personal campaigns and the campaigns of every
+// workspace the user belongs to.
+func (cs *campaignService) GetCampaignsOfUser(user_id string) ([]*types.Campaign, *types.ApplicationError) {
+	campaigns, err := cs.store.GetCampaignsOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get campaigns",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	memberships, err := cs.store.GetWorkspaceMembershipsOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get campaigns",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	for _, membership := range memberships {
+		workspaceCampaigns, err := cs.store.GetCampaignsOfWorkspace(membership.WorkspaceID.String())
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get campaigns",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		campaigns = append(campaigns, workspaceCampaigns...)
+	}
+
+	return campaigns, nil
+}
+
+func (cs *campaignService) GetCampaignByID(id, user_id string) (*types.Campaign, []*types.URL, *types.ApplicationError) {
+	campaign, appErr := cs.getAuthorizedCampaign(id, user_id, types.WorkspaceRoleViewer)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	urls, appErr := cs.campaignUrls(campaign)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	return campaign, urls, nil
+}
+
+func (cs *campaignService) Update(id, user_id string, campaignDto dtos.CampaignDTO) (*types.Campaign, *types.ApplicationError) {
+	campaign, appErr := cs.getAuthorizedCampaign(id, user_id, types.WorkspaceRoleEditor)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	appErr = applyCampaignDto(campaign, campaignDto)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	err := cs.store.UpdateCampaign(campaign)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to update campaign",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return campaign, nil
+}
+
+func (cs *campaignService) Delete(id, user_id string) *types.ApplicationError {
+	campaign, appErr := cs.getAuthorizedCampaign(id, user_id, types.WorkspaceRoleEditor)
+	if appErr != nil {
+		return appErr
+	}
+
+	err := cs.store.DeleteCampaign(campaign)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to delete campaign",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+// AddUrls adds links of the campaign owner to the campaign: personal links
+// of the user for personal campaigns, workspace links for workspace ones.
+func (cs *campaignService) AddUrls(id, user_id string, urlsDto dtos.CampaignUrlsDTO) *types.ApplicationError {
+	campaign, appErr := cs.getAuthorizedCampaign(id, user_id, types.WorkspaceRoleEditor)
+	if appErr != nil {
+		return appErr
+	}
+	if len(urlsDto.UrlIDs) == 0 {
+		return &types.ApplicationError{
+			Message:        "Provide the url_ids to add",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	var urls []*types.URL
+	for _, urlID := range urlsDto.UrlIDs {
+		url, err := cs.store.GetUrlByID(urlID)
+		if err != nil || url == nil || !url.DeletedAt.IsZero() {
+			return &types.ApplicationError{
+				Message:        "No url found with id " + urlID,
+				HttpStatusCode: http.StatusBadRequest,
+				Err:            err,
+			}
+		}
+		if url.WorkspaceID != campaign.WorkspaceID || (campaign.WorkspaceID == (gocql.UUID{}) && url.UserID != campaign.UserID) {
+			return &types.ApplicationError{
+				Message:        "The link " + urlID + " does not belong to the campaign owner",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		urls = append(urls, url)
+	}
+
+	for _, url := range urls {
+		err := cs.store.AddCampaignUrl(campaign.ID, url.ID)
+		if err != nil {
+			return &types.ApplicationError{
+				Message:        "Unable to add url to campaign",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+	}
+
+	return nil
+}
+
+func (cs *campaignService) RemoveUrl(id, user_id, url_id string) *types.ApplicationError {
+	campaign, appErr := cs.getAuthorizedCampaign(id, user_id, types.WorkspaceRoleEditor)
+	if appErr != nil {
+		return appErr
+	}
+
+	urlID, err := gocql.ParseUUID(url_id)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Invalid url id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	err = cs.store.RemoveCampaignUrl(campaign.ID, urlID)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to remove url from campaign",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+// GetReport combines the click logs of every campaign link within the
+// campaign period, bot clicks are left out unless the filter includes them.
+func (cs *campaignService) GetReport(id, user_id string, filter dtos.AnalyticsFilterDTO) (*types.CampaignReport, *types.ApplicationError) {
+	campaign, appErr := cs.getAuthorizedCampaign(id, user_id, types.WorkspaceRoleViewer)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	urls, appErr := cs.campaignUrls(campaign)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	report := &types.CampaignReport{
+		Daily:     []*types.DailyClicks{},
+		TopLinks:  []*types.LinkInteractions{},
+		Countries: map[string]int{},
+		Channels:  map[string]int{},
+		Goals:     map[string]*types.GoalProgress{},
+	}
+	daily := map[string]int{}
+	var urlIDs []gocql.UUID
+	for _, url := range urls {
+		logs, err := cs.store.GetUrlLogsByUrlId(url.ID.String())
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get url logs",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+
+		linkClicks := 0
+		for _, log := range logs {
+			if !campaign.InPeriod(log.VisitedAt) || (!filter.IncludeBots && !types.IsHumanTraffic(log.TrafficClass)) {
+				continue
+			}
+			linkClicks++
+			daily[log.VisitedAt.UTC().Format("2006-01-02")]++
+			if log.Country != "" {
+				report.Countries[log.Country]++
+			}
+			report.Channels[campaignChannel(url, log)]++
+		}
+
+		report.Clicks += linkClicks
+		linkVisitors, err := countUniqueVisitors([]gocql.UUID{url.ID}, campaign.StartsAt, campaign.EndsAt)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get unique visitors",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		urlIDs = append(urlIDs, url.ID)
+		report.TopLinks = append(report.TopLinks, &types.LinkInteractions{ID: url.ID, ShortUrl: url.ShortUrl, Interactions: linkClicks, UniqueVisitors: linkVisitors})
+	}
+
+	var err error
+	report.UniqueVisitors, err = countUniqueVisitors(urlIDs, campaign.StartsAt, campaign.EndsAt)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get unique visitors",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	for date, clicks := range daily {
+		report.Daily = append(report.Daily, &types.DailyClicks{Date: date, Clicks: clicks})
+	}
+	sort.Slice(report.Daily, func(i, j int) bool {
+		return report.Daily[i].Date < report.Daily[j].Date
+	})
+	sort.SliceStable(report.TopLinks, func(i, j int) bool {
+		return report.TopLinks[i].Interactions > report.TopLinks[j].Interactions
+	})
+	if len(report.TopLinks) > maximumCampaignTopLinks {
+		report.TopLinks = report.TopLinks[:maximumCampaignTopLinks]
+	}
+
+	for goal, target := range campaign.Goals {
+		progress := &types.GoalProgress{Target: target}
+		switch goal {
+		case types.CampaignGoalClicks:
+			progress.Actual = report.Clicks
+		case types.CampaignGoalCountries:
+			progress.Actual = len(report.Countries)
+		}
+		report.Goals[goal] = progress
+	}
+
+	return report, nil
+}
+
+// campaignChannel prefers the utm_medium the link sends visitors with and
+// falls back to where the click came from.
+func campaignChannel(url *types.URL, log *types.UrlLog) string {
+	if medium := url.UTMParams["medium"]; medium != "" {
+		return strings.ToLower(medium)
+	}
+	return utils.ReferrerChannel(log.Referrer)
+}
+
+func (cs *campaignService) campaignUrls(campaign *types.Campaign) ([]*types.URL, *types.ApplicationError) {
+	urlIDs, err := cs.store.GetCampaignUrlIDs(campaign.ID)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get campaign urls",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	urls := []*types.URL{}
+	for _, urlID := range urlIDs {
+		url, err := cs.store.GetUrlByID(urlID.String())
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get campaign urls",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		if url != nil && url.DeletedAt.IsZero() {
+			urls = append(urls, url)
+		}
+	}
+
+	return urls, nil
+}
+
+// getAuthorizedCampaign returns personal campaigns to their owner and
+// workspace campaigns to members holding at least the required role.
+func (cs *campaignService) getAuthorizedCampaign(id, user_id, requiredRole string) (*types.Campaign, *types.ApplicationError) {
+	campaign, err := cs.store.GetCampaignByID(id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find the campaign",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if campaign == nil {
+		return nil, &types.ApplicationError{
+			Message:        "No campaign found with given id",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	if campaign.WorkspaceID == (gocql.UUID{}) {
+		if campaign.UserID.String() != user_id {
+			return nil, &types.ApplicationError{
+				Message:        "No campaign found with given id",
+				HttpStatusCode: http.StatusNotFound,
+			}
+		}
+		return campaign, nil
+	}
+
+	member, err := cs.store.GetWorkspaceMember(campaign.WorkspaceID.String(), user_id)
+	if err != nil || !member.CanAct(requiredRole) {
+		return nil, &types.ApplicationError{
+			Message:        "No campaign found with given id",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+
+	return campaign, nil
+}
+
+func applyCampaignDto(campaign *types.Campaign, campaignDto dtos.CampaignDTO) *types.ApplicationError {
+	name := strings.TrimSpace(campaignDto.Name)
+	if name == "" || len(name) > 200 {
+		return &types.ApplicationError{
+			Message:        "Campaign name must be 1 to 200 characters",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	if !campaignDto.StartsAt.IsZero() && !campaignDto.EndsAt.IsZero() && campaignDto.EndsAt.Before(campaignDto.StartsAt) {
+		return &types.ApplicationError{
+			Message:        "Campaign must end after it starts",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	for goal, target := range campaignDto.Goals {
+		if !types.IsValidCampaignGoal(goal) || target <= 0 {
+			return &types.ApplicationError{
+				Message:        "Goals must be clicks or countries with a positive target",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+	}
+
+	campaign.Name, campaign.BudgetNote, campaign.Goals = name, strings.TrimSpace(campaignDto.BudgetNote), campaignDto.Goals
+	campaign.StartsAt, campaign.EndsAt = campaignDto.StartsAt, campaignDto.EndsAt
+	return nil
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"urllite/store"
+	"urllite/types"
+	"urllite/types/dtos"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+)
+
+var (
+	hostnamePattern        = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+	appleAppIDPattern      = regexp.MustCompile(`^[A-Z0-9]{10}\.[A-Za-z0-9.-]+$`)
+	androidPackagePattern  = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*(\.[a-zA-Z][a-zA-Z0-9_]*)+$`)
+	certFingerprintPattern = regexp.MustCompile(`^([0-9A-F]{2}:){31}[0-9A-F]{2}$`)
+)
+
+type domainService struct {
+	store    store.Store
+	resolver utils.TXTResolver
+}
+
+type DomainService interface {
+	Create(domainDto dtos.DomainDTO, user_id string) (*types.Domain, *types.ApplicationError)
+	GetDomainsOfUser(user_id string) ([]*types.Domain, *types.ApplicationError)
+	Verify(id, user_id string, ctx context.Context) (*types.Domain, *types.ApplicationError)
+	Delete(id, user_id string) *types.ApplicationError
+	UpdateApps(id, user_id string, appsDto dtos.DomainAppsDTO) (*types.Domain, *types.ApplicationError)
+	ResolveHost(host string) (*types.Domain, *types.ApplicationError)
+}
+
+func NewDomainService() DomainService {
+	s := store.NewStore()
+	return &domainService{store: s, resolver: utils.NewTXTResolver()}
+}
+
+// NormalizeHost lowercases the host and strips the port and trailing dot
+// so it can be compared with stored hostnames.
+func NormalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+func (d *domainService) Create(domainDto dtos.DomainDTO, user_id string) (*types.Domain, *types.ApplicationError) {
+	hostname := NormalizeHost(domainDto.Hostname)
+	if !hostnamePattern.MatchString(hostname) || hostname == NormalizeHost(os.Getenv("SHORT_URL_HOST")) {
+		return nil, &types.ApplicationError{
+			Message:        "Not a valid domain",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	existingDomain, err := d.store.GetVerifiedDomainByHostname(hostname)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to check the domain",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if existingDomain != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Domain is already registered",
+			HttpStatusCode: http.StatusConflict,
+		}
+	}
+
+	userID, err := gocql.ParseUUID(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid user id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	domain := &types.Domain{Hostname: hostname, UserID: userID, Status: "pending"}
+	if domainDto.WorkspaceID != "" {
+		member, err := d.store.GetWorkspaceMember(domainDto.WorkspaceID, user_id)
+		if err != nil || !member.CanAct(types.WorkspaceRoleAdmin) {
+			return nil, &types.ApplicationError{
+				Message:        "Only workspace admins can add workspace domains",
+				HttpStatusCode: http.StatusForbidden,
+				Err:            err,
+			}
+		}
+		domain.WorkspaceID = member.WorkspaceID
+	}
+
+	domain.VerificationToken, err = utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to generate verification token",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	err = d.store.CreateDomain(domain)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to add domain",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return domain, nil
+}
+
+// GetDomainsOfUser lists personal domains and the domains of every workspace
+// the user belongs to.
+func (d *domainService) GetDomainsOfUser(user_id string) ([]*types.Domain, *types.ApplicationError) {
+	domains, err := d.store.GetDomainsOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get domains",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	var result []*types.Domain
+	for _, domain := range domains {
+		if domain.WorkspaceID == (gocql.UUID{}) {
+			result = append(result, domain)
+		}
+	}
+
+	memberships, err := d.store.GetWorkspaceMembershipsOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get domains",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	for _, membership := range memberships {
+		workspaceDomains, err := d.store.GetDomainsOfWorkspace(membership.WorkspaceID.String())
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get domains",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		result = append(result, workspaceDomains...)
+	}
+
+	return result, nil
+}
+
+func (d *domainService) Verify(id, user_id string, ctx context.Context) (*types.Domain, *types.ApplicationError) {
+	domain, appErr := d.getManagedDomain(id, user_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if domain.IsVerified() {
+		return domain, nil
+	}
+
+	existingDomain, err := d.store.GetVerifiedDomainByHostname(domain.Hostname)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to check the domain",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if existingDomain != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Domain is already registered",
+			HttpStatusCode: http.StatusConflict,
+		}
+	}
+
+	recordName, recordValue := domain.VerificationRecord()
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	records, err := d.resolver.LookupTXT(lookupCtx, recordName)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find the " + recordName + " TXT record",
+			HttpStatusCode: http.StatusUnprocessableEntity,
+			Err:            err,
+		}
+	}
+
+	for _, record := range records {
+		if strings.TrimSpace(record) == recordValue {
+			err = d.store.MarkDomainVerified(domain)
+			if err != nil {
+				return nil, &types.ApplicationError{
+					Message:        "Unable to verify domain",
+					HttpStatusCode: http.StatusInternalServerError,
+					Err:            err,
+				}
+			}
+			return domain, nil
+		}
+	}
+
+	return nil, &types.ApplicationError{
+		Message:        "The " + recordName + " TXT record does not contain " + recordValue,
+		HttpStatusCode: http.StatusUnprocessableEntity,
+	}
+}
+
+func (d *domainService) Delete(id, user_id string) *types.ApplicationError {
+	domain, appErr := d.getManagedDomain(id, user_id)
+	if appErr != nil {
+		return appErr
+	}
+
+	err := d.store.DeleteDomain(domain)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to delete domain",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+// UpdateApps sets the mobile apps allowed to open links of the domain, they
+// are published in apple-app-site-association and assetlinks.json.
+func (d *domainService) UpdateApps(id, user_id string, appsDto dtos.DomainAppsDTO) (*types.Domain, *types.ApplicationError) {
+	domain, appErr := d.getManagedDomain(id, user_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	var appleAppIDs []string
+	for _, appID := range appsDto.AppleAppIDs {
+		appID = strings.TrimSpace(appID)
+		if !appleAppIDPattern.MatchString(appID) {
+			return nil, &types.ApplicationError{
+				Message:        "Apple app ids look like TEAMID.com.example.app",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		appleAppIDs = append(appleAppIDs, appID)
+	}
+
+	packageName := strings.TrimSpace(appsDto.AndroidPackageName)
+	if packageName != "" && !androidPackagePattern.MatchString(packageName) {
+		return nil, &types.ApplicationError{
+			Message:        "Not a valid android package name",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	var fingerprints []string
+	for _, fingerprint := range appsDto.AndroidCertFingerprints {
+		fingerprint = strings.ToUpper(strings.TrimSpace(fingerprint))
+		if !certFingerprintPattern.MatchString(fingerprint) {
+			return nil, &types.ApplicationError{
+				Message:        "Certificate fingerprints must be SHA-256 in AA:BB:... form",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	if (packageName == "") != (len(fingerprints) == 0) {
+		return nil, &types.ApplicationError{
+			Message:        "Android apps need both the package name and a certificate fingerprint",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	domain.AppleAppIDs, domain.AndroidPackageName, domain.AndroidCertFingerprints = appleAppIDs, packageName, fingerprints
+	err := d.store.UpdateDomainApps(domain)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to update domain apps",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return domain, nil
+}
+
+// ResolveHost returns the verified custom domain serving the host, nil means
+// the default short link host.
+func (d *domainService) ResolveHost(host string) (*types.Domain, *types.ApplicationError) {
+	hostname := NormalizeHost(host)
+	if hostname == "" || hostname == NormalizeHost(os.Getenv("SHORT_URL_HOST")) {
+		return nil, nil
+	}
+
+	domain, err := d.store.GetVerifiedDomainByHostname(hostname)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find the domain",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return domain, nil
+}
+
+// getManagedDomain returns the domain if the user owns it or administers the
+// workspace owning it.
+func (d *domainService) getManagedDomain(id, user_id string) (*types.Domain, *types.ApplicationError) {
+	domain, err := d.store.GetDomainByID(id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find the domain",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if domain == nil {
+		return nil, &types.ApplicationError{
+			Message:        "No domain found with given id",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	if domain.WorkspaceID == (gocql.UUID{}) {
+		if domain.UserID.String() != user_id {
+			return nil, &types.ApplicationError{
+				Message:        "No domain found with given id",
+				HttpStatusCode: http.StatusNotFound,
+			}
+		}
+		return domain, nil
+	}
+
+	member, err := d.store.GetWorkspaceMember(domain.WorkspaceID.String(), user_id)
+	if err != nil || !member.CanAct(types.WorkspaceRoleAdmin) {
+		return nil, &types.ApplicationError{
+			Message:        "No domain found with given id",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+
+	return domain, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+// stubResolver answers TXT lookups from memory.
+type stubResolver struct {
+	records map[string][]string
+	err     error
+}
+
+func (r *stubResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.records[name], nil
+}
+
+func TestVerifyDomain(t *testing.T) {
+	owner := gocql.TimeUUID()
+	tests := []struct {
+		name        string
+		records     []string
+		resolverErr error
+		claimed     bool
+		wantStatus  int
+	}{
+		{name: "record present", records: []string{"urllite-verification=the-token"}},
+		{name: "record among others", records: []string{"v=spf1 -all", "  urllite-verification=the-token "}},
+		{name: "wrong token", records: []string{"urllite-verification=another-token"}, wantStatus: http.StatusUnprocessableEntity},
+		{name: "no record", wantStatus: http.StatusUnprocessableEntity},
+		{name: "lookup failure", resolverErr: errors.New("no such host"), wantStatus: http.StatusUnprocessableEntity},
+		{name: "verified by someone else", records: []string{"urllite-verification=the-token"}, claimed: true, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			domain := &types.Domain{ID: gocql.TimeUUID(), Hostname: "go.example.com", UserID: owner, VerificationToken: "the-token", Status: "pending"}
+			store.domains[domain.ID.String()] = domain
+			if tt.claimed {
+				other := &types.Domain{ID: gocql.TimeUUID(), Hostname: domain.Hostname, UserID: gocql.TimeUUID(), Status: "verified"}
+				store.domains[other.ID.String()] = other
+			}
+			recordName, _ := domain.VerificationRecord()
+			resolver := &stubResolver{records: map[string][]string{recordName: tt.records}, err: tt.resolverErr}
+
+			verified, appErr := (&domainService{store: store, resolver: resolver}).Verify(domain.ID.String(), owner.String(), context.Background())
+			if tt.wantStatus != 0 {
+				if appErr == nil || appErr.HttpStatusCode != tt.wantStatus {
+					t.Fatalf("Verify() = %v, want status %d", appErr, tt.wantStatus)
+				}
+				if domain.IsVerified() {
+					t.Error("domain was verified")
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatal(appErr)
+			}
+			if !verified.IsVerified() {
+				t.Errorf("status = %q", verified.Status)
+			}
+		})
+	}
+}
+
+func TestVerifyDomainOfAnotherUser(t *testing.T) {
+	store := newFakeStore()
+	domain := &types.Domain{ID: gocql.TimeUUID(), Hostname: "go.example.com", UserID: gocql.TimeUUID(), VerificationToken: "the-token"}
+	store.domains[domain.ID.String()] = domain
+
+	_, appErr := (&domainService{store: store, resolver: &stubResolver{}}).Verify(domain.ID.String(), gocql.TimeUUID().String(), context.Background())
+	if appErr == nil || appErr.HttpStatusCode != http.StatusNotFound {
+		t.Errorf("Verify() = %v, want status %d", appErr, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,437 @@
+package service
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+	"urllite/cache"
+	"urllite/store"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis keeps everything in memory, expirations are ignored.
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+	sets   map[string]map[string]bool
+	scores map[string]map[string]float64
+	lists  map[string][]string
+	// err is returned by Get and Exists, a redis that cannot be reached.
+	err error
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		values: map[string]string{},
+		sets:   map[string]map[string]bool{},
+		scores: map[string]map[string]float64{},
+		lists:  map[string][]string{},
+	}
+}
+
+// useFakeRedis swaps the shared redis client for the duration of the test.
+func useFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	sharedRedisClientOnce.Do(func() {})
+	previous := sharedRedisClient
+	fake := newFakeRedis()
+	sharedRedisClient = fake
+	t.Cleanup(func() { sharedRedisClient = previous })
+	return fake
+}
+
+func (r *fakeRedis) Set(key string, value string, expiration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[key] = value
+	return nil
+}
+
+func (r *fakeRedis) Get(key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return "", r.err
+	}
+	value, ok := r.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (r *fakeRedis) Exists(key string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return false, r.err
+	}
+	_, ok := r.values[key]
+	return ok, nil
+}
+
+func (r *fakeRedis) Delete(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.values, key)
+	delete(r.sets, key)
+	delete(r.scores, key)
+	delete(r.lists, key)
+	return nil
+}
+
+func (r *fakeRedis) DeleteMatching(pattern string) error {
+	r.mu.Lock()
+	keys := []string{}
+	for key := range r.values {
+		if matched, _ := path.Match(pattern, key); matched {
+			keys = append(keys, key)
+		}
+	}
+	r.mu.Unlock()
+	for _, key := range keys {
+		r.Delete(key)
+	}
+	return nil
+}
+
+func (r *fakeRedis) Increment(key string, expiration time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count, _ := strconv.ParseInt(r.values[key], 10, 64)
+	count++
+	r.values[key] = strconv.FormatInt(count, 10)
+	return count, nil
+}
+
+func (r *fakeRedis) SetIfAbsent(key string, value string, expiration time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.values[key]; ok {
+		return false, nil
+	}
+	r.values[key] = value
+	return true, nil
+}
+
+func (r *fakeRedis) AddUnique(key string, element string, expiration time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sets[key] == nil {
+		r.sets[key] = map[string]bool{}
+	}
+	r.sets[key][element] = true
+	return nil
+}
+
+func (r *fakeRedis) CountUnique(keys ...string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	union := map[string]bool{}
+	for _, key := range keys {
+		for element := range r.sets[key] {
+			union[element] = true
+		}
+	}
+	return int64(len(union)), nil
+}
+
+func (r *fakeRedis) Publish(channel string, message string) error {
+	return nil
+}
+
+func (r *fakeRedis) Subscribe(channels ...string) (cache.Subscription, error) {
+	return nil, nil
+}
+
+func (r *fakeRedis) IncrementScore(key string, member string, by float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.scores[key] == nil {
+		r.scores[key] = map[string]float64{}
+	}
+	r.scores[key][member] += by
+	return nil
+}
+
+func (r *fakeRedis) TopScores(key string, limit int64) ([]cache.ScoredMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	members := []cache.ScoredMember{}
+	for member, score := range r.scores[key] {
+		members = append(members, cache.ScoredMember{Member: member, Score: score})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Score > members[j].Score })
+	if int64(len(members)) > limit {
+		members = members[:limit]
+	}
+	return members, nil
+}
+
+func (r *fakeRedis) SetScore(key string, member string, score float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.scores[key] == nil {
+		r.scores[key] = map[string]float64{}
+	}
+	r.scores[key][member] = score
+	return nil
+}
+
+func (r *fakeRedis) RemoveMember(key string, member string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.scores[key], member)
+	return nil
+}
+
+func (r *fakeRedis) PushCapped(key string, value string, limit int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := append([]string{value}, r.lists[key]...)
+	if int64(len(list)) > limit {
+		list = list[:limit]
+	}
+	r.lists[key] = list
+	return nil
+}
+
+func (r *fakeRedis) Range(key string, limit int64) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := r.lists[key]
+	if int64(len(list)) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// fakeStore answers the store calls the tests need from memory, any other
+// call panics on the nil embedded store.
+type fakeStore struct {
+	store.Store
+
+	users      map[string]*types.User
+	passwords  map[string]*types.Password
+	apiKeys    map[string][]*types.ApiKey
+	twoFactors map[string]*types.TwoFactor
+	identities map[string]*types.OAuthIdentity
+	stats      map[string]int64
+
+	urls       map[string]*types.URL
+	members    map[string]*types.WorkspaceMember
+	workspaces map[string]*types.Workspace
+	domains    map[string]*types.Domain
+
+	roleChanges []*types.RoleChange
+	erased      []*types.User
+	touchErr    error
+	// shortUrlClashes is the number of short url lookups that find a link.
+	shortUrlClashes int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users:      map[string]*types.User{},
+		passwords:  map[string]*types.Password{},
+		apiKeys:    map[string][]*types.ApiKey{},
+		twoFactors: map[string]*types.TwoFactor{},
+		identities: map[string]*types.OAuthIdentity{},
+		stats:      map[string]int64{},
+		urls:       map[string]*types.URL{},
+		members:    map[string]*types.WorkspaceMember{},
+		workspaces: map[string]*types.Workspace{},
+		domains:    map[string]*types.Domain{},
+	}
+}
+
+func (s *fakeStore) CreateUser(user *types.User) error {
+	user.ID, user.CreatedAt, user.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+	user.Status, user.Role = types.UserStatusActive, "user"
+	s.users[user.ID.String()] = user
+	return nil
+}
+
+func (s *fakeStore) GetUserByID(id string) (*types.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *fakeStore) GetUserByEmail(email string) (*types.User, error) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, gocql.ErrNotFound
+}
+
+func (s *fakeStore) UpdateUser(user *types.User) error {
+	user.UpdatedAt = time.Now()
+	s.users[user.ID.String()] = user
+	return nil
+}
+
+func (s *fakeStore) GetPasswordByUserID(userID string) (*types.Password, error) {
+	password, ok := s.passwords[userID]
+	if !ok {
+		return nil, gocql.ErrNotFound
+	}
+	return password, nil
+}
+
+func (s *fakeStore) DeletePassword(password *types.Password) error {
+	delete(s.passwords, password.UserID.String())
+	return nil
+}
+
+func (s *fakeStore) GetApiKeysOfUser(userID string) ([]*types.ApiKey, error) {
+	return s.apiKeys[userID], nil
+}
+
+func (s *fakeStore) RevokeApiKey(apiKey *types.ApiKey) error {
+	apiKey.Status, apiKey.RevokedAt = "revoked", time.Now()
+	return nil
+}
+
+func (s *fakeStore) GetTwoFactorByUserID(userID string) (*types.TwoFactor, error) {
+	return s.twoFactors[userID], nil
+}
+
+func (s *fakeStore) DeleteTwoFactor(twoFactor *types.TwoFactor) error {
+	delete(s.twoFactors, twoFactor.UserID.String())
+	return nil
+}
+
+func (s *fakeStore) CreateOAuthIdentity(identity *types.OAuthIdentity) error {
+	s.identities[identity.Provider+"/"+identity.Subject] = identity
+	return nil
+}
+
+func (s *fakeStore) GetOAuthIdentity(provider, subject string) (*types.OAuthIdentity, error) {
+	return s.identities[provider+"/"+subject], nil
+}
+
+func (s *fakeStore) IncrementSystemStat(metric string, delta int64) error {
+	s.stats[metric] += delta
+	return nil
+}
+
+func (s *fakeStore) SaveTwoFactor(twoFactor *types.TwoFactor) error {
+	s.twoFactors[twoFactor.UserID.String()] = twoFactor
+	return nil
+}
+
+func (s *fakeStore) CreateRoleChange(roleChange *types.RoleChange) error {
+	s.roleChanges = append(s.roleChanges, roleChange)
+	return nil
+}
+
+func (s *fakeStore) CreateApiKey(apiKey *types.ApiKey) error {
+	apiKey.ID, apiKey.CreatedAt, apiKey.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+	s.apiKeys[apiKey.UserID.String()] = append(s.apiKeys[apiKey.UserID.String()], apiKey)
+	return nil
+}
+
+func (s *fakeStore) GetApiKeyByHash(hashedKey string) (*types.ApiKey, error) {
+	for _, apiKeys := range s.apiKeys {
+		for _, apiKey := range apiKeys {
+			if apiKey.HashedKey == hashedKey {
+				return apiKey, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeStore) TouchApiKey(apiKey *types.ApiKey) error {
+	apiKey.LastUsedAt = time.Now()
+	return s.touchErr
+}
+
+func (s *fakeStore) GetUrlByID(id string) (*types.URL, error) {
+	return s.urls[id], nil
+}
+
+func (s *fakeStore) UpdateUrlOwner(url *types.URL) error {
+	s.urls[url.ID.String()] = url
+	return nil
+}
+
+func (s *fakeStore) GetWorkspaceMember(workspaceID, userID string) (*types.WorkspaceMember, error) {
+	return s.members[workspaceID+"/"+userID], nil
+}
+
+func (s *fakeStore) addMember(workspaceID gocql.UUID, user *types.User, role string) {
+	s.members[workspaceID.String()+"/"+user.ID.String()] = &types.WorkspaceMember{WorkspaceID: workspaceID, UserID: user.ID, Role: role}
+}
+
+func (s *fakeStore) GetWorkspaceMembershipsOfUser(userID string) ([]*types.WorkspaceMember, error) {
+	var memberships []*types.WorkspaceMember
+	for _, member := range s.members {
+		if member.UserID.String() == userID {
+			memberships = append(memberships, member)
+		}
+	}
+	return memberships, nil
+}
+
+func (s *fakeStore) GetWorkspaceByID(id string) (*types.Workspace, error) {
+	return s.workspaces[id], nil
+}
+
+func (s *fakeStore) GetURLsOfUser(userID string) ([]*types.URL, error) {
+	var urls []*types.URL
+	for _, url := range s.urls {
+		if url.UserID.String() == userID {
+			urls = append(urls, url)
+		}
+	}
+	return urls, nil
+}
+
+func (s *fakeStore) EraseUser(user *types.User) error {
+	delete(s.users, user.ID.String())
+	s.erased = append(s.erased, user)
+	return nil
+}
+
+func (s *fakeStore) GetUrlByShortUrl(domain *types.Domain, shortUrl string) (*types.URL, error) {
+	if s.shortUrlClashes > 0 {
+		s.shortUrlClashes--
+		return &types.URL{ShortUrl: shortUrl}, nil
+	}
+	for _, url := range s.urls {
+		if domain.Serves(url) && url.ShortUrl == shortUrl {
+			return url, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeStore) GetDomainByID(id string) (*types.Domain, error) {
+	return s.domains[id], nil
+}
+
+func (s *fakeStore) GetVerifiedDomainByHostname(hostname string) (*types.Domain, error) {
+	for _, domain := range s.domains {
+		if domain.Hostname == hostname && domain.IsVerified() {
+			return domain, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeStore) MarkDomainVerified(domain *types.Domain) error {
+	domain.Status, domain.VerifiedAt = "verified", time.Now()
+	return nil
+}
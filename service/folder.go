@@ -0,0 +1,246 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"urllite/store"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gocql/gocql"
+)
+
+type folderService struct {
+	store store.Store
+}
+
+type FolderService interface {
+	Create(folderDto dtos.FolderDTO, user_id string) (*types.Folder, *types.ApplicationError)
+	GetFoldersOfUser(user_id string) ([]*types.Folder, *types.ApplicationError)
+	Rename(id, user_id string, folderDto dtos.FolderDTO) (*types.Folder, *types.ApplicationError)
+	Delete(id, user_id string) *types.ApplicationError
+	GetFolderAnalytics(id, user_id string, filter dtos.AnalyticsFilterDTO) (*types.LinkAnalytics, *types.ApplicationError)
+}
+
+func NewFolderService() FolderService {
+	s := store.NewStore()
+	return &folderService{store: s}
+}
+
+func (f *folderService) Create(folderDto dtos.FolderDTO, user_id string) (*types.Folder, *types.ApplicationError) {
+	name, appErr := validateFolderName(folderDto.Name)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	userID, err := gocql.ParseUUID(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid user id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	folder := &types.Folder{Name: name, UserID: userID}
+	if folderDto.WorkspaceID != "" {
+		member, err := f.store.GetWorkspaceMember(folderDto.WorkspaceID, user_id)
+		if err != nil || !member.CanAct(types.WorkspaceRoleEditor) {
+			return nil, &types.ApplicationError{
+				Message:        "Only workspace editors can add workspace folders",
+				HttpStatusCode: http.StatusForbidden,
+				Err:            err,
+			}
+		}
+		folder.WorkspaceID = member.WorkspaceID
+	}
+
+	err = f.store.CreateFolder(folder)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to create folder",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return folder, nil
+}
+
+// GetFoldersOfUser lists personal folders and the folders of every workspace
+// the user belongs to.
+func (f *folderService) GetFoldersOfUser(user_id string) ([]*types.Folder, *types.ApplicationError) {
+	folders, err := f.store.GetFoldersOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get folders",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	memberships, err := f.store.GetWorkspaceMembershipsOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get folders",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	for _, membership := range memberships {
+		workspaceFolders, err := f.store.GetFoldersOfWorkspace(membership.WorkspaceID.String())
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get folders",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		folders = append(folders, workspaceFolders...)
+	}
+
+	return folders, nil
+}
+
+func (f *folderService) Rename(id, user_id string, folderDto dtos.FolderDTO) (*types.Folder, *types.ApplicationError) {
+	folder, appErr := f.getAuthorizedFolder(id, user_id, types.WorkspaceRoleEditor)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	folder.Name, appErr = validateFolderName(folderDto.Name)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	err := f.store.RenameFolder(folder)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to rename folder",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return folder, nil
+}
+
+// Delete removes the folder and takes its links out of it, the links
+// themselves are kept.
+func (f *folderService) Delete(id, user_id string) *types.ApplicationError {
+	folder, appErr := f.getAuthorizedFolder(id, user_id, types.WorkspaceRoleEditor)
+	if appErr != nil {
+		return appErr
+	}
+
+	urls, appErr := f.folderUrls(folder)
+	if appErr != nil {
+		return appErr
+	}
+	for _, url := range urls {
+		url.FolderID = gocql.UUID{}
+		err := f.store.UpdateURL(url)
+		if err != nil {
+			return &types.ApplicationError{
+				Message:        "Unable to move links out of the folder",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+	}
+
+	err := f.store.DeleteFolder(folder)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to delete folder",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+func (f *folderService) GetFolderAnalytics(id, user_id string, filter dtos.AnalyticsFilterDTO) (*types.LinkAnalytics, *types.ApplicationError) {
+	folder, appErr := f.getAuthorizedFolder(id, user_id, types.WorkspaceRoleViewer)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	urls, appErr := f.folderUrls(folder)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return aggregateInteractions(f.store, urls, filter)
+}
+
+func (f *folderService) folderUrls(folder *types.Folder) ([]*types.URL, *types.ApplicationError) {
+	var urls []*types.URL
+	var err error
+	if folder.WorkspaceID == (gocql.UUID{}) {
+		urls, err = f.store.GetURLsOfUser(folder.UserID.String())
+	} else {
+		urls, err = f.store.GetURLsOfWorkspace(folder.WorkspaceID.String())
+	}
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get urls",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return filterUrls(urls, dtos.UrlFilterDTO{FolderID: folder.ID.String()}), nil
+}
+
+// getAuthorizedFolder returns personal folders to their owner and workspace
+// folders to members holding at least the required workspace role.
+func (f *folderService) getAuthorizedFolder(id, user_id, requiredRole string) (*types.Folder, *types.ApplicationError) {
+	folder, err := f.store.GetFolderByID(id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find the folder",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if folder == nil {
+		return nil, &types.ApplicationError{
+			Message:        "No folder found with given id",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	if folder.WorkspaceID == (gocql.UUID{}) {
+		if folder.UserID.String() != user_id {
+			return nil, &types.ApplicationError{
+				Message:        "No folder found with given id",
+				HttpStatusCode: http.StatusNotFound,
+			}
+		}
+		return folder, nil
+	}
+
+	member, err := f.store.GetWorkspaceMember(folder.WorkspaceID.String(), user_id)
+	if err != nil || !member.CanAct(requiredRole) {
+		return nil, &types.ApplicationError{
+			Message:        "No folder found with given id",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+
+	return folder, nil
+}
+
+func validateFolderName(name string) (string, *types.ApplicationError) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return "", &types.ApplicationError{
+			Message:        "Folder name must be 1 to 100 characters",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	return name, nil
+}
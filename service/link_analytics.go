@@ -0,0 +1,97 @@
+package service
+
+import (
+	"net/http"
+	"sort"
+	"time"
+	"urllite/store"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gocql/gocql"
+)
+
+// aggregateInteractions sums the clicks of a group of links, links are listed
+// with the most clicked first. Unique visitors of the group are estimated
+// over the union of the links, a visitor of several links counts once.
+func aggregateInteractions(s store.Store, urls []*types.URL, filter dtos.AnalyticsFilterDTO) (*types.LinkAnalytics, *types.ApplicationError) {
+	analytics := &types.LinkAnalytics{Links: len(urls), Urls: []*types.LinkInteractions{}}
+	var urlIDs []gocql.UUID
+	for _, url := range urls {
+		interactions, err := s.CountInteractions(url.ID.String(), filter.IncludeBots)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get url interactions count",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		uniqueVisitors, err := countUniqueVisitors([]gocql.UUID{url.ID}, time.Time{}, time.Time{})
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get unique visitors",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		analytics.Interactions += interactions
+		analytics.Urls = append(analytics.Urls, &types.LinkInteractions{ID: url.ID, ShortUrl: url.ShortUrl, Interactions: interactions, UniqueVisitors: uniqueVisitors})
+		urlIDs = append(urlIDs, url.ID)
+	}
+
+	var err error
+	analytics.UniqueVisitors, err = countUniqueVisitors(urlIDs, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get unique visitors",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	sort.SliceStable(analytics.Urls, func(i, j int) bool {
+		return analytics.Urls[i].Interactions > analytics.Urls[j].Interactions
+	})
+	return analytics, nil
+}
+
+// scopedUrls returns the personal links of the user, or the links of the
+// workspace when the user holds at least the required role in it.
+func scopedUrls(s store.Store, user_id, workspace_id, requiredRole string) ([]*types.URL, *types.ApplicationError) {
+	if workspace_id == "" {
+		urls, err := s.GetURLsOfUser(user_id)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get urls",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		return urls, nil
+	}
+
+	member, err := s.GetWorkspaceMember(workspace_id, user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find workspace membership",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if !member.CanAct(requiredRole) {
+		return nil, &types.ApplicationError{
+			Message:        "You are not allowed to do this in the workspace",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	urls, err := s.GetURLsOfWorkspace(workspace_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get urls",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	return urls, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"urllite/cache"
+	"urllite/store"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+const liveClickChannelPrefix = "url_clicks:"
+
+type liveClickService struct {
+	store store.Store
+}
+
+// LiveClickService fans clicks logged by the worker out to live streams
+// through redis pub/sub.
+type LiveClickService interface {
+	PublishClick(url *types.URL, log *types.UrlLog) error
+	SubscribeUrl(url *types.URL) (cache.Subscription, *types.ApplicationError)
+	SubscribeUser(user_id string) (cache.Subscription, *types.ApplicationError)
+}
+
+func NewLiveClickService() LiveClickService {
+	s := store.NewStore()
+	return &liveClickService{store: s}
+}
+
+func urlClicksChannel(urlID gocql.UUID) string {
+	return liveClickChannelPrefix + "url:" + urlID.String()
+}
+
+func userClicksChannel(userID gocql.UUID) string {
+	return liveClickChannelPrefix + "user:" + userID.String()
+}
+
+func workspaceClicksChannel(workspaceID gocql.UUID) string {
+	return liveClickChannelPrefix + "workspace:" + workspaceID.String()
+}
+
+// PublishClick sends the log to the stream of the link and the stream of
+// its owner, the workspace for workspace links.
+func (ls *liveClickService) PublishClick(url *types.URL, log *types.UrlLog) error {
+	message, err := json.Marshal(log)
+	if err != nil {
+		return err
+	}
+
+	ownerChannel := userClicksChannel(url.UserID)
+	if url.WorkspaceID != (gocql.UUID{}) {
+		ownerChannel = workspaceClicksChannel(url.WorkspaceID)
+	}
+	for _, channel := range []string{urlClicksChannel(url.ID), ownerChannel} {
+		if err := sharedRedis().Publish(channel, string(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SubscribeUrl streams the clicks of a link the caller already has access to.
+func (ls *liveClickService) SubscribeUrl(url *types.URL) (cache.Subscription, *types.ApplicationError) {
+	return ls.subscribe(urlClicksChannel(url.ID))
+}
+
+// SubscribeUser streams the clicks of the personal links of the user and of
+// the links of every workspace the user belongs to.
+func (ls *liveClickService) SubscribeUser(user_id string) (cache.Subscription, *types.ApplicationError) {
+	userID, err := gocql.ParseUUID(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid user id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	memberships, err := ls.store.GetWorkspaceMembershipsOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get workspaces",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	channels := []string{userClicksChannel(userID)}
+	for _, membership := range memberships {
+		channels = append(channels, workspaceClicksChannel(membership.WorkspaceID))
+	}
+	return ls.subscribe(channels...)
+}
+
+func (ls *liveClickService) subscribe(channels ...string) (cache.Subscription, *types.ApplicationError) {
+	subscription, err := sharedRedis().Subscribe(channels...)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to open the live stream",
+			HttpStatusCode: http.StatusServiceUnavailable,
+			Err:            err,
+		}
+	}
+	return subscription, nil
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"urllite/store"
+	"urllite/types"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	// Failed logins are counted within this window from the first one.
+	loginFailureWindow = 15 * time.Minute
+	// Every failure from this one on makes the next attempt wait, doubling
+	// up to loginMaxDelay.
+	loginDelayAfter = 3
+	loginMaxDelay   = time.Minute
+	// The account is locked for loginLockoutDuration at this many failures.
+	loginLockoutThreshold = 10
+	loginLockoutDuration  = 30 * time.Minute
+	// A device and location is known for this long after it was first seen.
+	knownLoginSourceTTL = 180 * 24 * time.Hour
+
+	lockedAccountsKey = "locked_accounts"
+)
+
+type loginProtectionService struct {
+	store store.Store
+}
+
+// LoginProtectionService throttles password logins per account, whatever ip
+// they come from, and tells users about logins from new devices.
+type LoginProtectionService interface {
+	CheckAllowed(user *types.User) *types.ApplicationError
+	RecordFailure(user *types.User)
+	RecordSuccess(user *types.User)
+	NotifyNewLoginSource(user *types.User, ip, userAgent string)
+	GetLockouts() ([]*types.LoginLockout, *types.ApplicationError)
+	GetLockout(user_id string) (*types.LoginLockout, *types.ApplicationError)
+	ClearLockout(user_id string) *types.ApplicationError
+}
+
+func NewLoginProtectionService() LoginProtectionService {
+	s := store.NewStore()
+	return &loginProtectionService{store: s}
+}
+
+func loginFailuresKey(user_id string) string {
+	return "login_failures_" + user_id
+}
+
+func loginRetryAfterKey(user_id string) string {
+	return "login_retry_after_" + user_id
+}
+
+func loginLockedKey(user_id string) string {
+	return "login_locked_" + user_id
+}
+
+func loginSourcesTrackedKey(user_id string) string {
+	return "login_sources_tracked_" + user_id
+}
+
+func loginSourceKey(user_id, source string) string {
+	return "login_source_" + user_id + "_" + source
+}
+
+// forgetLoginHistory drops the failed logins, the lockout and the known
+// login sources of an erased user.
+func forgetLoginHistory(user_id string) error {
+	for _, key := range []string{loginLockedKey(user_id), loginFailuresKey(user_id), loginRetryAfterKey(user_id), loginSourcesTrackedKey(user_id)} {
+		if err := sharedRedis().Delete(key); err != nil {
+			return err
+		}
+	}
+	if err := sharedRedis().RemoveMember(lockedAccountsKey, user_id); err != nil {
+		return err
+	}
+	return sharedRedis().DeleteMatching(loginSourceKey(user_id, "*"))
+}
+
+// loginDelay is the wait before the next attempt after the given number of
+// failures.
+func loginDelay(failures int64) time.Duration {
+	if failures < loginDelayAfter {
+		return 0
+	}
+	delay := time.Duration(math.Pow(2, float64(failures-loginDelayAfter))) * time.Second
+	if delay > loginMaxDelay {
+		return loginMaxDelay
+	}
+	return delay
+}
+
+// CheckAllowed is asked before the password is checked, so a locked account
+// stays locked for the right password too.
+func (l *loginProtectionService) CheckAllowed(user *types.User) *types.ApplicationError {
+	user_id := user.ID.String()
+	locked, err := sharedRedis().Exists(loginLockedKey(user_id))
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to check the login attempts",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if locked {
+		return &types.ApplicationError{
+			Message:        "Too many failed logins, the account is temporarily locked",
+			HttpStatusCode: http.StatusLocked,
+		}
+	}
+
+	waiting, err := sharedRedis().Exists(loginRetryAfterKey(user_id))
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to check the login attempts",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if waiting {
+		return &types.ApplicationError{
+			Message:        "Too many failed logins, wait a moment before trying again",
+			HttpStatusCode: http.StatusTooManyRequests,
+		}
+	}
+	return nil
+}
+
+// RecordFailure counts a wrong password, makes the next attempt wait and
+// locks the account once the threshold is reached.
+func (l *loginProtectionService) RecordFailure(user *types.User) {
+	user_id := user.ID.String()
+	failures, err := sharedRedis().Increment(loginFailuresKey(user_id), loginFailureWindow)
+	if err != nil {
+		log.Printf("Unable to count failed login of %s: %v", user_id, err)
+		return
+	}
+
+	if failures >= loginLockoutThreshold {
+		lockedUntil := time.Now().Add(loginLockoutDuration)
+		err = sharedRedis().Set(loginLockedKey(user_id), lockedUntil.Format(time.RFC3339), loginLockoutDuration)
+		if err != nil {
+			log.Printf("Unable to lock %s: %v", user_id, err)
+			return
+		}
+		sharedRedis().Delete(loginFailuresKey(user_id))
+		if err := sharedRedis().SetScore(lockedAccountsKey, user_id, float64(lockedUntil.Unix())); err != nil {
+			log.Printf("Unable to list lockout of %s: %v", user_id, err)
+		}
+		go func() {
+			if err := utils.NewMailer().SendAccountLocked(user, lockedUntil); err != nil {
+				log.Printf("Unable to send lockout notice to %s: %v", user_id, err)
+			}
+		}()
+		return
+	}
+
+	if delay := loginDelay(failures); delay > 0 {
+		if err := sharedRedis().Set(loginRetryAfterKey(user_id), "1", delay); err != nil {
+			log.Printf("Unable to delay logins of %s: %v", user_id, err)
+		}
+	}
+}
+
+// RecordSuccess forgets the failures once the password was right.
+func (l *loginProtectionService) RecordSuccess(user *types.User) {
+	user_id := user.ID.String()
+	sharedRedis().Delete(loginFailuresKey(user_id))
+	sharedRedis().Delete(loginRetryAfterKey(user_id))
+}
+
+// NotifyNewLoginSource mails the user when a login came from a device or
+// location not seen before. The first login tracked is only remembered,
+// there is nothing to compare it with.
+func (l *loginProtectionService) NotifyNewLoginSource(user *types.User, ip, userAgent string) {
+	user_id := user.ID.String()
+	go func() {
+		device, os := utils.ParseUserAgent(userAgent)
+		source := &types.LoginSource{IP: ip, Device: device, OS: os, At: time.Now()}
+		source.Country, source.City = locateIP(ip)
+
+		hash := sha256.Sum256([]byte(source.Device + "|" + source.OS + "|" + source.Country))
+		sourceKey := loginSourceKey(user_id, hex.EncodeToString(hash[:8]))
+		isNewSource, err := sharedRedis().SetIfAbsent(sourceKey, "1", knownLoginSourceTTL)
+		if err != nil {
+			log.Printf("Unable to check login source of %s: %v", user_id, err)
+			return
+		}
+		isFirstLogin, err := sharedRedis().SetIfAbsent(loginSourcesTrackedKey(user_id), "1", 0)
+		if err != nil || isFirstLogin || !isNewSource {
+			return
+		}
+		if err := utils.NewMailer().SendNewLoginAlert(user, source); err != nil {
+			log.Printf("Unable to send new login alert to %s: %v", user_id, err)
+		}
+	}()
+}
+
+func (l *loginProtectionService) lockout(user *types.User) (*types.LoginLockout, error) {
+	user_id := user.ID.String()
+	lockout := &types.LoginLockout{UserID: user.ID, Email: user.Email}
+
+	exists, err := sharedRedis().Exists(loginFailuresKey(user_id))
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		failures, err := sharedRedis().Get(loginFailuresKey(user_id))
+		if err != nil {
+			return nil, err
+		}
+		lockout.FailedAttempts, _ = strconv.ParseInt(failures, 10, 64)
+	}
+
+	lockout.Locked, err = sharedRedis().Exists(loginLockedKey(user_id))
+	if err != nil {
+		return nil, err
+	}
+	if lockout.Locked {
+		lockedUntil, err := sharedRedis().Get(loginLockedKey(user_id))
+		if err != nil {
+			return nil, err
+		}
+		lockout.LockedUntil, _ = time.Parse(time.RFC3339, lockedUntil)
+	}
+	return lockout, nil
+}
+
+// GetLockouts lists the accounts locked right now, the expired entries are
+// dropped from the list on the way.
+func (l *loginProtectionService) GetLockouts() ([]*types.LoginLockout, *types.ApplicationError) {
+	members, err := sharedRedis().TopScores(lockedAccountsKey, 1000)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get lockouts",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	lockouts := []*types.LoginLockout{}
+	now := time.Now().Unix()
+	for _, member := range members {
+		if int64(member.Score) <= now {
+			sharedRedis().RemoveMember(lockedAccountsKey, member.Member)
+			continue
+		}
+		lockout, appErr := l.GetLockout(member.Member)
+		if appErr != nil && appErr.HttpStatusCode == http.StatusNotFound {
+			sharedRedis().RemoveMember(lockedAccountsKey, member.Member)
+			continue
+		} else if appErr != nil {
+			return nil, appErr
+		}
+		if lockout.Locked {
+			lockouts = append(lockouts, lockout)
+		}
+	}
+	return lockouts, nil
+}
+
+func (l *loginProtectionService) GetLockout(user_id string) (*types.LoginLockout, *types.ApplicationError) {
+	user, err := l.store.GetUserByID(user_id)
+	if err == gocql.ErrNotFound || (err == nil && user == nil) {
+		return nil, &types.ApplicationError{
+			Message:        "User not found",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	} else if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	lockout, err := l.lockout(user)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get the lockout",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	return lockout, nil
+}
+
+// ClearLockout unlocks the account and forgets its failed logins.
+func (l *loginProtectionService) ClearLockout(user_id string) *types.ApplicationError {
+	if _, appErr := l.GetLockout(user_id); appErr != nil {
+		return appErr
+	}
+
+	for _, key := range []string{loginLockedKey(user_id), loginFailuresKey(user_id), loginRetryAfterKey(user_id)} {
+		if err := sharedRedis().Delete(key); err != nil {
+			return &types.ApplicationError{
+				Message:        "Unable to clear the lockout",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+	}
+	sharedRedis().RemoveMember(lockedAccountsKey, user_id)
+	return nil
+}
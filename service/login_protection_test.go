@@ -0,0 +1,122 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"urllite/types"
+)
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int64
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: loginDelayAfter - 1, want: 0},
+		{failures: loginDelayAfter, want: time.Second},
+		{failures: loginDelayAfter + 1, want: 2 * time.Second},
+		{failures: loginDelayAfter + 5, want: 32 * time.Second},
+		{failures: loginDelayAfter + 6, want: loginMaxDelay},
+		{failures: loginLockoutThreshold - 1, want: loginMaxDelay},
+	}
+
+	for _, tt := range tests {
+		if got := loginDelay(tt.failures); got != tt.want {
+			t.Errorf("loginDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginLockoutThresholds(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		wantStatus int
+	}{
+		{name: "no failure", failures: 0},
+		{name: "below the delay", failures: loginDelayAfter - 1},
+		{name: "delayed", failures: loginDelayAfter, wantStatus: http.StatusTooManyRequests},
+		{name: "one short of the lockout", failures: loginLockoutThreshold - 1, wantStatus: http.StatusTooManyRequests},
+		{name: "locked", failures: loginLockoutThreshold, wantStatus: http.StatusLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redis := useFakeRedis(t)
+			store := newFakeStore()
+			user := &types.User{Email: "owner@example.com"}
+			store.CreateUser(user)
+			service := &loginProtectionService{store: store}
+
+			for i := 0; i < tt.failures; i++ {
+				service.RecordFailure(user)
+			}
+
+			appErr := service.CheckAllowed(user)
+			if tt.wantStatus == 0 {
+				if appErr != nil {
+					t.Fatalf("CheckAllowed() = %v", appErr)
+				}
+				return
+			}
+			if appErr == nil || appErr.HttpStatusCode != tt.wantStatus {
+				t.Fatalf("CheckAllowed() = %v, want status %d", appErr, tt.wantStatus)
+			}
+
+			if tt.wantStatus == http.StatusLocked {
+				lockout, appErr := service.GetLockout(user.ID.String())
+				if appErr != nil {
+					t.Fatal(appErr)
+				}
+				if !lockout.Locked || lockout.FailedAttempts != 0 || lockout.LockedUntil.Before(time.Now()) {
+					t.Errorf("lockout = %+v", lockout)
+				}
+				if _, listed := redis.scores[lockedAccountsKey][user.ID.String()]; !listed {
+					t.Error("locked account missing from the lockout list")
+				}
+			}
+		})
+	}
+}
+
+func TestLoginProtectionReset(t *testing.T) {
+	tests := []struct {
+		name  string
+		reset func(service *loginProtectionService, user *types.User) *types.ApplicationError
+	}{
+		{name: "successful login", reset: func(service *loginProtectionService, user *types.User) *types.ApplicationError {
+			service.RecordSuccess(user)
+			return nil
+		}},
+		{name: "lockout cleared", reset: func(service *loginProtectionService, user *types.User) *types.ApplicationError {
+			return service.ClearLockout(user.ID.String())
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeRedis(t)
+			store := newFakeStore()
+			user := &types.User{Email: "owner@example.com"}
+			store.CreateUser(user)
+			service := &loginProtectionService{store: store}
+
+			for i := 0; i < loginDelayAfter; i++ {
+				service.RecordFailure(user)
+			}
+			if appErr := tt.reset(service, user); appErr != nil {
+				t.Fatal(appErr)
+			}
+			if appErr := service.CheckAllowed(user); appErr != nil {
+				t.Fatalf("CheckAllowed() = %v", appErr)
+			}
+
+			// The count starts over, a single failure must not delay again.
+			service.RecordFailure(user)
+			if appErr := service.CheckAllowed(user); appErr != nil {
+				t.Errorf("CheckAllowed() after one new failure = %v", appErr)
+			}
+		})
+	}
+}
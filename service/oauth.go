@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"urllite/oauth"
+	"urllite/store"
+	"urllite/types"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+type oauthState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+type oauthService struct {
+	store store.Store
+}
+
+type OAuthService interface {
+	BeginLogin(providerName string, ctx context.Context) (string, *types.ApplicationError)
+	CompleteLogin(providerName, state, code string, ctx context.Context) (*types.User, *types.ApplicationError)
+}
+
+func NewOAuthService() OAuthService {
+	s := store.NewStore()
+	return &oauthService{store: s}
+}
+
+func (o *oauthService) BeginLogin(providerName string, ctx context.Context) (string, *types.ApplicationError) {
+	provider, err := oauth.GetProvider(ctx, providerName)
+	if err != nil {
+		return "", &types.ApplicationError{
+			Message:        "Login provider not available",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+
+	state, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return "", &types.ApplicationError{
+			Message:        "Unable to start login",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	nonce, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return "", &types.ApplicationError{
+			Message:        "Unable to start login",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	codeVerifier, err := utils.GenerateRandomString(64)
+	if err != nil {
+		return "", &types.ApplicationError{
+			Message:        "Unable to start login",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	payload, _ := json.Marshal(oauthState{Provider: provider.Name(), Nonce: nonce, CodeVerifier: codeVerifier})
+	redisClient := sharedRedis()
+	err = redisClient.Set("oauth_state_"+state, string(payload), oauthStateTTL)
+	if err != nil {
+		return "", &types.ApplicationError{
+			Message:        "Unable to store login state",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return provider.AuthCodeURL(state, nonce, oauth.CodeChallenge(codeVerifier)), nil
+}
+
+func (o *oauthService) CompleteLogin(providerName, state, code string, ctx context.Context) (*types.User, *types.ApplicationError) {
+	if state == "" || code == "" {
+		return nil, &types.ApplicationError{
+			Message:        "Missing state or code",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	redisClient := sharedRedis()
+	redisStateKey := "oauth_state_" + state
+	payload, err := redisClient.Get(redisStateKey)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Login session expired or invalid",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	redisClient.Delete(redisStateKey)
+
+	var loginState oauthState
+	if err := json.Unmarshal([]byte(payload), &loginState); err != nil || loginState.Provider != strings.ToLower(providerName) {
+		return nil, &types.ApplicationError{
+			Message:        "Login session expired or invalid",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	provider, err := oauth.GetProvider(ctx, providerName)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Login provider not available",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+
+	identity, err := provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil || identity.Subject == "" {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to verify the login with " + provider.Name(),
+			HttpStatusCode: http.StatusUnauthorized,
+			Err:            err,
+		}
+	}
+
+	user, appErr := o.findOrCreateUser(identity)
+	if appErr != nil {
+		return nil, appErr
+	}
+	// Accounts without a password confirm their erasure with a fresh login.
+	sharedRedis().Set(recentOAuthLoginKey(user.ID.String()), "1", erasureReauthWindow)
+	return user, nil
+}
+
+// findOrCreateUser links the provider identity to the user owning the same
+// email. Only emails verified by the provider are trusted for linking.
+func (o *oauthService) findOrCreateUser(identity *oauth.Identity) (*types.User, *types.ApplicationError) {
+	linkedIdentity, err := o.store.GetOAuthIdentity(identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find linked account",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if linkedIdentity != nil {
+		user, err := o.store.GetUserByID(linkedIdentity.UserID.String())
+		if err != nil || user == nil {
+			return nil, &types.ApplicationError{
+				Message:        "Linked user not found",
+				HttpStatusCode: http.StatusNotFound,
+				Err:            err,
+			}
+		}
+		return user, nil
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, &types.ApplicationError{
+			Message:        "Email is not verified with " + identity.Provider,
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	user, err := o.store.GetUserByEmail(identity.Email)
+	if err != nil && err != gocql.ErrNotFound {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	if user == nil {
+		user = &types.User{Name: identity.Name, Email: identity.Email, VerifiedEmail: identity.Email}
+		if user.Name == "" {
+			user.Name = strings.Split(identity.Email, "@")[0]
+		}
+		err = o.store.CreateUser(user)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Error while creating user",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+	} else if !user.IsEmailVerified() {
+		// Anyone could have signed up with this address before its owner,
+		// whatever they set up on the account must not survive the owner
+		// proving the address.
+		if err := o.dropUnverifiedCredentials(user); err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to secure the existing account",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		user.VerifiedEmail = user.Email
+		err = o.store.UpdateUser(user)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable update user verified email",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		if err := o.store.IncrementSystemStat(types.StatVerifiedUsers, 1); err != nil {
+			log.Printf("Unable to count verified user %s: %v", user.ID, err)
+		}
+	}
+
+	err = o.store.CreateOAuthIdentity(&types.OAuthIdentity{UserID: user.ID, Provider: identity.Provider, Subject: identity.Subject, Email: identity.Email})
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to link account",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return user, nil
+}
+
+// dropUnverifiedCredentials removes the password, api keys and second factor
+// of an account whose email was never verified and ends its sessions, the
+// account is left to the verified owner of the email.
+func (o *oauthService) dropUnverifiedCredentials(user *types.User) error {
+	user_id := user.ID.String()
+	password, err := o.store.GetPasswordByUserID(user_id)
+	if err != nil && err != gocql.ErrNotFound {
+		return err
+	}
+	if password != nil {
+		if err := o.store.DeletePassword(password); err != nil {
+			return err
+		}
+	}
+
+	apiKeys, err := o.store.GetApiKeysOfUser(user_id)
+	if err != nil {
+		return err
+	}
+	for _, apiKey := range apiKeys {
+		if !apiKey.IsActive() {
+			continue
+		}
+		if err := o.store.RevokeApiKey(apiKey); err != nil {
+			return err
+		}
+	}
+
+	twoFactor, err := o.store.GetTwoFactorByUserID(user_id)
+	if err != nil {
+		return err
+	}
+	if twoFactor != nil {
+		if err := o.store.DeleteTwoFactor(twoFactor); err != nil {
+			return err
+		}
+	}
+
+	return endUserSessions(user_id)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+	"urllite/oauth"
+	"urllite/types"
+)
+
+func TestCompleteLoginRejectsInvalidState(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    string
+		payload  string
+		provider string
+	}{
+		{name: "unknown state", state: "unknown", provider: "google"},
+		{name: "state of another provider", state: "the-state", provider: "google", payload: `{"provider":"github","nonce":"n","code_verifier":"v"}`},
+		{name: "corrupt state", state: "the-state", provider: "google", payload: `not json`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redis := useFakeRedis(t)
+			if tt.payload != "" {
+				redis.Set("oauth_state_"+tt.state, tt.payload, oauthStateTTL)
+			}
+			service := &oauthService{store: newFakeStore()}
+
+			user, appErr := service.CompleteLogin(tt.provider, tt.state, "the-code", context.Background())
+			if appErr == nil || appErr.HttpStatusCode != http.StatusBadRequest {
+				t.Fatalf("CompleteLogin = %v, %v, want a bad request", user, appErr)
+			}
+			if exists, _ := redis.Exists("oauth_state_" + tt.state); exists {
+				t.Error("the login state must not be usable twice")
+			}
+		})
+	}
+}
+
+func TestBeginLoginStoresState(t *testing.T) {
+	redis := useFakeRedis(t)
+	t.Setenv("OAUTH_GITHUB_CLIENT_ID", "client")
+	t.Setenv("OAUTH_GITHUB_REDIRECT_URL", "https://urllite.test/callback")
+	service := &oauthService{store: newFakeStore()}
+
+	if _, appErr := service.BeginLogin("github", context.Background()); appErr != nil {
+		t.Fatal(appErr)
+	}
+	if len(redis.values) != 1 {
+		t.Fatalf("stored %d login states, want 1", len(redis.values))
+	}
+	for _, payload := range redis.values {
+		var state oauthState
+		if err := json.Unmarshal([]byte(payload), &state); err != nil {
+			t.Fatal(err)
+		}
+		if state.Provider != "github" || state.Nonce == "" || state.CodeVerifier == "" {
+			t.Errorf("state = %+v", state)
+		}
+	}
+}
+
+func TestFindOrCreateUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		existing      *types.User
+		emailVerified bool
+		wantStatus    int
+		wantNewUser   bool
+		wantCleanup   bool
+	}{
+		{name: "creates a new user", emailVerified: true, wantNewUser: true},
+		{name: "links a verified user", existing: &types.User{Email: "owner@example.com", VerifiedEmail: "owner@example.com"}, emailVerified: true},
+		{name: "takes over an unverified user", existing: &types.User{Email: "owner@example.com"}, emailVerified: true, wantCleanup: true},
+		{name: "refuses an email the provider did not verify", existing: &types.User{Email: "owner@example.com"}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeRedis(t)
+			store := newFakeStore()
+			if tt.existing != nil {
+				store.CreateUser(tt.existing)
+				user_id := tt.existing.ID.String()
+				store.passwords[user_id] = &types.Password{UserID: tt.existing.ID, HashedPassword: "hash"}
+				store.apiKeys[user_id] = []*types.ApiKey{{UserID: tt.existing.ID, Status: "active"}}
+				store.twoFactors[user_id] = &types.TwoFactor{UserID: tt.existing.ID, Status: "enabled"}
+			}
+			service := &oauthService{store: store}
+			identity := &oauth.Identity{Provider: "google", Subject: "subject-1", Email: "owner@example.com", EmailVerified: tt.emailVerified}
+
+			user, appErr := service.findOrCreateUser(identity)
+			if tt.wantStatus != 0 {
+				if appErr == nil || appErr.HttpStatusCode != tt.wantStatus {
+					t.Fatalf("findOrCreateUser = %v, %v, want status %d", user, appErr, tt.wantStatus)
+				}
+				if len(store.identities) != 0 {
+					t.Error("the identity must not be linked")
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatal(appErr)
+			}
+			if !user.IsEmailVerified() {
+				t.Error("the linked user must have a verified email")
+			}
+			if linked, _ := store.GetOAuthIdentity("google", "subject-1"); linked == nil || linked.UserID != user.ID {
+				t.Errorf("identity linked to %v, want %s", linked, user.ID)
+			}
+			if tt.wantNewUser && (tt.existing != nil || len(store.users) != 1) {
+				t.Errorf("%d users, want one new user", len(store.users))
+			}
+			if tt.existing != nil && user.ID != tt.existing.ID {
+				t.Errorf("linked to %s, want the existing user %s", user.ID, tt.existing.ID)
+			}
+
+			if tt.existing == nil {
+				return
+			}
+			user_id := tt.existing.ID.String()
+			_, hasPassword := store.passwords[user_id]
+			_, hasTwoFactor := store.twoFactors[user_id]
+			hasActiveKey := store.apiKeys[user_id][0].IsActive()
+			if tt.wantCleanup == (hasPassword || hasTwoFactor || hasActiveKey) {
+				t.Errorf("password %v, second factor %v, active api key %v, want them removed: %v", hasPassword, hasTwoFactor, hasActiveKey, tt.wantCleanup)
+			}
+			if revoked := IsUserSessionRevoked(user_id, time.Now().Add(-time.Minute)); revoked != tt.wantCleanup {
+				t.Errorf("earlier sessions revoked = %v, want %v", revoked, tt.wantCleanup)
+			}
+		})
+	}
+}
+
+func TestFindOrCreateUserReturnsLinkedUser(t *testing.T) {
+	useFakeRedis(t)
+	store := newFakeStore()
+	owner := &types.User{Email: "owner@example.com", VerifiedEmail: "owner@example.com"}
+	store.CreateUser(owner)
+	store.CreateOAuthIdentity(&types.OAuthIdentity{UserID: owner.ID, Provider: "google", Subject: "subject-1"})
+	service := &oauthService{store: store}
+
+	// The provider email may have changed since the identity was linked.
+	user, appErr := service.findOrCreateUser(&oauth.Identity{Provider: "google", Subject: "subject-1", Email: "new@example.com"})
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if user.ID != owner.ID {
+		t.Errorf("user = %s, want %s", user.ID, owner.ID)
+	}
+}
+
+func TestIsUserSessionRevoked(t *testing.T) {
+	useFakeRedis(t)
+	before := time.Now().Add(-time.Hour)
+	if IsUserSessionRevoked("user-1", before) {
+		t.Fatal("sessions are valid until they are ended")
+	}
+
+	if err := endUserSessions("user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if !IsUserSessionRevoked("user-1", before) {
+		t.Error("a token issued before the sessions were ended must be rejected")
+	}
+	if IsUserSessionRevoked("user-1", time.Now().Add(time.Second)) {
+		t.Error("a token issued after the sessions were ended must be accepted")
+	}
+	if IsUserSessionRevoked("user-2", before) {
+		t.Error("ending the sessions of one user must not affect another")
+	}
+
+	if err := revokeUserSessions("user-2", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if !IsUserSessionRevoked("user-2", time.Now().Add(time.Second)) {
+		t.Error("a suspended user must have every token rejected")
+	}
+}
+
+func TestIsUserSessionRevokedWithoutRedis(t *testing.T) {
+	redis := useFakeRedis(t)
+	redis.err = errors.New("connection refused")
+	if !IsUserSessionRevoked("user-1", time.Now()) {
+		t.Error("a token must be rejected when the revocation cannot be checked")
+	}
+}
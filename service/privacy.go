@@ -0,0 +1,187 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"urllite/store"
+	"urllite/types"
+	"urllite/types/dtos"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+)
+
+type privacyService struct {
+	store store.Store
+}
+
+type PrivacyService interface {
+	GetSettings(user_id string) (*types.PrivacySettings, *types.ApplicationError)
+	UpdateSettings(user_id string, settingsDto dtos.PrivacySettingsDTO) (*types.PrivacySettings, *types.ApplicationError)
+}
+
+func NewPrivacyService() PrivacyService {
+	s := store.NewStore()
+	return &privacyService{store: s}
+}
+
+// deploymentPrivacySettings reads the policy of the deployment, PRIVACY_IP_MODE
+// (full, truncate, hash or none), LOG_RETENTION_DAYS (unset keeps logs
+// forever) and PRIVACY_HONOR_DNT.
+func deploymentPrivacySettings() *types.PrivacySettings {
+	settings := &types.PrivacySettings{IPMode: types.IPModeFull}
+	if mode := os.Getenv("PRIVACY_IP_MODE"); types.IsValidIPMode(mode) {
+		settings.IPMode = mode
+	}
+	if days, err := strconv.Atoi(os.Getenv("LOG_RETENTION_DAYS")); err == nil && days > 0 {
+		settings.RetentionDays = days
+	}
+	settings.HonorDoNotTrack, _ = strconv.ParseBool(os.Getenv("PRIVACY_HONOR_DNT"))
+	return settings
+}
+
+// EffectivePrivacySettings combines the deployment policy with the settings
+// of the user, whichever is stricter wins.
+func EffectivePrivacySettings(s store.Store, user_id string) (*types.PrivacySettings, error) {
+	userSettings, err := s.GetPrivacySettingsByUserID(user_id)
+	if err != nil {
+		return nil, err
+	}
+	return deploymentPrivacySettings().Stricter(userSettings), nil
+}
+
+// AnonymizeUrlLog strips what the settings do not allow to be stored from a
+// click before it is logged.
+func AnonymizeUrlLog(urlLog *types.UrlLog, settings *types.PrivacySettings) {
+	if settings.HonorDoNotTrack && urlLog.DoNotTrack {
+		urlLog.ClientIP = ""
+		urlLog.UserAgent = ""
+		urlLog.City = ""
+		urlLog.Referrer = referrerOrigin(urlLog.Referrer)
+		return
+	}
+
+	switch settings.IPMode {
+	case types.IPModeTruncate:
+		urlLog.ClientIP = truncateIP(urlLog.ClientIP)
+	case types.IPModeHash:
+		urlLog.ClientIP = hashIP(urlLog.ClientIP)
+	case types.IPModeNone:
+		urlLog.ClientIP = ""
+	}
+}
+
+// truncateIP zeroes the host part of the address, keeping the /24 of IPv4
+// and the /48 of IPv6 addresses.
+func truncateIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+var (
+	ipHashSecret     []byte
+	ipHashSecretOnce sync.Once
+)
+
+// hashIP keys the hash with IP_HASH_SECRET so addresses cannot be recovered
+// by hashing the whole address space.
+func hashIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	ipHashSecretOnce.Do(func() {
+		ipHashSecret = []byte(os.Getenv("IP_HASH_SECRET"))
+		if len(ipHashSecret) == 0 {
+			log.Println("IP_HASH_SECRET is not set, hashed addresses will not match across restarts")
+			secret, _ := utils.GenerateRandomString(32)
+			ipHashSecret = []byte(secret)
+		}
+	})
+	mac := hmac.New(sha256.New, ipHashSecret)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func referrerOrigin(referrer string) string {
+	parsed, err := url.Parse(referrer)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+func (p *privacyService) GetSettings(user_id string) (*types.PrivacySettings, *types.ApplicationError) {
+	settings, err := p.store.GetPrivacySettingsByUserID(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to fetch privacy settings",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if settings == nil {
+		userID, err := gocql.ParseUUID(user_id)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Invalid user id",
+				HttpStatusCode: http.StatusBadRequest,
+				Err:            err,
+			}
+		}
+		settings = &types.PrivacySettings{UserID: userID}
+	}
+	return settings, nil
+}
+
+func (p *privacyService) UpdateSettings(user_id string, settingsDto dtos.PrivacySettingsDTO) (*types.PrivacySettings, *types.ApplicationError) {
+	settings, appErr := p.GetSettings(user_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if settingsDto.IPMode != nil {
+		if *settingsDto.IPMode != "" && !types.IsValidIPMode(*settingsDto.IPMode) {
+			return nil, &types.ApplicationError{
+				Message:        "IP mode must be one of full, truncate, hash or none",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		settings.IPMode = *settingsDto.IPMode
+	}
+	if settingsDto.RetentionDays != nil {
+		if *settingsDto.RetentionDays < 0 {
+			return nil, &types.ApplicationError{
+				Message:        "Retention days cannot be negative",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		settings.RetentionDays = *settingsDto.RetentionDays
+	}
+	if settingsDto.HonorDoNotTrack != nil {
+		settings.HonorDoNotTrack = *settingsDto.HonorDoNotTrack
+	}
+
+	err := p.store.SavePrivacySettings(settings)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to save privacy settings",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	return settings, nil
+}
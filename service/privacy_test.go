@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+	"urllite/types"
+)
+
+func TestTruncateIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{ip: "203.0.113.77", want: "203.0.113.0"},
+		{ip: "2001:db8:1234:5678::1", want: "2001:db8:1234::"},
+		{ip: "::ffff:203.0.113.77", want: "203.0.113.0"},
+		{ip: "not an ip", want: ""},
+		{ip: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := truncateIP(tt.ip); got != tt.want {
+			t.Errorf("truncateIP(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestAnonymizeUrlLog(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   types.PrivacySettings
+		doNotTrack bool
+		wantIP     string
+		wantAgent  string
+		wantCity   string
+		wantRef    string
+	}{
+		{name: "full", settings: types.PrivacySettings{IPMode: types.IPModeFull}, wantIP: "203.0.113.77", wantAgent: "Mozilla/5.0", wantCity: "Berlin", wantRef: "https://example.com/page?q=1"},
+		{name: "truncate", settings: types.PrivacySettings{IPMode: types.IPModeTruncate}, wantIP: "203.0.113.0", wantAgent: "Mozilla/5.0", wantCity: "Berlin", wantRef: "https://example.com/page?q=1"},
+		{name: "hash", settings: types.PrivacySettings{IPMode: types.IPModeHash}, wantIP: hashIP("203.0.113.77"), wantAgent: "Mozilla/5.0", wantCity: "Berlin", wantRef: "https://example.com/page?q=1"},
+		{name: "none", settings: types.PrivacySettings{IPMode: types.IPModeNone}, wantIP: "", wantAgent: "Mozilla/5.0", wantCity: "Berlin", wantRef: "https://example.com/page?q=1"},
+		{name: "do not track honoured", settings: types.PrivacySettings{IPMode: types.IPModeFull, HonorDoNotTrack: true}, doNotTrack: true, wantRef: "https://example.com"},
+		{name: "do not track ignored", settings: types.PrivacySettings{IPMode: types.IPModeFull}, doNotTrack: true, wantIP: "203.0.113.77", wantAgent: "Mozilla/5.0", wantCity: "Berlin", wantRef: "https://example.com/page?q=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urlLog := &types.UrlLog{ClientIP: "203.0.113.77", UserAgent: "Mozilla/5.0", City: "Berlin", Country: "DE", Referrer: "https://example.com/page?q=1", DoNotTrack: tt.doNotTrack}
+			AnonymizeUrlLog(urlLog, &tt.settings)
+			if urlLog.ClientIP != tt.wantIP || urlLog.UserAgent != tt.wantAgent || urlLog.City != tt.wantCity || urlLog.Referrer != tt.wantRef {
+				t.Errorf("log = ip %q, agent %q, city %q, referrer %q", urlLog.ClientIP, urlLog.UserAgent, urlLog.City, urlLog.Referrer)
+			}
+			if urlLog.Country != "DE" {
+				t.Errorf("country = %q, the country is always kept", urlLog.Country)
+			}
+		})
+	}
+}
+
+func TestPrivacySettingsStricter(t *testing.T) {
+	deployment := &types.PrivacySettings{IPMode: types.IPModeTruncate, RetentionDays: 90}
+	tests := []struct {
+		name          string
+		user          *types.PrivacySettings
+		wantMode      string
+		wantRetention int
+		wantDNT       bool
+	}{
+		{name: "no user settings", wantMode: types.IPModeTruncate, wantRetention: 90},
+		{name: "user cannot loosen", user: &types.PrivacySettings{IPMode: types.IPModeFull, RetentionDays: 365}, wantMode: types.IPModeTruncate, wantRetention: 90},
+		{name: "user tightens", user: &types.PrivacySettings{IPMode: types.IPModeNone, RetentionDays: 30, HonorDoNotTrack: true}, wantMode: types.IPModeNone, wantRetention: 30, wantDNT: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			combined := deployment.Stricter(tt.user)
+			if combined.IPMode != tt.wantMode || combined.RetentionDays != tt.wantRetention || combined.HonorDoNotTrack != tt.wantDNT {
+				t.Errorf("combined = %+v", combined)
+			}
+		})
+	}
+}
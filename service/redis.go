@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"urllite/cache"
+)
+
+var (
+	sharedRedisClient     cache.RedisClient
+	sharedRedisClientOnce sync.Once
+)
+
+// sharedRedis returns one client per process for the redis work done on
+// every click or request, the client pools its connections.
+func sharedRedis() cache.RedisClient {
+	sharedRedisClientOnce.Do(func() {
+		sharedRedisClient = cache.InitRedis(context.Background())
+	})
+	return sharedRedisClient
+}
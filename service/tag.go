@@ -0,0 +1,111 @@
+package service
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"urllite/store"
+	"urllite/types"
+	"urllite/types/dtos"
+)
+
+type tagService struct {
+	store store.Store
+}
+
+// TagService works on the tags of the personal links of a user, or of the
+// links of a workspace when a workspace id is given.
+type TagService interface {
+	GetTags(user_id, workspace_id string) ([]*types.TagUsage, *types.ApplicationError)
+	RenameTag(user_id, workspace_id, tag, newTag string) *types.ApplicationError
+	DeleteTag(user_id, workspace_id, tag string) *types.ApplicationError
+	GetTagAnalytics(user_id, workspace_id, tag string, filter dtos.AnalyticsFilterDTO) (*types.LinkAnalytics, *types.ApplicationError)
+}
+
+func NewTagService() TagService {
+	s := store.NewStore()
+	return &tagService{store: s}
+}
+
+func (t *tagService) GetTags(user_id, workspace_id string) ([]*types.TagUsage, *types.ApplicationError) {
+	urls, appErr := scopedUrls(t.store, user_id, workspace_id, types.WorkspaceRoleViewer)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	usage := map[string]int{}
+	for _, url := range urls {
+		for _, tag := range url.Tags {
+			usage[tag]++
+		}
+	}
+
+	tags := []*types.TagUsage{}
+	for name, links := range usage {
+		tags = append(tags, &types.TagUsage{Name: name, Links: links})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+func (t *tagService) RenameTag(user_id, workspace_id, tag, newTag string) *types.ApplicationError {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	newTag, appErr := normalizeTag(newTag)
+	if appErr != nil {
+		return appErr
+	}
+
+	return t.updateTaggedUrls(user_id, workspace_id, tag, func(url *types.URL) {
+		url.Tags = slices.DeleteFunc(url.Tags, func(urlTag string) bool { return urlTag == tag || urlTag == newTag })
+		url.Tags = append(url.Tags, newTag)
+	})
+}
+
+func (t *tagService) DeleteTag(user_id, workspace_id, tag string) *types.ApplicationError {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	return t.updateTaggedUrls(user_id, workspace_id, tag, func(url *types.URL) {
+		url.Tags = slices.DeleteFunc(url.Tags, func(urlTag string) bool { return urlTag == tag })
+	})
+}
+
+func (t *tagService) GetTagAnalytics(user_id, workspace_id, tag string, filter dtos.AnalyticsFilterDTO) (*types.LinkAnalytics, *types.ApplicationError) {
+	urls, appErr := scopedUrls(t.store, user_id, workspace_id, types.WorkspaceRoleViewer)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return aggregateInteractions(t.store, filterUrls(urls, dtos.UrlFilterDTO{Tag: tag}), filter)
+}
+
+// updateTaggedUrls applies the change to every link carrying the tag.
+func (t *tagService) updateTaggedUrls(user_id, workspace_id, tag string, change func(url *types.URL)) *types.ApplicationError {
+	urls, appErr := scopedUrls(t.store, user_id, workspace_id, types.WorkspaceRoleEditor)
+	if appErr != nil {
+		return appErr
+	}
+
+	taggedUrls := filterUrls(urls, dtos.UrlFilterDTO{Tag: tag})
+	if len(taggedUrls) == 0 {
+		return &types.ApplicationError{
+			Message:        "No links found with given tag",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	for _, url := range taggedUrls {
+		change(url)
+		err := t.store.UpdateURL(url)
+		if err != nil {
+			return &types.ApplicationError{
+				Message:        "Unable to update url tags",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"log"
+	"os"
+	"time"
+	"urllite/types"
+	"urllite/utils"
+)
+
+const (
+	defaultDatacenterRangesFile = "config/datacenter_ip_ranges.txt"
+	// More clicks than this from one address on one link within the window
+	// are treated as automated.
+	clickRateLimit  = 10
+	clickRateWindow = time.Minute
+)
+
+type trafficClassifier struct {
+	datacenterRanges *utils.IPRanges
+}
+
+// TrafficClassifier tells human clicks from bots, link previews and
+// suspicious clicks. It runs before a click is queued, while the address of
+// the visitor is still known.
+type TrafficClassifier interface {
+	Classify(log *types.UrlLog) string
+}
+
+// NewTrafficClassifier loads the datacenter ranges from the file named by
+// DATACENTER_IP_RANGES_FILE, clicks are classified without them when the
+// file is missing.
+func NewTrafficClassifier() TrafficClassifier {
+	path := os.Getenv("DATACENTER_IP_RANGES_FILE")
+	if path == "" {
+		path = defaultDatacenterRangesFile
+	}
+	ranges, err := utils.LoadIPRanges(path)
+	if err != nil {
+		log.Printf("Unable to load datacenter ip ranges from %s: %v", path, err)
+	}
+	return &trafficClassifier{datacenterRanges: ranges}
+}
+
+func (tc *trafficClassifier) Classify(urlLog *types.UrlLog) string {
+	trafficClass := utils.ClassifyUserAgent(urlLog.UserAgent)
+	if trafficClass != types.TrafficHuman {
+		return trafficClass
+	}
+	if tc.datacenterRanges.Contains(urlLog.ClientIP) {
+		return types.TrafficSuspicious
+	}
+	if urlLog.ClientIP != "" && tc.exceedsClickRate(urlLog) {
+		return types.TrafficSuspicious
+	}
+	return types.TrafficHuman
+}
+
+// exceedsClickRate counts the clicks of the address on the link in a fixed
+// window, a redis failure lets the click through as human.
+func (tc *trafficClassifier) exceedsClickRate(urlLog *types.UrlLog) bool {
+	count, err := sharedRedis().Increment("click_rate_"+urlLog.UrlID.String()+"_"+urlLog.ClientIP, clickRateWindow)
+	if err != nil {
+		log.Printf("Unable to count click rate: %v", err)
+		return false
+	}
+	return count > clickRateLimit
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"urllite/store"
+	"urllite/types"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	recoveryCodeCount     = 10
+	twoFactorChallengeTTL = 5 * time.Minute
+	// The challenge is dropped after this many wrong codes, the password has
+	// to be entered again.
+	twoFactorMaxAttempts = 5
+)
+
+type twoFactorService struct {
+	store                  store.Store
+	loginProtectionService LoginProtectionService
+}
+
+type TwoFactorService interface {
+	Enroll(user_id string) (string, string, *types.ApplicationError)
+	Confirm(user_id, code string) ([]string, *types.ApplicationError)
+	Disable(user_id, password string) *types.ApplicationError
+	IsEnabled(user_id string) (bool, *types.ApplicationError)
+	CreateChallenge(user *types.User, ctx context.Context) (string, *types.ApplicationError)
+	CompleteChallenge(challengeToken, code, recoveryCode string, ctx context.Context) (*types.User, *types.ApplicationError)
+}
+
+func NewTwoFactorService() TwoFactorService {
+	s := store.NewStore()
+	return &twoFactorService{store: s, loginProtectionService: NewLoginProtectionService()}
+}
+
+func (t *twoFactorService) Enroll(user_id string) (string, string, *types.ApplicationError) {
+	user, err := t.store.GetUserByID(user_id)
+	if err != nil || user == nil {
+		return "", "", &types.ApplicationError{
+			Message:        "User not found",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+
+	twoFactor, appErr := t.getTwoFactor(user_id)
+	if appErr != nil {
+		return "", "", appErr
+	}
+	if twoFactor.IsEnabled() {
+		return "", "", &types.ApplicationError{
+			Message:        "Two factor authentication is already enabled",
+			HttpStatusCode: http.StatusConflict,
+		}
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", &types.ApplicationError{
+			Message:        "Unable to generate secret",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	err = t.store.SaveTwoFactor(&types.TwoFactor{UserID: user.ID, Secret: secret, Status: "pending"})
+	if err != nil {
+		return "", "", &types.ApplicationError{
+			Message:        "Unable to save two factor settings",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "urllite"
+	}
+	return secret, utils.TOTPProvisioningURI(issuer, user.Email, secret), nil
+}
+
+func (t *twoFactorService) Confirm(user_id, code string) ([]string, *types.ApplicationError) {
+	twoFactor, appErr := t.getTwoFactor(user_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if twoFactor == nil {
+		return nil, &types.ApplicationError{
+			Message:        "Two factor enrollment not started",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	if twoFactor.IsEnabled() {
+		return nil, &types.ApplicationError{
+			Message:        "Two factor authentication is already enabled",
+			HttpStatusCode: http.StatusConflict,
+		}
+	}
+
+	step, ok := utils.VerifyTOTP(twoFactor.Secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid authentication code",
+			HttpStatusCode: http.StatusNotAcceptable,
+		}
+	}
+
+	recoveryCodes, hashedCodes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to generate recovery codes",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	twoFactor.Status, twoFactor.EnabledAt = "enabled", time.Now()
+	twoFactor.RecoveryCodes, twoFactor.LastUsedStep = hashedCodes, step
+	err = t.store.SaveTwoFactor(twoFactor)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to enable two factor authentication",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return recoveryCodes, nil
+}
+
+func (t *twoFactorService) Disable(user_id, password string) *types.ApplicationError {
+	twoFactor, appErr := t.getTwoFactor(user_id)
+	if appErr != nil {
+		return appErr
+	}
+	if twoFactor == nil {
+		return &types.ApplicationError{
+			Message:        "Two factor authentication is not enabled",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	storedPassword, err := t.store.GetPasswordByUserID(user_id)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Password not availble for the user",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(storedPassword.HashedPassword), []byte(password))
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Incorrect password.",
+			HttpStatusCode: http.StatusNotAcceptable,
+		}
+	}
+
+	err = t.store.DeleteTwoFactor(twoFactor)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to disable two factor authentication",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+func (t *twoFactorService) IsEnabled(user_id string) (bool, *types.ApplicationError) {
+	twoFactor, appErr := t.getTwoFactor(user_id)
+	if appErr != nil {
+		return false, appErr
+	}
+	return twoFactor.IsEnabled(), nil
+}
+
+// CreateChallenge hands out an opaque token that proves the password step
+// passed. It cannot be used as an access token.
+func (t *twoFactorService) CreateChallenge(user *types.User, ctx context.Context) (string, *types.ApplicationError) {
+	challengeToken, err := utils.GenerateRandomString(48)
+	if err != nil {
+		return "", &types.ApplicationError{
+			Message:        "Unable to create login challenge",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	redisClient := sharedRedis()
+	err = redisClient.Set("two_factor_challenge_"+utils.HashToken(challengeToken), user.ID.String(), twoFactorChallengeTTL)
+	if err != nil {
+		return "", &types.ApplicationError{
+			Message:        "Unable to create login challenge",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return challengeToken, nil
+}
+
+func (t *twoFactorService) CompleteChallenge(challengeToken, code, recoveryCode string, ctx context.Context) (*types.User, *types.ApplicationError) {
+	redisClient := sharedRedis()
+	redisChallengeKey := "two_factor_challenge_" + utils.HashToken(challengeToken)
+	userID, err := redisClient.Get(redisChallengeKey)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Login challenge expired or invalid",
+			HttpStatusCode: http.StatusUnauthorized,
+		}
+	}
+
+	user, err := t.store.GetUserByID(userID)
+	if err != nil || user == nil {
+		return nil, &types.ApplicationError{
+			Message:        "User not found",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+	if appErr := t.loginProtectionService.CheckAllowed(user); appErr != nil {
+		return nil, appErr
+	}
+
+	twoFactor, appErr := t.getTwoFactor(userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !twoFactor.IsEnabled() {
+		return nil, &types.ApplicationError{
+			Message:        "Two factor authentication is not enabled",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	if recoveryCode != "" {
+		if !useRecoveryCode(twoFactor, recoveryCode) {
+			return nil, t.rejectCode(user, redisChallengeKey, "Invalid recovery code")
+		}
+	} else {
+		step, ok := utils.VerifyTOTP(twoFactor.Secret, strings.TrimSpace(code), time.Now())
+		if !ok || step <= twoFactor.LastUsedStep {
+			return nil, t.rejectCode(user, redisChallengeKey, "Invalid authentication code")
+		}
+		twoFactor.LastUsedStep = step
+	}
+
+	err = t.store.SaveTwoFactor(twoFactor)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to update two factor settings",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	redisClient.Delete(redisChallengeKey)
+	redisClient.Delete(redisChallengeKey + "_attempts")
+	t.loginProtectionService.RecordSuccess(user)
+
+	return user, nil
+}
+
+// rejectCode counts the wrong code against the challenge and against the
+// account, like a wrong password.
+func (t *twoFactorService) rejectCode(user *types.User, redisChallengeKey, message string) *types.ApplicationError {
+	t.loginProtectionService.RecordFailure(user)
+
+	attempts, err := sharedRedis().Increment(redisChallengeKey+"_attempts", twoFactorChallengeTTL)
+	if err != nil || attempts >= twoFactorMaxAttempts {
+		sharedRedis().Delete(redisChallengeKey)
+		sharedRedis().Delete(redisChallengeKey + "_attempts")
+		return &types.ApplicationError{
+			Message:        "Too many invalid codes, log in again",
+			HttpStatusCode: http.StatusUnauthorized,
+		}
+	}
+	return &types.ApplicationError{
+		Message:        message,
+		HttpStatusCode: http.StatusNotAcceptable,
+	}
+}
+
+func (t *twoFactorService) getTwoFactor(user_id string) (*types.TwoFactor, *types.ApplicationError) {
+	twoFactor, err := t.store.GetTwoFactorByUserID(user_id)
+	if err != nil && err != gocql.ErrNotFound {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find two factor settings",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	return twoFactor, nil
+}
+
+// generateRecoveryCodes returns the plain codes for the user and their hashes
+// for storage.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashedCodes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRandomString(10)
+		if err != nil {
+			return nil, nil, err
+		}
+		code = strings.ToLower(code[:5] + "-" + code[5:])
+		codes = append(codes, code)
+		hashedCodes = append(hashedCodes, utils.HashToken(code))
+	}
+	return codes, hashedCodes, nil
+}
+
+// useRecoveryCode removes the matching code, every recovery code works once.
+func useRecoveryCode(twoFactor *types.TwoFactor, recoveryCode string) bool {
+	hashedCode := utils.HashToken(strings.ToLower(strings.TrimSpace(recoveryCode)))
+	for i, storedCode := range twoFactor.RecoveryCodes {
+		if storedCode == hashedCode {
+			twoFactor.RecoveryCodes = append(twoFactor.RecoveryCodes[:i], twoFactor.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"urllite/types"
+	"urllite/utils"
+)
+
+// currentTOTP computes the code an authenticator app shows right now.
+func currentTOTP(t *testing.T, secret string) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+// newTwoFactorFixture enables two factor authentication for a user and opens
+// a login challenge for them.
+func newTwoFactorFixture(t *testing.T) (*twoFactorService, *fakeStore, *fakeRedis, *types.User, string) {
+	t.Helper()
+	redis := useFakeRedis(t)
+	store := newFakeStore()
+	user := &types.User{Email: "owner@example.com", VerifiedEmail: "owner@example.com"}
+	store.CreateUser(user)
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, hashedCodes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.twoFactors[user.ID.String()] = &types.TwoFactor{UserID: user.ID, Secret: secret, Status: "enabled", RecoveryCodes: hashedCodes}
+
+	service := &twoFactorService{store: store, loginProtectionService: &loginProtectionService{store: store}}
+	challengeToken, appErr := service.CreateChallenge(user, context.Background())
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	return service, store, redis, user, challengeToken
+}
+
+func TestCompleteChallenge(t *testing.T) {
+	service, store, _, user, challengeToken := newTwoFactorFixture(t)
+	twoFactor := store.twoFactors[user.ID.String()]
+	code := currentTOTP(t, twoFactor.Secret)
+
+	loggedIn, appErr := service.CompleteChallenge(challengeToken, code, "", context.Background())
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if loggedIn.ID != user.ID {
+		t.Errorf("logged in as %s, want %s", loggedIn.ID, user.ID)
+	}
+	if _, appErr := service.CompleteChallenge(challengeToken, code, "", context.Background()); appErr == nil || appErr.HttpStatusCode != http.StatusUnauthorized {
+		t.Errorf("reusing the challenge = %v, want unauthorized", appErr)
+	}
+
+	// A new challenge with the same code is a replay of the step already used.
+	challengeToken, _ = service.CreateChallenge(user, context.Background())
+	if _, appErr := service.CompleteChallenge(challengeToken, code, "", context.Background()); appErr == nil || appErr.HttpStatusCode != http.StatusNotAcceptable {
+		t.Errorf("replaying the code = %v, want not acceptable", appErr)
+	}
+}
+
+func TestCompleteChallengeWithRecoveryCode(t *testing.T) {
+	service, store, _, user, challengeToken := newTwoFactorFixture(t)
+	codes, hashedCodes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.twoFactors[user.ID.String()].RecoveryCodes = hashedCodes
+
+	tests := []struct {
+		name         string
+		recoveryCode string
+		wantStatus   int
+	}{
+		{name: "valid code", recoveryCode: codes[0]},
+		{name: "code already used", recoveryCode: codes[0], wantStatus: http.StatusNotAcceptable},
+		{name: "code in capitals with spaces", recoveryCode: " " + strings.ToUpper(codes[1]) + " "},
+		{name: "unknown code", recoveryCode: "aaaaa-bbbbb", wantStatus: http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, appErr := service.CompleteChallenge(challengeToken, "", tt.recoveryCode, context.Background())
+			if tt.wantStatus == 0 && appErr != nil {
+				t.Fatal(appErr)
+			}
+			if tt.wantStatus != 0 && (appErr == nil || appErr.HttpStatusCode != tt.wantStatus) {
+				t.Fatalf("CompleteChallenge = %v, want status %d", appErr, tt.wantStatus)
+			}
+			if appErr == nil {
+				challengeToken, _ = service.CreateChallenge(user, context.Background())
+			}
+			sharedRedis().Delete(loginRetryAfterKey(user.ID.String()))
+		})
+	}
+	if remaining := len(store.twoFactors[user.ID.String()].RecoveryCodes); remaining != recoveryCodeCount-2 {
+		t.Errorf("%d recovery codes left, want %d", remaining, recoveryCodeCount-2)
+	}
+}
+
+func TestCompleteChallengeLimitsAttempts(t *testing.T) {
+	service, store, redis, user, challengeToken := newTwoFactorFixture(t)
+	user_id := user.ID.String()
+
+	for attempt := 1; attempt <= twoFactorMaxAttempts; attempt++ {
+		// Wait out the delay the account gets after a few failures.
+		redis.Delete(loginRetryAfterKey(user_id))
+		_, appErr := service.CompleteChallenge(challengeToken, "000000", "", context.Background())
+		wantStatus := http.StatusNotAcceptable
+		if attempt == twoFactorMaxAttempts {
+			wantStatus = http.StatusUnauthorized
+		}
+		if appErr == nil || appErr.HttpStatusCode != wantStatus {
+			t.Fatalf("attempt %d = %v, want status %d", attempt, appErr, wantStatus)
+		}
+	}
+
+	if failures, _ := redis.Get(loginFailuresKey(user_id)); failures != fmt.Sprint(twoFactorMaxAttempts) {
+		t.Errorf("account failures = %q, want %d", failures, twoFactorMaxAttempts)
+	}
+	redis.Delete(loginRetryAfterKey(user_id))
+	code := currentTOTP(t, store.twoFactors[user_id].Secret)
+	if _, appErr := service.CompleteChallenge(challengeToken, code, "", context.Background()); appErr == nil || appErr.HttpStatusCode != http.StatusUnauthorized {
+		t.Errorf("the dropped challenge = %v, want unauthorized", appErr)
+	}
+}
+
+func TestCompleteChallengeHonoursAccountDelay(t *testing.T) {
+	service, _, _, _, challengeToken := newTwoFactorFixture(t)
+	for attempt := 1; attempt <= loginDelayAfter; attempt++ {
+		service.CompleteChallenge(challengeToken, "000000", "", context.Background())
+	}
+	if _, appErr := service.CompleteChallenge(challengeToken, "000000", "", context.Background()); appErr == nil || appErr.HttpStatusCode != http.StatusTooManyRequests {
+		t.Errorf("CompleteChallenge = %v, want too many requests", appErr)
+	}
+}
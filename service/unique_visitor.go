@@ -0,0 +1,127 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+	"urllite/types"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	uniqueVisitorDateFormat = "2006-01-02"
+	// Daily estimates are kept for this many days, the visitor days for the
+	// life of the link.
+	uniqueVisitorRetentionDays = 90
+	uniqueVisitorTrendDays     = 30
+)
+
+func dailyVisitorsKey(urlID gocql.UUID, date string) string {
+	return "unique_visitors_" + urlID.String() + "_" + date
+}
+
+// visitorDaysKey holds the visitors of every day of the link. The hashes
+// change with the daily salt, so it adds up the daily visitors rather than
+// telling distinct people apart.
+func visitorDaysKey(urlID gocql.UUID) string {
+	return "unique_visitors_" + urlID.String()
+}
+
+// visitorSalt returns the salt of the day. It expires the day after, from
+// then on the hashes of that day cannot be linked to an address anymore.
+func visitorSalt(date string) (string, error) {
+	key := "unique_visitor_salt_" + date
+	salt, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	_, err = sharedRedis().SetIfAbsent(key, salt, 48*time.Hour)
+	if err != nil {
+		return "", err
+	}
+	return sharedRedis().Get(key)
+}
+
+// RecordUniqueVisitor adds the visitor of a human click to the estimates of
+// the link. The address and user agent are only kept as a salted hash.
+func RecordUniqueVisitor(urlLog *types.UrlLog) error {
+	if !types.IsHumanTraffic(urlLog.TrafficClass) {
+		return nil
+	}
+
+	date := urlLog.VisitedAt.UTC().Format(uniqueVisitorDateFormat)
+	salt, err := visitorSalt(date)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(salt + "|" + urlLog.ClientIP + "|" + urlLog.UserAgent))
+	visitor := hex.EncodeToString(hash[:16])
+
+	err = sharedRedis().AddUnique(dailyVisitorsKey(urlLog.UrlID, date), visitor, (uniqueVisitorRetentionDays+1)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	return sharedRedis().AddUnique(visitorDaysKey(urlLog.UrlID), visitor, 0)
+}
+
+// estimateUniqueVisitors returns today, the visitor days and the daily
+// estimates of the last 30 days, oldest first.
+func estimateUniqueVisitors(urlID gocql.UUID) (*types.UniqueVisitors, error) {
+	visitorDays, err := sharedRedis().CountUnique(visitorDaysKey(urlID))
+	if err != nil {
+		return nil, err
+	}
+
+	visitors := &types.UniqueVisitors{VisitorDays: visitorDays, Daily: []*types.DailyVisitors{}}
+	today := time.Now().UTC()
+	for day := uniqueVisitorTrendDays - 1; day >= 0; day-- {
+		date := today.AddDate(0, 0, -day).Format(uniqueVisitorDateFormat)
+		count, err := sharedRedis().CountUnique(dailyVisitorsKey(urlID, date))
+		if err != nil {
+			return nil, err
+		}
+		visitors.Daily = append(visitors.Daily, &types.DailyVisitors{Date: date, Visitors: count})
+	}
+	visitors.Today = visitors.Daily[len(visitors.Daily)-1].Visitors
+
+	return visitors, nil
+}
+
+// countUniqueVisitors estimates the distinct visitors across links. With a
+// period only the days inside it, within the daily retention, are counted.
+// A visitor is distinct within a day only, over several days this counts
+// visitor days like UniqueVisitors.VisitorDays.
+func countUniqueVisitors(urlIDs []gocql.UUID, from, to time.Time) (int64, error) {
+	if len(urlIDs) == 0 {
+		return 0, nil
+	}
+	if from.IsZero() && to.IsZero() {
+		var keys []string
+		for _, urlID := range urlIDs {
+			keys = append(keys, visitorDaysKey(urlID))
+		}
+		return sharedRedis().CountUnique(keys...)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	oldest := today.AddDate(0, 0, -uniqueVisitorRetentionDays)
+	if from.IsZero() || from.Before(oldest) {
+		from = oldest
+	}
+	if to.IsZero() || to.After(today.Add(24*time.Hour)) {
+		to = today.Add(24 * time.Hour)
+	}
+
+	var keys []string
+	for day := from.UTC().Truncate(24 * time.Hour); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, urlID := range urlIDs {
+			keys = append(keys, dailyVisitorsKey(urlID, day.Format(uniqueVisitorDateFormat)))
+		}
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return sharedRedis().CountUnique(keys...)
+}
@@ -2,26 +2,70 @@ package service
 
 import (
 	"net/http"
+	neturl "net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
 	"urllite/store"
 	"urllite/types"
+	"urllite/types/dtos"
 	"urllite/utils"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocql/gocql"
 )
 
+const (
+	maximumTagsPerUrl  = 20
+	maximumNotesLength = 10000
+	// A clash of generated short urls is rare, a few more tries settle it.
+	shortUrlGenerationAttempts = 5
+)
+
+var (
+	customSlugPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,64}$`)
+	tagPattern        = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+	// Paths of our own routes, a link there would shadow the route or never
+	// be reached. Compared in lower case.
+	reservedSlugs = map[string]bool{
+		"api":                        true,
+		"admin":                      true,
+		"signup":                     true,
+		"signup-and-login":           true,
+		"login":                      true,
+		"logout":                     true,
+		"oauth":                      true,
+		"send-forget-password-otp":   true,
+		"verify-forget-password-otp": true,
+		"change-password-via-otp":    true,
+		"change-password":            true,
+		"verify-email-otp":           true,
+		"verify-email":               true,
+		"apple-app-site-association": true,
+		"well-known":                 true,
+		"exports":                    true,
+		"report":                     true,
+	}
+)
+
 type urlService struct {
 	store store.Store
 }
 
 type UrlService interface {
-	CreateUrl(longUrl, user_id string) (*types.URL, *types.ApplicationError)
+	CreateUrl(urlDto dtos.UrlDTO, user_id string) (*types.URL, *types.ApplicationError)
 	GetUrlByID(id, user_id string) (*types.URL, *types.ApplicationError)
-	GetUrlByShortUrl(short_url string) (*types.URL, *types.ApplicationError)
+	GetEditableUrlByID(id, user_id string) (*types.URL, *types.ApplicationError)
+	GetUrlByShortUrl(host, short_url string) (*types.URL, *types.ApplicationError)
 	DeleteUrlById(id, user_id string) *types.ApplicationError
-	GetUrlsOfUser(user_id string) ([]*types.URL, *types.ApplicationError)
+	GetUrlsOfUser(user_id string, filter dtos.UrlFilterDTO) ([]*types.URL, *types.ApplicationError)
+	GetUrlsOfWorkspace(workspace_id, user_id string, filter dtos.UrlFilterDTO) ([]*types.URL, *types.ApplicationError)
+	TransferUrl(id, user_id string, transferDto dtos.UrlTransferDTO) (*types.URL, *types.ApplicationError)
+	UpdateUrl(id, user_id string, updateDto dtos.UrlUpdateDTO) (*types.URL, *types.ApplicationError)
+	ResolveDestination(url *types.URL, visit *types.Visit) *types.RedirectTarget
 	GetUrlLogsByUrl(url *types.URL) ([]*types.UrlLog, *types.ApplicationError)
-	GetUrlDatas(url *types.URL) (map[string]interface{}, *types.ApplicationError)
+	GetUrlDatas(url *types.URL, filter dtos.AnalyticsFilterDTO) (map[string]interface{}, *types.ApplicationError)
 }
 
 func NewUrlService() UrlService {
@@ -29,9 +73,9 @@ func NewUrlService() UrlService {
 	return &urlService{store: s}
 }
 
-func (u *urlService) CreateUrl(longUrl, user_id string) (*types.URL, *types.ApplicationError) {
+func (u *urlService) CreateUrl(urlDto dtos.UrlDTO, user_id string) (*types.URL, *types.ApplicationError) {
 	var url types.URL
-	normalisedUrl, ok := utils.NormalizeAndValidateURL(longUrl)
+	normalisedUrl, ok := utils.NormalizeAndValidateURL(urlDto.LongUrl)
 	if !ok {
 		return nil, &types.ApplicationError{
 			Message:        "Not a valid url",
@@ -40,23 +84,114 @@ func (u *urlService) CreateUrl(longUrl, user_id string) (*types.URL, *types.Appl
 	}
 
 	parsedUserID, err := gocql.ParseUUID(user_id)
-	if !ok {
+	if err != nil {
 		return nil, &types.ApplicationError{
 			Message:        "Unable to find logged user data",
 			HttpStatusCode: http.StatusInternalServerError,
 			Err:            err,
 		}
 	}
+
+	if urlDto.WorkspaceID != "" {
+		member, appErr := u.workspaceMember(urlDto.WorkspaceID, user_id)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if !member.CanAct(types.WorkspaceRoleEditor) {
+			return nil, &types.ApplicationError{
+				Message:        "You are not allowed to create links in this workspace",
+				HttpStatusCode: http.StatusForbidden,
+			}
+		}
+		url.WorkspaceID = member.WorkspaceID
+	}
+	var urlDomain *types.Domain
+	if urlDto.Domain != "" {
+		domain, appErr := u.usableDomain(urlDto.Domain, user_id)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.Domain, url.DomainID = domain.Hostname, domain.ID
+		urlDomain = domain
+	}
+	routingRules, appErr := validateRoutingRules(urlDto.RoutingRules)
+	if appErr != nil {
+		return nil, appErr
+	}
+	url.RoutingRules = routingRules
+	variants, appErr := validateVariants(urlDto.Variants)
+	if appErr != nil {
+		return nil, appErr
+	}
+	url.Variants = variants
+	if urlDto.DeepLink != nil {
+		deepLink, appErr := validateDeepLink(*urlDto.DeepLink)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.DeepLink = deepLink
+	}
+	url.UTMParams, appErr = validateUTMParams(urlDto.UTMParams)
+	if appErr != nil {
+		return nil, appErr
+	}
+	url.QueryMerge, appErr = validateQueryMerge(urlDto.QueryMerge)
+	if appErr != nil {
+		return nil, appErr
+	}
+	url.ForwardQuery = urlDto.ForwardQuery
+	url.RedirectType, appErr = validateRedirectType(urlDto.RedirectType)
+	if appErr != nil {
+		return nil, appErr
+	}
+	url.InterstitialDelay = types.DefaultInterstitialDelay
+	if urlDto.InterstitialDelay != nil {
+		url.InterstitialDelay, appErr = validateInterstitialDelay(*urlDto.InterstitialDelay)
+		if appErr != nil {
+			return nil, appErr
+		}
+	}
+	url.LeavingWarning, url.NoIndex = urlDto.LeavingWarning, urlDto.NoIndex
+	url.Tags, appErr = normalizeTags(urlDto.Tags)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if urlDto.FolderID != "" {
+		url.FolderID, appErr = u.usableFolder(urlDto.FolderID, &url)
+		if appErr != nil {
+			return nil, appErr
+		}
+	}
+	url.Notes, appErr = validateNotes(urlDto.Notes)
+	if appErr != nil {
+		return nil, appErr
+	}
 	url.LongUrl = normalisedUrl
-	url.Status = "active"
+	url.Status = types.UrlStatusActive
 	url.UserID = parsedUserID
-	shortUrl, err := utils.GenerateBase62ID()
-	url.ShortUrl = shortUrl
-	if err != nil {
-		return nil, &types.ApplicationError{
-			Message:        "Unable to generate short url",
-			HttpStatusCode: http.StatusInternalServerError,
-			Err:            err,
+	if urlDto.ShortUrl != "" {
+		if appErr := validateCustomSlug(urlDto.ShortUrl); appErr != nil {
+			return nil, appErr
+		}
+		existingUrl, err := u.store.GetUrlByShortUrl(urlDomain, urlDto.ShortUrl)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to check the short url",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		if existingUrl != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Short url is already taken on this domain",
+				HttpStatusCode: http.StatusConflict,
+			}
+		}
+		url.ShortUrl = urlDto.ShortUrl
+	} else {
+		url.ShortUrl, appErr = u.generateShortUrl(urlDomain)
+		if appErr != nil {
+			return nil, appErr
 		}
 	}
 
@@ -68,10 +203,61 @@ func (u *urlService) CreateUrl(longUrl, user_id string) (*types.URL, *types.Appl
 			Err:            err,
 		}
 	}
+	PublishWebhookEvent(types.WebhookEventUrlCreated, url.UserID, url.WorkspaceID, &url)
 	return &url, nil
 }
 
-func (u *urlService) GetUrlsOfUser(user_id string) ([]*types.URL, *types.ApplicationError) {
+func validateCustomSlug(shortUrl string) *types.ApplicationError {
+	if !customSlugPattern.MatchString(shortUrl) {
+		return &types.ApplicationError{
+			Message:        "Short url must be 3 to 64 letters, digits, '-' or '_'",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	if reservedSlugs[strings.ToLower(shortUrl)] {
+		return &types.ApplicationError{
+			Message:        "Short url " + shortUrl + " is reserved",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+// generateShortUrl returns a base62 short url not yet taken on the domain.
+func (u *urlService) generateShortUrl(domain *types.Domain) (string, *types.ApplicationError) {
+	for attempt := 0; attempt < shortUrlGenerationAttempts; attempt++ {
+		shortUrl, err := utils.GenerateBase62ID()
+		if err != nil {
+			return "", &types.ApplicationError{
+				Message:        "Unable to generate short url",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		if reservedSlugs[strings.ToLower(shortUrl)] {
+			continue
+		}
+
+		existingUrl, err := u.store.GetUrlByShortUrl(domain, shortUrl)
+		if err != nil {
+			return "", &types.ApplicationError{
+				Message:        "Unable to check the short url",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		if existingUrl == nil {
+			return shortUrl, nil
+		}
+	}
+
+	return "", &types.ApplicationError{
+		Message:        "Unable to generate a free short url, try again",
+		HttpStatusCode: http.StatusConflict,
+	}
+}
+
+func (u *urlService) GetUrlsOfUser(user_id string, filter dtos.UrlFilterDTO) ([]*types.URL, *types.ApplicationError) {
 	urls, err := u.store.GetURLsOfUser(user_id)
 	if err != nil {
 		return nil, &types.ApplicationError{
@@ -79,7 +265,9 @@ func (u *urlService) GetUrlsOfUser(user_id string) ([]*types.URL, *types.Applica
 			HttpStatusCode: http.StatusInternalServerError,
 			Err:            err,
 		}
-	} else if len(urls) == 0 {
+	}
+	urls = filterUrls(urls, filter)
+	if len(urls) == 0 {
 		return nil, &types.ApplicationError{
 			Message:        "N0 urls found",
 			HttpStatusCode: http.StatusNoContent,
@@ -90,23 +278,179 @@ func (u *urlService) GetUrlsOfUser(user_id string) ([]*types.URL, *types.Applica
 }
 
 func (u *urlService) GetUrlByID(id, user_id string) (*types.URL, *types.ApplicationError) {
+	return u.getAuthorizedUrl(id, user_id, types.WorkspaceRoleViewer)
+}
+
+func (u *urlService) GetEditableUrlByID(id, user_id string) (*types.URL, *types.ApplicationError) {
+	return u.getAuthorizedUrl(id, user_id, types.WorkspaceRoleEditor)
+}
+
+// getAuthorizedUrl returns personal links to their owner and workspace links
+// to members holding at least the required workspace role.
+func (u *urlService) getAuthorizedUrl(id, user_id, requiredRole string) (*types.URL, *types.ApplicationError) {
 	url, err := u.store.GetUrlByID(id)
-	parsedUser_id, err := gocql.ParseUUID(user_id)
 	if err != nil {
 		return nil, &types.ApplicationError{
-			Message:        "Unable to find the user from the context",
+			Message:        "Unable to find the url",
 			HttpStatusCode: http.StatusInternalServerError,
 			Err:            err,
 		}
 	}
-	if url.UserID != parsedUser_id || (err != nil && url == nil) {
+	if url == nil || !url.DeletedAt.IsZero() {
 		return nil, &types.ApplicationError{
 			Message:        "No url found with given id",
 			HttpStatusCode: http.StatusNotFound,
 		}
-	} else if err != nil {
+	}
+
+	if url.WorkspaceID == (gocql.UUID{}) {
+		if url.UserID.String() != user_id {
+			return nil, &types.ApplicationError{
+				Message:        "No url found with given id",
+				HttpStatusCode: http.StatusNotFound,
+			}
+		}
+		return url, nil
+	}
+
+	member, appErr := u.workspaceMember(url.WorkspaceID.String(), user_id)
+	if appErr != nil {
+		if appErr.HttpStatusCode == http.StatusForbidden {
+			appErr.Message, appErr.HttpStatusCode = "No url found with given id", http.StatusNotFound
+		}
+		return nil, appErr
+	}
+	if !member.CanAct(requiredRole) {
 		return nil, &types.ApplicationError{
-			Message:        "Unable to find the url",
+			Message:        "You are not allowed to change this url",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	return url, nil
+}
+
+func (u *urlService) workspaceMember(workspace_id, user_id string) (*types.WorkspaceMember, *types.ApplicationError) {
+	member, err := u.store.GetWorkspaceMember(workspace_id, user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find workspace membership",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if member == nil {
+		return nil, &types.ApplicationError{
+			Message:        "You are not a member of this workspace",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	return member, nil
+}
+
+func (u *urlService) GetUrlsOfWorkspace(workspace_id, user_id string, filter dtos.UrlFilterDTO) ([]*types.URL, *types.ApplicationError) {
+	_, appErr := u.workspaceMember(workspace_id, user_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	urls, err := u.store.GetURLsOfWorkspace(workspace_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get urls",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return filterUrls(urls, filter), nil
+}
+
+// filterUrls keeps the links carrying the tag and sitting in the folder of
+// the filter, empty filter fields match every link.
+func filterUrls(urls []*types.URL, filter dtos.UrlFilterDTO) []*types.URL {
+	tag := strings.ToLower(strings.TrimSpace(filter.Tag))
+	if tag == "" && filter.FolderID == "" {
+		return urls
+	}
+
+	var filteredUrls []*types.URL
+	for _, url := range urls {
+		if tag != "" && !url.HasTag(tag) {
+			continue
+		}
+		if filter.FolderID != "" && url.FolderID.String() != filter.FolderID {
+			continue
+		}
+		filteredUrls = append(filteredUrls, url)
+	}
+	return filteredUrls
+}
+
+// TransferUrl moves a link to another user or workspace. Personal links can
+// be moved by their owner, workspace links by workspace admins. Moving into a
+// workspace needs editor access there. Links are only handed to another user
+// inside a workspace both belong to, nobody gets a link pushed onto their
+// own account.
+func (u *urlService) TransferUrl(id, user_id string, transferDto dtos.UrlTransferDTO) (*types.URL, *types.ApplicationError) {
+	if (transferDto.UserID == "") == (transferDto.WorkspaceID == "") {
+		return nil, &types.ApplicationError{
+			Message:        "Provide either a user_id or a workspace_id",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	url, appErr := u.getAuthorizedUrl(id, user_id, types.WorkspaceRoleAdmin)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if transferDto.WorkspaceID != "" {
+		member, appErr := u.workspaceMember(transferDto.WorkspaceID, user_id)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if !member.CanAct(types.WorkspaceRoleEditor) {
+			return nil, &types.ApplicationError{
+				Message:        "You are not allowed to move links into this workspace",
+				HttpStatusCode: http.StatusForbidden,
+			}
+		}
+		url.WorkspaceID = member.WorkspaceID
+	} else {
+		newOwner, err := u.store.GetUserByID(transferDto.UserID)
+		if err != nil || newOwner == nil {
+			return nil, &types.ApplicationError{
+				Message:        "User not found",
+				HttpStatusCode: http.StatusNotFound,
+				Err:            err,
+			}
+		}
+		if url.WorkspaceID == (gocql.UUID{}) {
+			return nil, &types.ApplicationError{
+				Message:        "Move the link into a workspace to share it with other users",
+				HttpStatusCode: http.StatusForbidden,
+			}
+		}
+		_, appErr := u.workspaceMember(url.WorkspaceID.String(), newOwner.ID.String())
+		if appErr != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Links can only be handed over to members of the workspace",
+				HttpStatusCode: http.StatusForbidden,
+			}
+		}
+		url.UserID = newOwner.ID
+	}
+
+	// Folders belong to the previous owner or workspace.
+	if transferDto.WorkspaceID != "" {
+		url.FolderID = gocql.UUID{}
+	}
+	err := u.store.UpdateUrlOwner(url)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to transfer url",
 			HttpStatusCode: http.StatusInternalServerError,
 			Err:            err,
 		}
@@ -115,9 +459,528 @@ func (u *urlService) GetUrlByID(id, user_id string) (*types.URL, *types.Applicat
 	return url, nil
 }
 
-func (u *urlService) GetUrlByShortUrl(short_url string) (*types.URL, *types.ApplicationError) {
-	url, err := u.store.GetUrlByShortUrl(short_url)
-	if err != nil && url == nil {
+func (u *urlService) UpdateUrl(id, user_id string, updateDto dtos.UrlUpdateDTO) (*types.URL, *types.ApplicationError) {
+	url, appErr := u.GetEditableUrlByID(id, user_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if updateDto.LongUrl != nil {
+		normalisedUrl, ok := utils.NormalizeAndValidateURL(*updateDto.LongUrl)
+		if !ok {
+			return nil, &types.ApplicationError{
+				Message:        "Not a valid url",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		url.LongUrl = normalisedUrl
+	}
+	if updateDto.RoutingRules != nil {
+		routingRules, appErr := validateRoutingRules(*updateDto.RoutingRules)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.RoutingRules = routingRules
+	}
+	if updateDto.Variants != nil {
+		variants, appErr := validateVariants(*updateDto.Variants)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.Variants = variants
+	}
+	if updateDto.DeepLink != nil {
+		deepLink, appErr := validateDeepLink(*updateDto.DeepLink)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.DeepLink = deepLink
+	}
+	if updateDto.UTMParams != nil {
+		utmParams, appErr := validateUTMParams(*updateDto.UTMParams)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.UTMParams = utmParams
+	}
+	if updateDto.ForwardQuery != nil {
+		url.ForwardQuery = *updateDto.ForwardQuery
+	}
+	if updateDto.QueryMerge != nil {
+		queryMerge, appErr := validateQueryMerge(*updateDto.QueryMerge)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.QueryMerge = queryMerge
+	}
+	if updateDto.RedirectType != nil {
+		redirectType, appErr := validateRedirectType(*updateDto.RedirectType)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.RedirectType = redirectType
+	}
+	if updateDto.InterstitialDelay != nil {
+		interstitialDelay, appErr := validateInterstitialDelay(*updateDto.InterstitialDelay)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.InterstitialDelay = interstitialDelay
+	}
+	if updateDto.LeavingWarning != nil {
+		url.LeavingWarning = *updateDto.LeavingWarning
+	}
+	if updateDto.NoIndex != nil {
+		url.NoIndex = *updateDto.NoIndex
+	}
+	if updateDto.Tags != nil {
+		tags, appErr := normalizeTags(*updateDto.Tags)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.Tags = tags
+	}
+	if updateDto.FolderID != nil {
+		url.FolderID = gocql.UUID{}
+		if *updateDto.FolderID != "" {
+			folderID, appErr := u.usableFolder(*updateDto.FolderID, url)
+			if appErr != nil {
+				return nil, appErr
+			}
+			url.FolderID = folderID
+		}
+	}
+	if updateDto.Notes != nil {
+		notes, appErr := validateNotes(*updateDto.Notes)
+		if appErr != nil {
+			return nil, appErr
+		}
+		url.Notes = notes
+	}
+
+	err := u.store.UpdateURL(url)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to update url",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return url, nil
+}
+
+// ResolveDestination picks the destination for the visit from the routing
+// rules, then from the split test variants, falling back to the long url.
+// The visitor location is only looked up when a country rule needs it.
+func (u *urlService) ResolveDestination(url *types.URL, visit *types.Visit) *types.RedirectTarget {
+	target := pickDestination(url, visit)
+	target.Destination = buildDestination(url, target.Destination, visit.Query)
+	return target
+}
+
+func pickDestination(url *types.URL, visit *types.Visit) *types.RedirectTarget {
+	for _, rule := range url.RoutingRules {
+		if rule.Type == types.RoutingRuleCountry {
+			LocateVisit(visit)
+		}
+		if rule.Matches(visit) {
+			return &types.RedirectTarget{Destination: rule.Destination, MatchedRule: rule.Label(visit)}
+		}
+	}
+
+	if len(url.Variants) > 0 {
+		variant := url.Variants.ByName(visit.AssignedVariant)
+		if variant == nil || variant.Weight == 0 {
+			variant = url.Variants.Pick(url.ID.String() + visit.ClientIP)
+		}
+		return &types.RedirectTarget{Destination: variant.Destination, MatchedRule: types.RoutingRuleDefault, Variant: variant.Name}
+	}
+
+	return &types.RedirectTarget{Destination: url.LongUrl, MatchedRule: types.RoutingRuleDefault}
+}
+
+// buildDestination adds the UTM parameters of the link and, when enabled, the
+// visitor query string to the destination. Keys present on both sides are
+// resolved by the link's query merge rule, the UTM parameters of the link
+// count as part of the destination so visitors cannot replace them under the
+// default rule. The query of the destination is
+// kept as written, only the added parameters are appended: re-encoding it
+// would reorder the keys and change the escaping some sites depend on.
+func buildDestination(url *types.URL, destination string, incoming neturl.Values) string {
+	if len(url.UTMParams) == 0 && (!url.ForwardQuery || len(incoming) == 0) {
+		return destination
+	}
+
+	parsedDestination, err := neturl.Parse(destination)
+	if err != nil {
+		return destination
+	}
+	query := parsedDestination.Query()
+	added := neturl.Values{}
+	for _, key := range types.UTMKeys {
+		if value := url.UTMParams[key]; value != "" && query.Get("utm_"+key) == "" {
+			added.Set("utm_"+key, value)
+		}
+	}
+
+	replaced := map[string]bool{}
+	if url.ForwardQuery {
+		for key, values := range incoming {
+			_, exists := query[key]
+			_, configured := added[key]
+			switch {
+			case !exists && !configured:
+				added[key] = values
+			case url.QueryMerge == types.QueryMergeKeepIncoming:
+				if exists {
+					replaced[key] = true
+				}
+				added[key] = values
+			case url.QueryMerge == types.QueryMergeAppend:
+				added[key] = append(added[key], values...)
+			}
+		}
+	}
+
+	rawQuery := withoutQueryKeys(parsedDestination.RawQuery, replaced)
+	if encoded := added.Encode(); encoded != "" {
+		if rawQuery != "" {
+			rawQuery += "&"
+		}
+		rawQuery += encoded
+	}
+	parsedDestination.RawQuery = rawQuery
+	return parsedDestination.String()
+}
+
+// withoutQueryKeys removes the pairs of the keys from the raw query and
+// leaves every other pair as it was written.
+func withoutQueryKeys(rawQuery string, keys map[string]bool) string {
+	if len(keys) == 0 || rawQuery == "" {
+		return rawQuery
+	}
+
+	var kept []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := neturl.QueryUnescape(key); err == nil && keys[unescaped] {
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&")
+}
+
+// validateUTMParams accepts the keys with or without the "utm_" prefix and
+// drops empty values.
+func validateUTMParams(params types.UTMParams) (types.UTMParams, *types.ApplicationError) {
+	validParams := types.UTMParams{}
+	for key, value := range params {
+		key = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(key)), "utm_")
+		if !types.IsValidUTMKey(key) {
+			return nil, &types.ApplicationError{
+				Message:        "UTM parameters must be one of source, medium, campaign, term or content",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			validParams[key] = value
+		}
+	}
+
+	if len(validParams) == 0 {
+		return nil, nil
+	}
+	return validParams, nil
+}
+
+// usableFolder returns the folder id if the folder belongs to the same owner
+// as the link: the user for personal links, the workspace for workspace links.
+func (u *urlService) usableFolder(folder_id string, url *types.URL) (gocql.UUID, *types.ApplicationError) {
+	folder, err := u.store.GetFolderByID(folder_id)
+	if err != nil || folder == nil {
+		return gocql.UUID{}, &types.ApplicationError{
+			Message:        "No folder found with given id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	if folder.WorkspaceID != url.WorkspaceID || (url.WorkspaceID == (gocql.UUID{}) && folder.UserID != url.UserID) {
+		return gocql.UUID{}, &types.ApplicationError{
+			Message:        "The folder and the link must belong to the same owner",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	return folder.ID, nil
+}
+
+// normalizeTags lowercases tags and drops duplicates so filtering can compare
+// them as they are.
+func normalizeTags(tags []string) ([]string, *types.ApplicationError) {
+	var normalizedTags []string
+	for _, tag := range tags {
+		tag, appErr := normalizeTag(tag)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if !slices.Contains(normalizedTags, tag) {
+			normalizedTags = append(normalizedTags, tag)
+		}
+	}
+
+	if len(normalizedTags) > maximumTagsPerUrl {
+		return nil, &types.ApplicationError{
+			Message:        "A link can have at most 20 tags",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	return normalizedTags, nil
+}
+
+func normalizeTag(tag string) (string, *types.ApplicationError) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(tag) {
+		return "", &types.ApplicationError{
+			Message:        "Tags must be 1 to 50 letters, digits, '-' or '_'",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	return tag, nil
+}
+
+func validateNotes(notes string) (string, *types.ApplicationError) {
+	notes = strings.TrimSpace(notes)
+	if len(notes) > maximumNotesLength {
+		return "", &types.ApplicationError{
+			Message:        "Notes can be at most 10000 characters",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	return notes, nil
+}
+
+func validateRedirectType(redirectType string) (string, *types.ApplicationError) {
+	if redirectType == "" {
+		return types.RedirectFound, nil
+	}
+	if !types.IsValidRedirectType(redirectType) {
+		return "", &types.ApplicationError{
+			Message:        "Redirect type must be one of 301, 302, 307, 308 or interstitial",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	return redirectType, nil
+}
+
+func validateInterstitialDelay(delay int) (int, *types.ApplicationError) {
+	if delay < 0 || delay > types.MaximumInterstitialDelay {
+		return 0, &types.ApplicationError{
+			Message:        "Interstitial delay must be between 0 and 30 seconds",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	return delay, nil
+}
+
+func validateQueryMerge(rule string) (string, *types.ApplicationError) {
+	if rule == "" {
+		return types.QueryMergeKeepDestination, nil
+	}
+	if !types.IsValidQueryMerge(rule) {
+		return "", &types.ApplicationError{
+			Message:        "Query merge must be one of destination, incoming or append",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	return rule, nil
+}
+
+// validateVariants requires at least two variants with unique names and
+// weights adding up to 100. Unnamed variants are called A, B, C and so on.
+func validateVariants(variants []types.Variant) (types.Variants, *types.ApplicationError) {
+	if len(variants) == 0 {
+		return nil, nil
+	}
+	if len(variants) < 2 || len(variants) > 26 {
+		return nil, &types.ApplicationError{
+			Message:        "A split test needs between 2 and 26 variants",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	var validVariants types.Variants
+	totalWeight := 0
+	for i, variant := range variants {
+		variant.Name = strings.TrimSpace(variant.Name)
+		if variant.Name == "" {
+			variant.Name = string(rune('A' + i))
+		}
+		if validVariants.ByName(variant.Name) != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Variant names must be unique",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		if variant.Weight < 0 {
+			return nil, &types.ApplicationError{
+				Message:        "Variant weights can not be negative",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		totalWeight += variant.Weight
+
+		destination, ok := utils.NormalizeAndValidateURL(variant.Destination)
+		if !ok {
+			return nil, &types.ApplicationError{
+				Message:        "Not a valid variant destination",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		variant.Destination = destination
+
+		validVariants = append(validVariants, variant)
+	}
+
+	if totalWeight != 100 {
+		return nil, &types.ApplicationError{
+			Message:        "Variant weights must add up to 100",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	return validVariants, nil
+}
+
+// validateRoutingRules normalises the rule values and destinations so they
+// compare cheaply on every redirect.
+func validateRoutingRules(rules []types.RoutingRule) (types.RoutingRules, *types.ApplicationError) {
+	var validRules types.RoutingRules
+	for _, rule := range rules {
+		rule.Type = strings.ToLower(strings.TrimSpace(rule.Type))
+		if !types.IsValidRoutingRuleType(rule.Type) {
+			return nil, &types.ApplicationError{
+				Message:        "Routing rule type must be one of country, device, os or language",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+
+		var values []string
+		for _, value := range rule.Values {
+			value = strings.ToLower(strings.TrimSpace(value))
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			return nil, &types.ApplicationError{
+				Message:        "Routing rule needs at least one value",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		rule.Values = values
+
+		destination, ok := utils.NormalizeAndValidateURL(rule.Destination)
+		if !ok {
+			return nil, &types.ApplicationError{
+				Message:        "Not a valid routing rule destination",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		rule.Destination = destination
+
+		validRules = append(validRules, rule)
+	}
+
+	return validRules, nil
+}
+
+// validateDeepLink checks the app links of both platforms, a platform without
+// any link configured is dropped.
+func validateDeepLink(deepLink types.DeepLink) (types.DeepLink, *types.ApplicationError) {
+	var appErr *types.ApplicationError
+	deepLink.IOS, appErr = validateAppLink(deepLink.IOS)
+	if appErr != nil {
+		return types.DeepLink{}, appErr
+	}
+	deepLink.Android, appErr = validateAppLink(deepLink.Android)
+	if appErr != nil {
+		return types.DeepLink{}, appErr
+	}
+	return deepLink, nil
+}
+
+func isUnsafeScheme(scheme string) bool {
+	switch strings.ToLower(scheme) {
+	case "javascript", "data", "vbscript", "file":
+		return true
+	}
+	return false
+}
+
+func validateAppLink(appLink *types.AppLink) (*types.AppLink, *types.ApplicationError) {
+	if appLink == nil {
+		return nil, nil
+	}
+
+	appLink.URI = strings.TrimSpace(appLink.URI)
+	if appLink.URI != "" {
+		parsedURI, err := neturl.Parse(appLink.URI)
+		if err != nil || parsedURI.Scheme == "" || isUnsafeScheme(parsedURI.Scheme) {
+			return nil, &types.ApplicationError{
+				Message:        "App uri must use the app's url scheme, e.g. myapp://path",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+	}
+
+	for _, link := range []*string{&appLink.UniversalLink, &appLink.StoreURL} {
+		if strings.TrimSpace(*link) == "" {
+			*link = ""
+			continue
+		}
+		normalisedLink, ok := utils.NormalizeAndValidateURL(*link)
+		if !ok {
+			return nil, &types.ApplicationError{
+				Message:        "Universal links and store urls must be valid web urls",
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		*link = normalisedLink
+	}
+
+	if appLink.URI == "" && appLink.UniversalLink == "" && appLink.StoreURL == "" {
+		return nil, nil
+	}
+	return appLink, nil
+}
+
+// GetUrlByShortUrl resolves the slug within the custom domain serving the
+// request host, unknown hosts fall back to the default short link host.
+func (u *urlService) GetUrlByShortUrl(host, short_url string) (*types.URL, *types.ApplicationError) {
+	var domain *types.Domain
+	hostname := NormalizeHost(host)
+	if hostname != "" && hostname != NormalizeHost(os.Getenv("SHORT_URL_HOST")) {
+		customDomain, err := u.store.GetVerifiedDomainByHostname(hostname)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to find the domain",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		domain = customDomain
+	}
+
+	url, err := u.store.GetUrlByShortUrl(domain, short_url)
+	if err == nil && url == nil {
+		return nil, &types.ApplicationError{
+			Message:        "No url found with given short url",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	} else if err != nil && url == nil {
 		return nil, &types.ApplicationError{
 			Message:        "No url found with given short url",
 			HttpStatusCode: http.StatusNotFound,
@@ -134,7 +997,7 @@ func (u *urlService) GetUrlByShortUrl(short_url string) (*types.URL, *types.Appl
 }
 
 func (u *urlService) DeleteUrlById(id, user_id string) *types.ApplicationError {
-	url, appErr := u.GetUrlByID(id, user_id)
+	url, appErr := u.GetEditableUrlByID(id, user_id)
 	if appErr != nil {
 		return appErr
 	}
@@ -147,6 +1010,7 @@ func (u *urlService) DeleteUrlById(id, user_id string) *types.ApplicationError {
 			Err:            err,
 		}
 	}
+	PublishWebhookEvent(types.WebhookEventUrlDeleted, url.UserID, url.WorkspaceID, url)
 
 	return nil
 }
@@ -164,7 +1028,7 @@ func (u *urlService) GetUrlLogsByUrl(url *types.URL) ([]*types.UrlLog, *types.Ap
 	return logs, nil
 }
 
-func (u *urlService) GetUrlDatas(url *types.URL) (map[string]interface{}, *types.ApplicationError) {
+func (u *urlService) GetUrlDatas(url *types.URL, filter dtos.AnalyticsFilterDTO) (map[string]interface{}, *types.ApplicationError) {
 	resp, err := http.Get(url.LongUrl)
 	if err != nil {
 		return nil, &types.ApplicationError{
@@ -187,7 +1051,7 @@ func (u *urlService) GetUrlDatas(url *types.URL) (map[string]interface{}, *types
 	title := doc.Find("title").Text()
 	favicon, _ := doc.Find("link[rel~='icon']").Attr("href")
 
-	urlInteractions, err := u.store.CountInteractions(url.ID.String())
+	urlInteractions, err := u.store.CountInteractions(url.ID.String(), filter.IncludeBots)
 	if err != nil {
 		return nil, &types.ApplicationError{
 			Message:        "Unable to get url interactions count",
@@ -195,5 +1059,73 @@ func (u *urlService) GetUrlDatas(url *types.URL) (map[string]interface{}, *types
 			Err:            err,
 		}
 	}
-	return map[string]interface{}{"title": title, "favicon": favicon, "interactions": urlInteractions}, nil
+	uniqueVisitors, err := estimateUniqueVisitors(url.ID)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get unique visitors",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	urlDatas := map[string]interface{}{"title": title, "favicon": favicon, "interactions": urlInteractions, "unique_visitors": uniqueVisitors}
+	if len(url.Variants) > 0 {
+		variantInteractions, err := u.store.CountInteractionsByVariant(url.ID.String(), filter.IncludeBots)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get variant interactions count",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		for _, variant := range url.Variants {
+			if _, ok := variantInteractions[variant.Name]; !ok {
+				variantInteractions[variant.Name] = 0
+			}
+		}
+		urlDatas["variant_interactions"] = variantInteractions
+	}
+
+	return urlDatas, nil
+}
+
+// usableDomain returns the verified custom domain if it belongs to the user
+// or to a workspace where the user can edit links.
+func (u *urlService) usableDomain(hostname, user_id string) (*types.Domain, *types.ApplicationError) {
+	domain, err := u.store.GetVerifiedDomainByHostname(NormalizeHost(hostname))
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find the domain",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if domain == nil {
+		return nil, &types.ApplicationError{
+			Message:        "No verified domain found with given hostname",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	if domain.WorkspaceID == (gocql.UUID{}) {
+		if domain.UserID.String() != user_id {
+			return nil, &types.ApplicationError{
+				Message:        "You are not allowed to use this domain",
+				HttpStatusCode: http.StatusForbidden,
+			}
+		}
+		return domain, nil
+	}
+
+	member, appErr := u.workspaceMember(domain.WorkspaceID.String(), user_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !member.CanAct(types.WorkspaceRoleEditor) {
+		return nil, &types.ApplicationError{
+			Message:        "You are not allowed to use this domain",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	return domain, nil
 }
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"log"
 	"net/http"
 	"time"
 	"urllite/store"
@@ -10,34 +11,68 @@ import (
 )
 
 type urlLogService struct {
-	store store.Store
-	task  tasks.UrlLog
+	store             store.Store
+	task              tasks.UrlLog
+	trafficClassifier TrafficClassifier
 }
 
 type UrlLogService interface {
-	CreateUrlLogByUrl(url *types.URL, clientIp string) *types.ApplicationError
+	CreateUrlLogByUrl(url *types.URL, visit *types.Visit, target *types.RedirectTarget) *types.ApplicationError
 	DeleteUrlLogByUrl(urlID string) *types.ApplicationError
 }
 
 func NewUrlLogService() UrlLogService {
 	s := store.NewStore()
 	t := tasks.NewUrlLogTask()
-	return &urlLogService{store: s, task: t}
+	return &urlLogService{store: s, task: t, trafficClassifier: NewTrafficClassifier()}
 }
 
-func (uls *urlLogService) CreateUrlLogByUrl(url *types.URL, clientIp string) *types.ApplicationError {
-	location, err := utils.GetIPAddressLocation(clientIp)
+// CreateUrlLogByUrl logs the click off the redirect path. The work that
+// needs the raw visitor address is done before the click is queued, the
+// queued log only carries what the privacy settings allow to be stored.
+func (uls *urlLogService) CreateUrlLogByUrl(url *types.URL, visit *types.Visit, target *types.RedirectTarget) *types.ApplicationError {
+	urlLog := &types.UrlLog{
+		UrlID:       url.ID,
+		VisitedAt:   time.Now(),
+		ClientIP:    visit.ClientIP,
+		City:        visit.City,
+		Country:     visit.Country,
+		Destination: target.Destination,
+		MatchedRule: target.MatchedRule,
+		Variant:     target.Variant,
+		Referrer:    visit.Referrer,
+		UserAgent:   visit.UserAgent,
+		DoNotTrack:  visit.DoNotTrack,
+	}
+	go uls.enqueueUrlLog(url, urlLog, visit.Located)
+	return nil
+}
 
-	task, err := uls.task.CreateLog(url.ID.String(), clientIp, location["country"], location["city"], time.Now())
+func (uls *urlLogService) enqueueUrlLog(url *types.URL, urlLog *types.UrlLog, located bool) {
+	settings, err := EffectivePrivacySettings(uls.store, url.UserID.String())
 	if err != nil {
-		return &types.ApplicationError{
-			Message:        "Unable to create the log",
-			HttpStatusCode: http.StatusInternalServerError,
-			Err:            err,
+		log.Printf("Unable to get privacy settings of %s: %v", url.UserID, err)
+		settings = deploymentPrivacySettings()
+	}
+	doNotTrack := settings.HonorDoNotTrack && urlLog.DoNotTrack
+
+	if !doNotTrack && !located {
+		urlLog.Country, urlLog.City = locateIP(urlLog.ClientIP)
+	}
+	urlLog.TrafficClass = uls.trafficClassifier.Classify(urlLog)
+	if !doNotTrack {
+		if err := RecordUniqueVisitor(urlLog); err != nil {
+			log.Printf("Unable to record unique visitor: %v", err)
 		}
 	}
-	go tasks.PerformNow(task)
-	return nil
+	AnonymizeUrlLog(urlLog, settings)
+
+	task, err := uls.task.CreateLog(urlLog)
+	if err != nil {
+		log.Printf("Unable to create the log of %s: %v", url.ID, err)
+		return
+	}
+	tasks.PerformNow(task)
 }
 
 func (uls *urlLogService) DeleteUrlLogByUrl(urlID string) *types.ApplicationError {
@@ -60,3 +95,27 @@ func (uls *urlLogService) DeleteUrlLogByUrl(urlID string) *types.ApplicationErro
 	}
 	return nil
 }
+
+// LocateVisit looks up the visitor location once per visit, routing rules
+// and the click log share the result.
+func LocateVisit(visit *types.Visit) {
+	if visit.Located {
+		return
+	}
+	visit.Located = true
+	visit.Country, visit.City = locateIP(visit.ClientIP)
+}
+
+// locateIP only hands the network of the address to the lookup service,
+// the /24 or /48 is enough to place the visitor.
+func locateIP(ip string) (string, string) {
+	network := truncateIP(ip)
+	if network == "" {
+		return "", ""
+	}
+	location, err := utils.GetIPAddressLocation(network)
+	if err != nil {
+		return "", ""
+	}
+	return location["country"], location["city"]
+}
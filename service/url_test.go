@@ -0,0 +1,263 @@
+package service
+
+import (
+	"net/http"
+	neturl "net/url"
+	"testing"
+	"time"
+	"urllite/types"
+	"urllite/types/dtos"
+
+	"github.com/gocql/gocql"
+)
+
+func TestTransferUrl(t *testing.T) {
+	tests := []struct {
+		name          string
+		inWorkspace   bool
+		recipient     string // member, stranger or the workspace itself
+		ownerRole     string
+		wantStatus    int
+		wantWorkspace bool
+	}{
+		{name: "personal link to another user", recipient: "stranger", wantStatus: http.StatusForbidden},
+		{name: "personal link into own workspace", recipient: "workspace", ownerRole: types.WorkspaceRoleEditor, wantWorkspace: true},
+		{name: "personal link into a workspace as viewer", recipient: "workspace", ownerRole: types.WorkspaceRoleViewer, wantStatus: http.StatusForbidden},
+		{name: "personal link into a foreign workspace", recipient: "workspace", wantStatus: http.StatusForbidden},
+		{name: "workspace link to a member", inWorkspace: true, ownerRole: types.WorkspaceRoleAdmin, recipient: "member", wantWorkspace: true},
+		{name: "workspace link to a stranger", inWorkspace: true, ownerRole: types.WorkspaceRoleAdmin, recipient: "stranger", wantStatus: http.StatusForbidden},
+		{name: "workspace link moved by an editor", inWorkspace: true, ownerRole: types.WorkspaceRoleEditor, recipient: "member", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			owner := &types.User{Email: "owner@example.com"}
+			member := &types.User{Email: "member@example.com"}
+			stranger := &types.User{Email: "stranger@example.com"}
+			store.CreateUser(owner)
+			store.CreateUser(member)
+			store.CreateUser(stranger)
+
+			workspaceID := gocql.TimeUUID()
+			if tt.ownerRole != "" {
+				store.addMember(workspaceID, owner, tt.ownerRole)
+			}
+			store.addMember(workspaceID, member, types.WorkspaceRoleViewer)
+
+			url := &types.URL{ID: gocql.TimeUUID(), UserID: owner.ID, FolderID: gocql.TimeUUID()}
+			if tt.inWorkspace {
+				url.WorkspaceID = workspaceID
+			}
+			store.urls[url.ID.String()] = url
+			previous := *url
+
+			var transferDto dtos.UrlTransferDTO
+			switch tt.recipient {
+			case "member":
+				transferDto.UserID = member.ID.String()
+			case "stranger":
+				transferDto.UserID = stranger.ID.String()
+			case "workspace":
+				transferDto.WorkspaceID = workspaceID.String()
+			}
+
+			transferred, appErr := (&urlService{store: store}).TransferUrl(url.ID.String(), owner.ID.String(), transferDto)
+			if tt.wantStatus != 0 {
+				if appErr == nil || appErr.HttpStatusCode != tt.wantStatus {
+					t.Fatalf("TransferUrl = %v, want status %d", appErr, tt.wantStatus)
+				}
+				stored := store.urls[url.ID.String()]
+				if stored.UserID != previous.UserID || stored.WorkspaceID != previous.WorkspaceID || stored.FolderID != previous.FolderID {
+					t.Error("a refused transfer must leave the link unchanged")
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatal(appErr)
+			}
+			if tt.wantWorkspace && transferred.WorkspaceID != workspaceID {
+				t.Errorf("workspace = %s, want %s", transferred.WorkspaceID, workspaceID)
+			}
+			if tt.recipient == "member" && transferred.UserID != member.ID {
+				t.Errorf("owner = %s, want %s", transferred.UserID, member.ID)
+			}
+		})
+	}
+}
+
+func TestValidateCustomSlug(t *testing.T) {
+	tests := []struct {
+		shortUrl string
+		wantErr  bool
+	}{
+		{shortUrl: "spring-sale"},
+		{shortUrl: "Launch_2026"},
+		{shortUrl: "ab", wantErr: true},
+		{shortUrl: "with space", wantErr: true},
+		{shortUrl: "apple-app-site-association", wantErr: true},
+		{shortUrl: "exports", wantErr: true},
+		{shortUrl: "Report", wantErr: true},
+		{shortUrl: "LOGIN", wantErr: true},
+		{shortUrl: "reports"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shortUrl, func(t *testing.T) {
+			appErr := validateCustomSlug(tt.shortUrl)
+			if (appErr != nil) != tt.wantErr {
+				t.Errorf("validateCustomSlug(%q) = %v, want error %v", tt.shortUrl, appErr, tt.wantErr)
+			}
+			if appErr != nil && appErr.HttpStatusCode != http.StatusBadRequest {
+				t.Errorf("status = %d", appErr.HttpStatusCode)
+			}
+		})
+	}
+}
+
+func TestGenerateShortUrlRetriesOnClash(t *testing.T) {
+	tests := []struct {
+		name       string
+		clashes    int
+		wantStatus int
+	}{
+		{name: "free on the first try"},
+		{name: "free after clashes", clashes: shortUrlGenerationAttempts - 1},
+		{name: "always taken", clashes: shortUrlGenerationAttempts, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			store.shortUrlClashes = tt.clashes
+
+			shortUrl, appErr := (&urlService{store: store}).generateShortUrl(&types.Domain{ID: gocql.TimeUUID(), Hostname: "go.example.com"})
+			if tt.wantStatus != 0 {
+				if appErr == nil || appErr.HttpStatusCode != tt.wantStatus {
+					t.Fatalf("generateShortUrl() = %q, %v, want status %d", shortUrl, appErr, tt.wantStatus)
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatal(appErr)
+			}
+			if shortUrl == "" || store.shortUrlClashes != 0 {
+				t.Errorf("short url = %q, clashes left = %d", shortUrl, store.shortUrlClashes)
+			}
+		})
+	}
+}
+
+func TestBuildDestination(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		url         types.URL
+		incoming    string
+		want        string
+	}{
+		{
+			name:        "nothing to add",
+			destination: "https://example.com/path?b=2&a=1",
+			want:        "https://example.com/path?b=2&a=1",
+		},
+		{
+			name:        "utm appended after the written query",
+			destination: "https://example.com/path?z=1&sig=a%2Bb&empty",
+			url:         types.URL{UTMParams: types.UTMParams{"source": "newsletter", "medium": "email"}},
+			want:        "https://example.com/path?z=1&sig=a%2Bb&empty&utm_medium=email&utm_source=newsletter",
+		},
+		{
+			name:        "utm already on the destination",
+			destination: "https://example.com/?utm_source=site",
+			url:         types.URL{UTMParams: types.UTMParams{"source": "newsletter"}},
+			want:        "https://example.com/?utm_source=site",
+		},
+		{
+			name:        "forwarded query keeps the destination",
+			destination: "https://example.com/?ref=link&x=%7E",
+			url:         types.URL{ForwardQuery: true, QueryMerge: types.QueryMergeKeepDestination},
+			incoming:    "ref=visitor&gclid=abc",
+			want:        "https://example.com/?ref=link&x=%7E&gclid=abc",
+		},
+		{
+			name:        "forwarded query replaces the destination value",
+			destination: "https://example.com/?ref=link&x=%7E",
+			url:         types.URL{ForwardQuery: true, QueryMerge: types.QueryMergeKeepIncoming},
+			incoming:    "ref=visitor",
+			want:        "https://example.com/?x=%7E&ref=visitor",
+		},
+		{
+			name:        "forwarded query appended to the destination value",
+			destination: "https://example.com/?tag=a",
+			url:         types.URL{ForwardQuery: true, QueryMerge: types.QueryMergeAppend},
+			incoming:    "tag=b",
+			want:        "https://example.com/?tag=a&tag=b",
+		},
+		{
+			name:        "forwarded utm keeps the link utm",
+			destination: "https://example.com/",
+			url:         types.URL{UTMParams: types.UTMParams{"source": "newsletter"}, ForwardQuery: true, QueryMerge: types.QueryMergeKeepDestination},
+			incoming:    "utm_source=x&utm_medium=cpc",
+			want:        "https://example.com/?utm_medium=cpc&utm_source=newsletter",
+		},
+		{
+			name:        "forwarded utm replaces the link utm",
+			destination: "https://example.com/",
+			url:         types.URL{UTMParams: types.UTMParams{"source": "newsletter"}, ForwardQuery: true, QueryMerge: types.QueryMergeKeepIncoming},
+			incoming:    "utm_source=x",
+			want:        "https://example.com/?utm_source=x",
+		},
+		{
+			name:        "forwarded utm appended to the link utm",
+			destination: "https://example.com/",
+			url:         types.URL{UTMParams: types.UTMParams{"source": "newsletter"}, ForwardQuery: true, QueryMerge: types.QueryMergeAppend},
+			incoming:    "utm_source=x",
+			want:        "https://example.com/?utm_source=newsletter&utm_source=x",
+		},
+		{
+			name:        "fragment kept",
+			destination: "https://example.com/app#/route?inner=1",
+			url:         types.URL{UTMParams: types.UTMParams{"campaign": "launch"}},
+			want:        "https://example.com/app?utm_campaign=launch#/route?inner=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incoming, err := neturl.ParseQuery(tt.incoming)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buildDestination(&tt.url, tt.destination, incoming); got != tt.want {
+				t.Errorf("buildDestination() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUrlByShortUrlOnReregisteredDomain(t *testing.T) {
+	t.Setenv("SHORT_URL_HOST", "urllite.in")
+	store := newFakeStore()
+	oldDomain := &types.Domain{ID: gocql.TimeUUID(), Hostname: "go.example.com", Status: "verified", DeletedAt: time.Now()}
+	newDomain := &types.Domain{ID: gocql.TimeUUID(), Hostname: "go.example.com", Status: "verified"}
+	store.domains[newDomain.ID.String()] = newDomain
+	oldUrl := &types.URL{ID: gocql.TimeUUID(), Domain: oldDomain.Hostname, DomainID: oldDomain.ID, ShortUrl: "old"}
+	clashingUrl := &types.URL{ID: gocql.TimeUUID(), Domain: oldDomain.Hostname, DomainID: oldDomain.ID, ShortUrl: "shared"}
+	newUrl := &types.URL{ID: gocql.TimeUUID(), Domain: newDomain.Hostname, DomainID: newDomain.ID, ShortUrl: "shared"}
+	for _, url := range []*types.URL{oldUrl, clashingUrl, newUrl} {
+		store.urls[url.ID.String()] = url
+	}
+	service := &urlService{store: store}
+
+	if url, appErr := service.GetUrlByShortUrl("go.example.com", "old"); appErr == nil || appErr.HttpStatusCode != http.StatusNotFound {
+		t.Errorf("link of the old registration resolved to %v, %v", url, appErr)
+	}
+	url, appErr := service.GetUrlByShortUrl("go.example.com:443", "shared")
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	if url.ID != newUrl.ID {
+		t.Errorf("resolved %v, want the link of the new registration", url.ID)
+	}
+}
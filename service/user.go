@@ -3,13 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
 	"math/rand"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 	"urllite/cache"
+	"urllite/security"
 	"urllite/store"
 	"urllite/types"
 	"urllite/types/dtos"
@@ -29,11 +30,16 @@ type UserService interface {
 	GetUserByEmail(email string) (*types.User, *types.ApplicationError)
 	GetUsers(types.UserFilter) ([]*types.User, *types.ApplicationError)
 	UpdateUserByID(id string, user types.User) *types.ApplicationError
-	DeleteUserByID(id string) *types.ApplicationError
+	DeleteUserByID(actor_id, id string) *types.ApplicationError
 	GenerateUserAccessToken(user *types.User, ctx context.Context) (string, *types.ApplicationError)
 	SendEmailVerificationOtp(emailID string) *types.ApplicationError
 	VerifyEmail(emailID, otpStr string) *types.ApplicationError
-	MakeAdmin(user_id string) *types.ApplicationError
+	MakeAdmin(actor_id, user_id string) *types.ApplicationError
+	AssignRole(actor_id, user_id, role string) *types.ApplicationError
+	RevokeRole(actor_id, user_id string) *types.ApplicationError
+	GetRoleChanges(user_id string) ([]*types.RoleChange, *types.ApplicationError)
+	SuspendUser(actor_id, user_id string) *types.ApplicationError
+	ReinstateUser(actor_id, user_id string) *types.ApplicationError
 }
 
 func NewUserService() UserService {
@@ -172,27 +178,33 @@ func (u *userService) UpdateUserByID(id string, user types.User) *types.Applicat
 	return nil
 }
 
-func (u *userService) DeleteUserByID(id string) *types.ApplicationError {
-	existingUser, err := u.store.GetUserByID(id)
-	if err != nil {
+// DeleteUserByID soft deletes a user the actor outranks. A deleted user is
+// not deleted again, the totals and the purge would be counted twice.
+func (u *userService) DeleteUserByID(actor_id, id string) *types.ApplicationError {
+	existingUser, appErr := u.getManagedUser(actor_id, id)
+	if appErr != nil {
+		return appErr
+	}
+	if existingUser.Status == types.UserStatusDeleted {
 		return &types.ApplicationError{
-			Message:        "Unable to find user",
-			HttpStatusCode: http.StatusInternalServerError,
-			Err:            err,
+			Message:        "User is already deleted",
+			HttpStatusCode: http.StatusConflict,
 		}
 	}
 
-	if existingUser == nil {
+	err := u.store.DeleteUser(existingUser)
+	if err != nil {
 		return &types.ApplicationError{
-			Message:        "No user found",
-			HttpStatusCode: http.StatusNotFound,
+			Message:        "Unable to delete the user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
 		}
 	}
 
-	err = u.store.DeleteUser(existingUser)
+	err = u.cascadeUserDeletion(existingUser)
 	if err != nil {
 		return &types.ApplicationError{
-			Message:        "Unable to delete the user",
+			Message:        "Unable to disable the links and sessions of the user",
 			HttpStatusCode: http.StatusInternalServerError,
 			Err:            err,
 		}
@@ -202,6 +214,13 @@ func (u *userService) DeleteUserByID(id string) *types.ApplicationError {
 }
 
 func (u *userService) GenerateUserAccessToken(user *types.User, ctx context.Context) (string, *types.ApplicationError) {
+	if !user.IsActive() {
+		return "", &types.ApplicationError{
+			Message:        "Your account is " + user.Status,
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
 	redisTokenKey := "access_token_" + user.ID.String()
 	redicClient := cache.InitRedis(ctx)
 	ok, err := redicClient.Exists(redisTokenKey)
@@ -233,9 +252,7 @@ func (u *userService) GenerateUserAccessToken(user *types.User, ctx context.Cont
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	jwtKey := []byte(os.Getenv("ACCESS_TOKEN_SECRET_KEY"))
-	accessToken, err := token.SignedString(jwtKey)
+	accessToken, err := security.JWTKeys().Sign(claims)
 	if err != nil {
 		return "", &types.ApplicationError{
 			Message:        "Unable to generate token",
@@ -342,6 +359,9 @@ func (u *userService) VerifyEmail(emailID, otpStr string) *types.ApplicationErro
 			Err:            err,
 		}
 	}
+	if err := u.store.IncrementSystemStat(types.StatVerifiedUsers, 1); err != nil {
+		log.Printf("Unable to count verified user %s: %v", user.ID, err)
+	}
 	appErr = u.UpdateUserByID(user.ID.String(), *user)
 	if appErr != nil {
 		return appErr
@@ -356,17 +376,62 @@ func (u *userService) VerifyEmail(emailID, otpStr string) *types.ApplicationErro
 			}
 		}
 	}
+	PublishWebhookEvent(types.WebhookEventUserVerified, user.ID, gocql.UUID{}, user)
 	return nil
 
 }
 
-func (u *userService) MakeAdmin(user_id string) *types.ApplicationError {
+func (u *userService) MakeAdmin(actor_id, user_id string) *types.ApplicationError {
+	return u.AssignRole(actor_id, user_id, types.RoleAdmin)
+}
+
+func (u *userService) AssignRole(actor_id, user_id, role string) *types.ApplicationError {
+	if !types.IsValidRole(role) {
+		return &types.ApplicationError{
+			Message:        "Invalid role: " + role,
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	actor, appErr := u.GetUserByID(actor_id)
+	if appErr != nil {
+		return appErr
+	}
+
 	user, appErr := u.GetUserByID(user_id)
 	if appErr != nil {
 		return appErr
 	}
 
-	user.Role = "admin"
+	if actor == nil || user == nil {
+		return &types.ApplicationError{
+			Message:        "User not found",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	if actor.ID == user.ID {
+		return &types.ApplicationError{
+			Message:        "You cannot change your own role",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	// Owners can change anyone, everybody else only users ranked below them
+	// and only up to their own role.
+	if actor.Role != types.RoleOwner && (types.RoleRank(user.Role) >= types.RoleRank(actor.Role) || types.RoleRank(role) > types.RoleRank(actor.Role)) {
+		return &types.ApplicationError{
+			Message:        "You are not allowed to change this role",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	previousRole := user.Role
+	if previousRole == role {
+		return nil
+	}
+
+	user.Role = role
 	err := u.store.UpdateUser(user)
 	if err != nil {
 		return &types.ApplicationError{
@@ -376,5 +441,34 @@ func (u *userService) MakeAdmin(user_id string) *types.ApplicationError {
 		}
 	}
 
+	err = u.store.CreateRoleChange(&types.RoleChange{UserID: user.ID, ActorID: actor.ID, PreviousRole: previousRole, NewRole: role})
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to record role change",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	// Drop the cached token so the next login carries the new role
+	sharedRedis().Delete("access_token_" + user.ID.String())
+
 	return nil
 }
+
+func (u *userService) RevokeRole(actor_id, user_id string) *types.ApplicationError {
+	return u.AssignRole(actor_id, user_id, types.RoleUser)
+}
+
+func (u *userService) GetRoleChanges(user_id string) ([]*types.RoleChange, *types.ApplicationError) {
+	roleChanges, err := u.store.GetRoleChangesByUserID(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get role changes",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return roleChanges, nil
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"urllite/store"
+	"urllite/tasks"
+	"urllite/types"
+	"urllite/types/dtos"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	dataExportValidity = 24 * time.Hour
+	// One export per user in this period, building one reads every table.
+	dataExportCooldown = time.Hour
+	// An account without a password or two factor is erased within this
+	// long after logging in through its provider.
+	erasureReauthWindow = 10 * time.Minute
+)
+
+type userDataService struct {
+	store store.Store
+}
+
+// UserDataService answers data subject requests, the export of everything
+// stored about a user and the erasure of their account.
+type UserDataService interface {
+	RequestExport(user_id string) *types.ApplicationError
+	BuildExport(user_id string) error
+	GetExport(token string) ([]byte, *types.ApplicationError)
+	Erase(user_id string, eraseDto dtos.EraseAccountDTO) *types.ApplicationError
+}
+
+func NewUserDataService() UserDataService {
+	s := store.NewStore()
+	return &userDataService{store: s}
+}
+
+func recentOAuthLoginKey(user_id string) string {
+	return "recent_oauth_login_" + user_id
+}
+
+func dataExportKey(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return "data_export_" + hex.EncodeToString(hash[:])
+}
+
+// dataExportUrl points at the download route of the api, served on
+// API_URL or else the short url host.
+func dataExportUrl(token string) string {
+	baseUrl := os.Getenv("API_URL")
+	if baseUrl == "" {
+		baseUrl = "https://" + os.Getenv("SHORT_URL_HOST")
+	}
+	return baseUrl + "/exports/" + token
+}
+
+func (d *userDataService) RequestExport(user_id string) *types.ApplicationError {
+	userID, err := gocql.ParseUUID(user_id)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Invalid user id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	ok, err := sharedRedis().SetIfAbsent("data_export_requested_"+user_id, "1", dataExportCooldown)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to request the export",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if !ok {
+		return &types.ApplicationError{
+			Message:        "An export was requested recently, check your email",
+			HttpStatusCode: http.StatusTooManyRequests,
+		}
+	}
+
+	task, err := tasks.NewUserDataTask().ExportData(&tasks.UserDataExport{UserID: userID})
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to request the export",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	go tasks.PerformNow(task)
+	return nil
+}
+
+// BuildExport runs in the worker. The archive is kept in redis under the
+// hash of a random token and the token is mailed to the user.
+func (d *userDataService) BuildExport(user_id string) error {
+	user, err := d.store.GetUserByID(user_id)
+	if err == gocql.ErrNotFound || (err == nil && user == nil) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	archive, err := d.exportArchive(user)
+	if err != nil {
+		return err
+	}
+
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return err
+	}
+	err = sharedRedis().Set(dataExportKey(token), string(archive), dataExportValidity)
+	if err != nil {
+		return err
+	}
+
+	return utils.NewMailer().SendDataExport(user, dataExportUrl(token))
+}
+
+func (d *userDataService) exportArchive(user *types.User) ([]byte, error) {
+	user_id := user.ID.String()
+	files := map[string]func() (interface{}, error){
+		"profile.json": func() (interface{}, error) { return user, nil },
+		"urls.json":    func() (interface{}, error) { return d.store.GetURLsOfUser(user_id) },
+		"click_logs.json": func() (interface{}, error) {
+			urls, err := d.store.GetURLsOfUser(user_id)
+			if err != nil {
+				return nil, err
+			}
+			logs := map[string][]*types.UrlLog{}
+			for _, url := range urls {
+				urlLogs, err := d.store.GetUrlLogsByUrlId(url.ID.String())
+				if err != nil {
+					return nil, err
+				}
+				logs[url.ID.String()] = urlLogs
+			}
+			return logs, nil
+		},
+		"otp_history.json": func() (interface{}, error) {
+			otps, err := d.store.GetOtpsOfUser(user_id)
+			for _, otp := range otps {
+				otp.Otp = ""
+			}
+			return otps, err
+		},
+		"sessions.json": func() (interface{}, error) {
+			apiKeys, err := d.store.GetApiKeysOfUser(user_id)
+			if err != nil {
+				return nil, err
+			}
+			identities, err := d.store.GetOAuthIdentitiesOfUser(user_id)
+			if err != nil {
+				return nil, err
+			}
+			twoFactor, err := d.store.GetTwoFactorByUserID(user_id)
+			if err != nil {
+				return nil, err
+			}
+			roleChanges, err := d.store.GetRoleChangesByUserID(user_id)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"api_keys": apiKeys, "oauth_identities": identities, "two_factor": twoFactor, "role_changes": roleChanges}, nil
+		},
+		"workspaces.json":       func() (interface{}, error) { return d.store.GetWorkspaceMembershipsOfUser(user_id) },
+		"domains.json":          func() (interface{}, error) { return d.store.GetDomainsOfUser(user_id) },
+		"folders.json":          func() (interface{}, error) { return d.store.GetFoldersOfUser(user_id) },
+		"campaigns.json":        func() (interface{}, error) { return d.store.GetCampaignsOfUser(user_id) },
+		"webhooks.json":         func() (interface{}, error) { return d.store.GetWebhooksOfUser(user_id) },
+		"privacy_settings.json": func() (interface{}, error) { return d.store.GetPrivacySettingsByUserID(user_id) },
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, load := range files {
+		data, err := load()
+		if err != nil {
+			return nil, fmt.Errorf("unable to export %s: %w", name, err)
+		}
+		file, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *userDataService) GetExport(token string) ([]byte, *types.ApplicationError) {
+	key := dataExportKey(token)
+	ok, err := sharedRedis().Exists(key)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to fetch the export",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if !ok {
+		return nil, &types.ApplicationError{
+			Message:        "Export not found or expired",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	archive, err := sharedRedis().Get(key)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to fetch the export",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	return []byte(archive), nil
+}
+
+// Erase deletes the account for good. Owners have to delete their
+// workspaces first, the other members would lose them otherwise.
+func (d *userDataService) Erase(user_id string, eraseDto dtos.EraseAccountDTO) *types.ApplicationError {
+	user, err := d.store.GetUserByID(user_id)
+	if err == gocql.ErrNotFound || (err == nil && user == nil) {
+		return &types.ApplicationError{
+			Message:        "User not found",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	} else if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to find user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	if appErr := d.confirmErasure(user_id, eraseDto); appErr != nil {
+		return appErr
+	}
+
+	memberships, err := d.store.GetWorkspaceMembershipsOfUser(user_id)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to get workspaces of the user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	for _, member := range memberships {
+		workspace, err := d.store.GetWorkspaceByID(member.WorkspaceID.String())
+		if err != nil {
+			return &types.ApplicationError{
+				Message:        "Unable to get workspaces of the user",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		if workspace != nil && workspace.OwnerID == user.ID {
+			return &types.ApplicationError{
+				Message:        "Delete the workspaces you own before erasing your account",
+				HttpStatusCode: http.StatusConflict,
+			}
+		}
+	}
+
+	urls, err := d.store.GetURLsOfUser(user_id)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to get urls of the user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	err = d.store.EraseUser(user)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to erase the user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	// The leaderboards and the login history name the user and their links,
+	// redis keeps them beyond the tables.
+	sharedRedis().Delete("access_token_" + user_id)
+	sharedRedis().RemoveMember(topUsersKey, user_id)
+	for _, url := range urls {
+		sharedRedis().Delete(visitorDaysKey(url.ID))
+		sharedRedis().RemoveMember(topLinksKey, url.ID.String())
+	}
+	if err := forgetLoginHistory(user_id); err != nil {
+		log.Printf("Unable to forget the login history of %s: %v", user_id, err)
+	}
+	return nil
+}
+
+// confirmErasure asks for the password like disabling two factor does. An
+// account without one confirms with a two factor code, or without two
+// factor by logging in through its provider right before.
+func (d *userDataService) confirmErasure(user_id string, eraseDto dtos.EraseAccountDTO) *types.ApplicationError {
+	storedPassword, err := d.store.GetPasswordByUserID(user_id)
+	if err != nil && err != gocql.ErrNotFound {
+		return &types.ApplicationError{
+			Message:        "Unable to verify the password",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if storedPassword != nil {
+		err = bcrypt.CompareHashAndPassword([]byte(storedPassword.HashedPassword), []byte(eraseDto.Password))
+		if err != nil {
+			return &types.ApplicationError{
+				Message:        "Incorrect password.",
+				HttpStatusCode: http.StatusNotAcceptable,
+			}
+		}
+		return nil
+	}
+
+	twoFactor, err := d.store.GetTwoFactorByUserID(user_id)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to get two factor settings",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if twoFactor.IsEnabled() {
+		step, ok := utils.VerifyTOTP(twoFactor.Secret, strings.TrimSpace(eraseDto.Code), time.Now())
+		if !ok || step <= twoFactor.LastUsedStep {
+			return &types.ApplicationError{
+				Message:        "Invalid authentication code",
+				HttpStatusCode: http.StatusNotAcceptable,
+			}
+		}
+		twoFactor.LastUsedStep = step
+		if err := d.store.SaveTwoFactor(twoFactor); err != nil {
+			return &types.ApplicationError{
+				Message:        "Unable to update two factor settings",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		return nil
+	}
+
+	recent, err := sharedRedis().Exists(recentOAuthLoginKey(user_id))
+	if err != nil || !recent {
+		return &types.ApplicationError{
+			Message:        "Log in again to erase your account",
+			HttpStatusCode: http.StatusUnauthorized,
+			Err:            err,
+		}
+	}
+	return nil
+}
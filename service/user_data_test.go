@@ -0,0 +1,159 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"urllite/types"
+	"urllite/types/dtos"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestErase(t *testing.T) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		password    bool
+		twoFactor   bool
+		recentLogin bool
+		ownsSpace   bool
+		eraseDto    dtos.EraseAccountDTO
+		code        bool
+		wantStatus  int
+	}{
+		{name: "correct password", password: true, eraseDto: dtos.EraseAccountDTO{Password: "correct horse"}},
+		{name: "wrong password", password: true, eraseDto: dtos.EraseAccountDTO{Password: "wrong"}, wantStatus: http.StatusNotAcceptable},
+		{name: "no password given", password: true, recentLogin: true, wantStatus: http.StatusNotAcceptable},
+		{name: "two factor code", twoFactor: true, code: true},
+		{name: "wrong two factor code", twoFactor: true, recentLogin: true, eraseDto: dtos.EraseAccountDTO{Code: "000000"}, wantStatus: http.StatusNotAcceptable},
+		{name: "recent provider login", recentLogin: true},
+		{name: "stale provider login", wantStatus: http.StatusUnauthorized},
+		{name: "workspace owner", password: true, ownsSpace: true, eraseDto: dtos.EraseAccountDTO{Password: "correct horse"}, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redis := useFakeRedis(t)
+			store := newFakeStore()
+			user := &types.User{Email: "owner@example.com", VerifiedEmail: "owner@example.com"}
+			store.CreateUser(user)
+			user_id := user.ID.String()
+
+			if tt.password {
+				store.passwords[user_id] = &types.Password{UserID: user.ID, HashedPassword: string(hashedPassword)}
+			}
+			if tt.twoFactor {
+				store.twoFactors[user_id] = &types.TwoFactor{UserID: user.ID, Secret: secret, Status: "enabled"}
+			}
+			if tt.recentLogin {
+				redis.Set(recentOAuthLoginKey(user_id), "1", erasureReauthWindow)
+			}
+			if tt.ownsSpace {
+				workspace := &types.Workspace{ID: gocql.MustRandomUUID(), OwnerID: user.ID}
+				store.workspaces[workspace.ID.String()] = workspace
+				store.addMember(workspace.ID, user, "owner")
+			}
+			eraseDto := tt.eraseDto
+			if tt.code {
+				eraseDto.Code = currentTOTP(t, secret)
+			}
+
+			appErr := (&userDataService{store: store}).Erase(user_id, eraseDto)
+			if tt.wantStatus != 0 {
+				if appErr == nil || appErr.HttpStatusCode != tt.wantStatus {
+					t.Fatalf("Erase() = %v, want status %d", appErr, tt.wantStatus)
+				}
+				if len(store.erased) != 0 {
+					t.Error("user was erased")
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatalf("Erase() = %v", appErr)
+			}
+			if len(store.erased) != 1 || store.erased[0].ID != user.ID {
+				t.Errorf("erased = %v", store.erased)
+			}
+		})
+	}
+}
+
+func TestEraseRejectsReplayedTwoFactorCode(t *testing.T) {
+	useFakeRedis(t)
+	store := newFakeStore()
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &types.User{Email: "owner@example.com"}
+	store.CreateUser(user)
+	store.twoFactors[user.ID.String()] = &types.TwoFactor{UserID: user.ID, Secret: secret, Status: "enabled"}
+
+	service := &userDataService{store: store}
+	code := currentTOTP(t, secret)
+	if appErr := service.confirmErasure(user.ID.String(), dtos.EraseAccountDTO{Code: code}); appErr != nil {
+		t.Fatal(appErr)
+	}
+	if appErr := service.confirmErasure(user.ID.String(), dtos.EraseAccountDTO{Code: code}); appErr == nil {
+		t.Error("the same code was accepted twice")
+	}
+}
+
+func TestEraseForgetsRedisState(t *testing.T) {
+	redis := useFakeRedis(t)
+	store := newFakeStore()
+	user := &types.User{Email: "owner@example.com"}
+	other := &types.User{Email: "other@example.com"}
+	store.CreateUser(user)
+	store.CreateUser(other)
+	user_id := user.ID.String()
+	url := &types.URL{ID: gocql.TimeUUID(), UserID: user.ID}
+	store.urls[url.ID.String()] = url
+	redis.Set(recentOAuthLoginKey(user_id), "1", erasureReauthWindow)
+
+	redis.IncrementScore(topUsersKey, user_id, 3)
+	redis.IncrementScore(topUsersKey, other.ID.String(), 1)
+	redis.IncrementScore(topLinksKey, url.ID.String(), 3)
+	redis.SetScore(lockedAccountsKey, user_id, 1)
+	keys := []string{loginFailuresKey(user_id), loginLockedKey(user_id), loginSourcesTrackedKey(user_id), loginSourceKey(user_id, "0011223344556677")}
+	for _, key := range keys {
+		redis.Set(key, "1", 0)
+	}
+	otherSource := loginSourceKey(other.ID.String(), "0011223344556677")
+	redis.Set(otherSource, "1", 0)
+
+	if appErr := (&userDataService{store: store}).Erase(user_id, dtos.EraseAccountDTO{}); appErr != nil {
+		t.Fatal(appErr)
+	}
+
+	for _, key := range keys {
+		if _, kept := redis.values[key]; kept {
+			t.Errorf("%s was kept", key)
+		}
+	}
+	if _, kept := redis.scores[topUsersKey][user_id]; kept {
+		t.Error("user kept in the top users")
+	}
+	if _, kept := redis.scores[topLinksKey][url.ID.String()]; kept {
+		t.Error("link kept in the top links")
+	}
+	if _, kept := redis.scores[lockedAccountsKey][user_id]; kept {
+		t.Error("user kept in the locked accounts")
+	}
+	if _, kept := redis.scores[topUsersKey][other.ID.String()]; !kept {
+		t.Error("other user removed from the top users")
+	}
+	if _, kept := redis.values[otherSource]; !kept {
+		t.Error("login source of another user removed")
+	}
+}
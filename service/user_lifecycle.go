@@ -0,0 +1,253 @@
+package service
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"urllite/tasks"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// Access tokens live this long, a revocation has to outlast them.
+	accessTokenLifetime = 24 * time.Hour
+	// Deleted users are purged after this, it leaves time to deal with
+	// complaints about what they did.
+	deletedUserPurgeDelay = 30 * 24 * time.Hour
+)
+
+func revokedUserKey(user_id string) string {
+	return "revoked_user_" + user_id
+}
+
+// revokeUserSessions makes every token of the user invalid for the given
+// period, zero keeps them invalid until restoreUserSessions.
+func revokeUserSessions(user_id string, period time.Duration) error {
+	sharedRedis().Delete("access_token_" + user_id)
+	return sharedRedis().Set(revokedUserKey(user_id), "1", period)
+}
+
+func restoreUserSessions(user_id string) error {
+	return sharedRedis().Delete(revokedUserKey(user_id))
+}
+
+func sessionsValidAfterKey(user_id string) string {
+	return "sessions_valid_after_" + user_id
+}
+
+// endUserSessions makes the tokens issued so far invalid, unlike
+// revokeUserSessions the user can log in again right away.
+func endUserSessions(user_id string) error {
+	sharedRedis().Delete("access_token_" + user_id)
+	return sharedRedis().Set(sessionsValidAfterKey(user_id), strconv.FormatInt(time.Now().Unix(), 10), accessTokenLifetime)
+}
+
+// IsUserSessionRevoked tells the authentication middleware to reject the
+// tokens of suspended and deleted users and the tokens issued before their
+// sessions were ended. A redis failure rejects the token, the revocation of
+// a suspended user cannot be skipped while redis is down.
+func IsUserSessionRevoked(user_id string, issuedAt time.Time) bool {
+	revoked, err := sharedRedis().Exists(revokedUserKey(user_id))
+	if err != nil {
+		log.Printf("Unable to check session revocation: %v", err)
+		return true
+	}
+	if revoked {
+		return true
+	}
+
+	validAfter, err := sharedRedis().Get(sessionsValidAfterKey(user_id))
+	if err == redis.Nil {
+		return false
+	}
+	if err != nil {
+		log.Printf("Unable to check session revocation: %v", err)
+		return true
+	}
+	validAfterUnix, err := strconv.ParseInt(validAfter, 10, 64)
+	return err != nil || issuedAt.Unix() < validAfterUnix
+}
+
+// setUrlsStatus moves the links created by the user from one of the given
+// states to the new one, links in other states are left alone.
+func (u *userService) setUrlsStatus(user_id, status string, from ...string) error {
+	urls, err := u.store.GetURLsCreatedByUser(user_id)
+	if err != nil {
+		return err
+	}
+
+	for _, url := range urls {
+		current := url.Status
+		if current == "" {
+			current = types.UrlStatusActive
+		}
+		for _, fromStatus := range from {
+			if current == fromStatus {
+				url.Status = status
+				if err := u.store.UpdateUrlStatus(url); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// getManagedUser returns the user when the actor outranks them, the same
+// rule as for role changes.
+func (u *userService) getManagedUser(actor_id, user_id string) (*types.User, *types.ApplicationError) {
+	actor, appErr := u.GetUserByID(actor_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	user, appErr := u.GetUserByID(user_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if actor == nil || user == nil {
+		return nil, &types.ApplicationError{
+			Message:        "User not found",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	if actor.ID == user.ID {
+		return nil, &types.ApplicationError{
+			Message:        "You cannot change your own account status",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	if actor.Role != types.RoleOwner && types.RoleRank(user.Role) >= types.RoleRank(actor.Role) {
+		return nil, &types.ApplicationError{
+			Message:        "You are not allowed to change this user",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	return user, nil
+}
+
+// SuspendUser blocks the logins of the user, revokes their sessions and
+// pauses their links until they are reinstated.
+func (u *userService) SuspendUser(actor_id, user_id string) *types.ApplicationError {
+	user, appErr := u.getManagedUser(actor_id, user_id)
+	if appErr != nil {
+		return appErr
+	}
+	if user.Status == types.UserStatusSuspended {
+		return nil
+	}
+
+	user.Status = types.UserStatusSuspended
+	err := u.store.UpdateUser(user)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to suspend the user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	err = revokeUserSessions(user_id, 0)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to revoke the sessions of the user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	err = u.setUrlsStatus(user_id, types.UrlStatusPaused, types.UrlStatusActive)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to pause the links of the user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+func (u *userService) ReinstateUser(actor_id, user_id string) *types.ApplicationError {
+	user, appErr := u.getManagedUser(actor_id, user_id)
+	if appErr != nil {
+		return appErr
+	}
+	if user.Status != types.UserStatusSuspended {
+		return &types.ApplicationError{
+			Message:        "User is not suspended",
+			HttpStatusCode: http.StatusConflict,
+		}
+	}
+
+	user.Status = types.UserStatusActive
+	err := u.store.UpdateUser(user)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to reinstate the user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	err = restoreUserSessions(user_id)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to restore the sessions of the user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	err = u.setUrlsStatus(user_id, types.UrlStatusActive, types.UrlStatusPaused)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to resume the links of the user",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+// cascadeUserDeletion disables the links and the password of a deleted
+// user, revokes their sessions and queues the purge of their data.
+func (u *userService) cascadeUserDeletion(user *types.User) error {
+	user_id := user.ID.String()
+	err := u.setUrlsStatus(user_id, types.UrlStatusDisabled, types.UrlStatusActive, types.UrlStatusPaused)
+	if err != nil {
+		return err
+	}
+
+	password, err := u.store.GetPasswordByUserID(user_id)
+	if err != nil && err != gocql.ErrNotFound {
+		return err
+	}
+	if password != nil {
+		if err := u.store.DeletePassword(password); err != nil {
+			return err
+		}
+	}
+
+	err = revokeUserSessions(user_id, accessTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	task, err := tasks.NewUserDataTask().PurgeData(&tasks.UserDataPurge{UserID: user.ID, Email: user.Email})
+	if err != nil {
+		return err
+	}
+	go tasks.PerformAfter(task, deletedUserPurgeDelay)
+	return nil
+}
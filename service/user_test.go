@@ -0,0 +1,113 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+	"urllite/types"
+)
+
+func TestAssignRoleRanks(t *testing.T) {
+	tests := []struct {
+		name       string
+		actorRole  string
+		userRole   string
+		newRole    string
+		wantStatus int
+	}{
+		{name: "owner promotes to owner", actorRole: types.RoleOwner, userRole: types.RoleUser, newRole: types.RoleOwner},
+		{name: "owner demotes another owner", actorRole: types.RoleOwner, userRole: types.RoleOwner, newRole: types.RoleAdmin},
+		{name: "admin promotes to support", actorRole: types.RoleAdmin, userRole: types.RoleUser, newRole: types.RoleSupport},
+		{name: "admin promotes to admin", actorRole: types.RoleAdmin, userRole: types.RoleAnalyst, newRole: types.RoleAdmin},
+		{name: "admin cannot promote to owner", actorRole: types.RoleAdmin, userRole: types.RoleUser, newRole: types.RoleOwner, wantStatus: http.StatusForbidden},
+		{name: "admin cannot change another admin", actorRole: types.RoleAdmin, userRole: types.RoleAdmin, newRole: types.RoleUser, wantStatus: http.StatusForbidden},
+		{name: "admin cannot change an owner", actorRole: types.RoleAdmin, userRole: types.RoleOwner, newRole: types.RoleUser, wantStatus: http.StatusForbidden},
+		{name: "support cannot promote above support", actorRole: types.RoleSupport, userRole: types.RoleUser, newRole: types.RoleAdmin, wantStatus: http.StatusForbidden},
+		{name: "support promotes to analyst", actorRole: types.RoleSupport, userRole: types.RoleUser, newRole: types.RoleAnalyst},
+		{name: "unknown role", actorRole: types.RoleOwner, userRole: types.RoleUser, newRole: "superuser", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeRedis(t)
+			store := newFakeStore()
+			actor := &types.User{Email: "actor@example.com"}
+			user := &types.User{Email: "user@example.com"}
+			store.CreateUser(actor)
+			store.CreateUser(user)
+			actor.Role, user.Role = tt.actorRole, tt.userRole
+			service := &userService{store: store}
+
+			appErr := service.AssignRole(actor.ID.String(), user.ID.String(), tt.newRole)
+			if tt.wantStatus != 0 {
+				if appErr == nil || appErr.HttpStatusCode != tt.wantStatus {
+					t.Fatalf("AssignRole = %v, want status %d", appErr, tt.wantStatus)
+				}
+				if user.Role != tt.userRole || len(store.roleChanges) != 0 {
+					t.Errorf("role changed to %s", user.Role)
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatal(appErr)
+			}
+			if user.Role != tt.newRole {
+				t.Errorf("role = %s, want %s", user.Role, tt.newRole)
+			}
+			if len(store.roleChanges) != 1 || store.roleChanges[0].PreviousRole != tt.userRole {
+				t.Errorf("role changes = %+v", store.roleChanges)
+			}
+		})
+	}
+}
+
+func TestAssignRoleRefusesOwnRole(t *testing.T) {
+	useFakeRedis(t)
+	store := newFakeStore()
+	owner := &types.User{Email: "owner@example.com"}
+	store.CreateUser(owner)
+	owner.Role = types.RoleOwner
+
+	appErr := (&userService{store: store}).AssignRole(owner.ID.String(), owner.ID.String(), types.RoleUser)
+	if appErr == nil || appErr.HttpStatusCode != http.StatusForbidden {
+		t.Errorf("AssignRole = %v, want forbidden", appErr)
+	}
+}
+
+func TestDeleteUserRefusals(t *testing.T) {
+	tests := []struct {
+		name       string
+		actorRole  string
+		userRole   string
+		userStatus string
+		self       bool
+		wantStatus int
+	}{
+		{name: "own account", actorRole: types.RoleAdmin, self: true, wantStatus: http.StatusForbidden},
+		{name: "another admin", actorRole: types.RoleAdmin, userRole: types.RoleAdmin, wantStatus: http.StatusForbidden},
+		{name: "an owner", actorRole: types.RoleAdmin, userRole: types.RoleOwner, wantStatus: http.StatusForbidden},
+		{name: "already deleted", actorRole: types.RoleOwner, userRole: types.RoleUser, userStatus: types.UserStatusDeleted, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeRedis(t)
+			store := newFakeStore()
+			actor := &types.User{Email: "actor@example.com"}
+			user := &types.User{Email: "user@example.com"}
+			store.CreateUser(actor)
+			store.CreateUser(user)
+			actor.Role, user.Role = tt.actorRole, tt.userRole
+			if tt.userStatus != "" {
+				user.Status = tt.userStatus
+			}
+			if tt.self {
+				user = actor
+			}
+
+			appErr := (&userService{store: store}).DeleteUserByID(actor.ID.String(), user.ID.String())
+			if appErr == nil || appErr.HttpStatusCode != tt.wantStatus {
+				t.Errorf("DeleteUserByID = %v, want status %d", appErr, tt.wantStatus)
+			}
+		})
+	}
+}
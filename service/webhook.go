@@ -0,0 +1,573 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"urllite/store"
+	"urllite/tasks"
+	"urllite/types"
+	"urllite/types/dtos"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	// webhookDisableAfterFailures is the number of events in a row that
+	// exhausted their retries before a webhook is disabled.
+	webhookDisableAfterFailures = 5
+	maximumWebhooksPerOwner     = 20
+	webhookDeliveriesPageSize   = 100
+	maximumWebhookResponseSize  = 2048
+)
+
+var errBlockedWebhookAddress = errors.New("webhook endpoint resolves to a private address")
+
+// webhookClient refuses to connect to loopback and private networks so
+// webhooks cannot be pointed at internal services.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: blockPrivateAddresses,
+		}).DialContext,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// blockPrivateAddresses runs on the resolved address right before the
+// connection, so a public name pointing at a private address is caught too.
+func blockPrivateAddresses(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return errBlockedWebhookAddress
+	}
+	return nil
+}
+
+type webhookService struct {
+	store store.Store
+	task  tasks.Webhook
+}
+
+type WebhookService interface {
+	Create(webhookDto dtos.WebhookDTO, user_id string) (*types.Webhook, *types.ApplicationError)
+	GetWebhooksOfUser(user_id string) ([]*types.Webhook, *types.ApplicationError)
+	GetWebhookByID(id, user_id string) (*types.Webhook, *types.ApplicationError)
+	Update(id, user_id string, updateDto dtos.WebhookUpdateDTO) (*types.Webhook, *types.ApplicationError)
+	Delete(id, user_id string) *types.ApplicationError
+	GetDeliveries(id, user_id string) ([]*types.WebhookDelivery, *types.ApplicationError)
+	Redeliver(id, delivery_id, user_id string) *types.ApplicationError
+
+	Dispatch(dispatch *tasks.WebhookDispatch) error
+	Deliver(ctx context.Context, delivery *tasks.WebhookDelivery) error
+}
+
+func NewWebhookService() WebhookService {
+	s := store.NewStore()
+	t := tasks.NewWebhookTask()
+	return &webhookService{store: s, task: t}
+}
+
+// PublishWebhookEvent queues an event for the webhooks of the owner, a zero
+// workspace id means the personal webhooks of the user.
+func PublishWebhookEvent(eventType string, userID, workspaceID gocql.UUID, data interface{}) {
+	task, err := newWebhookDispatchTask(eventType, userID, workspaceID, data)
+	if err != nil {
+		log.Printf("Unable to publish %s webhook event: %v", eventType, err)
+		return
+	}
+	go tasks.PerformNow(task)
+}
+
+// PublishWebhookEventOnce publishes the event unless one with the same key
+// was published within the period.
+func PublishWebhookEventOnce(eventType string, userID, workspaceID gocql.UUID, data interface{}, key string, period time.Duration) {
+	task, err := newWebhookDispatchTask(eventType, userID, workspaceID, data)
+	if err != nil {
+		log.Printf("Unable to publish %s webhook event: %v", eventType, err)
+		return
+	}
+	tasks.PerformOnce(task, eventType+":"+key, period)
+}
+
+func newWebhookDispatchTask(eventType string, userID, workspaceID gocql.UUID, data interface{}) (*asynq.Task, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks.NewWebhookTask().DispatchEvent(&tasks.WebhookDispatch{
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Event: types.WebhookEvent{
+			ID:        gocql.TimeUUID(),
+			Type:      eventType,
+			CreatedAt: time.Now(),
+			Data:      payload,
+		},
+	})
+}
+
+// Create registers the endpoint and returns the webhook with its signing
+// secret, the secret is not shown again.
+func (ws *webhookService) Create(webhookDto dtos.WebhookDTO, user_id string) (*types.Webhook, *types.ApplicationError) {
+	userID, err := gocql.ParseUUID(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid user id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	webhook := &types.Webhook{UserID: userID, Status: types.WebhookStatusActive}
+	webhook.URL, err = validateWebhookURL(webhookDto.URL)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        err.Error(),
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+	var appErr *types.ApplicationError
+	webhook.Events, appErr = normalizeWebhookEvents(webhookDto.Events)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	var existingWebhooks []*types.Webhook
+	if webhookDto.WorkspaceID != "" {
+		member, err := ws.store.GetWorkspaceMember(webhookDto.WorkspaceID, user_id)
+		if err != nil || !member.CanAct(types.WorkspaceRoleAdmin) {
+			return nil, &types.ApplicationError{
+				Message:        "Only workspace admins can add workspace webhooks",
+				HttpStatusCode: http.StatusForbidden,
+				Err:            err,
+			}
+		}
+		webhook.WorkspaceID = member.WorkspaceID
+		existingWebhooks, err = ws.store.GetWebhooksOfWorkspace(webhookDto.WorkspaceID)
+	} else {
+		existingWebhooks, err = ws.store.GetWebhooksOfUser(user_id)
+	}
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get webhooks",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if len(existingWebhooks) >= maximumWebhooksPerOwner {
+		return nil, &types.ApplicationError{
+			Message:        fmt.Sprintf("At most %d webhooks can be registered", maximumWebhooksPerOwner),
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	secret, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to generate webhook secret",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	webhook.Secret = "whsec_" + secret
+
+	err = ws.store.CreateWebhook(webhook)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to create webhook",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return webhook, nil
+}
+
+// GetWebhooksOfUser lists personal webhooks and the webhooks of every
+// workspace the user administers.
+func (ws *webhookService) GetWebhooksOfUser(user_id string) ([]*types.Webhook, *types.ApplicationError) {
+	webhooks, err := ws.store.GetWebhooksOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get webhooks",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	memberships, err := ws.store.GetWorkspaceMembershipsOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get webhooks",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	for _, membership := range memberships {
+		if !membership.CanAct(types.WorkspaceRoleAdmin) {
+			continue
+		}
+		workspaceWebhooks, err := ws.store.GetWebhooksOfWorkspace(membership.WorkspaceID.String())
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get webhooks",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		webhooks = append(webhooks, workspaceWebhooks...)
+	}
+
+	return webhooks, nil
+}
+
+func (ws *webhookService) GetWebhookByID(id, user_id string) (*types.Webhook, *types.ApplicationError) {
+	return ws.getManagedWebhook(id, user_id)
+}
+
+func (ws *webhookService) Update(id, user_id string, updateDto dtos.WebhookUpdateDTO) (*types.Webhook, *types.ApplicationError) {
+	webhook, appErr := ws.getManagedWebhook(id, user_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if updateDto.URL != nil {
+		webhookURL, err := validateWebhookURL(*updateDto.URL)
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        err.Error(),
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		webhook.URL = webhookURL
+	}
+	if updateDto.Events != nil {
+		webhook.Events, appErr = normalizeWebhookEvents(*updateDto.Events)
+		if appErr != nil {
+			return nil, appErr
+		}
+	}
+	if updateDto.Active != nil {
+		if *updateDto.Active {
+			webhook.Status, webhook.FailureCount, webhook.DisabledAt = types.WebhookStatusActive, 0, time.Time{}
+		} else if webhook.IsActive() {
+			webhook.Status, webhook.DisabledAt = types.WebhookStatusDisabled, time.Now()
+		}
+	}
+
+	err := ws.store.UpdateWebhook(webhook)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to update webhook",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return webhook, nil
+}
+
+func (ws *webhookService) Delete(id, user_id string) *types.ApplicationError {
+	webhook, appErr := ws.getManagedWebhook(id, user_id)
+	if appErr != nil {
+		return appErr
+	}
+
+	err := ws.store.DeleteWebhook(webhook)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to delete webhook",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+func (ws *webhookService) GetDeliveries(id, user_id string) ([]*types.WebhookDelivery, *types.ApplicationError) {
+	webhook, appErr := ws.getManagedWebhook(id, user_id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	deliveries, err := ws.store.GetWebhookDeliveries(webhook.ID, webhookDeliveriesPageSize)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get webhook deliveries",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver posts the event of an earlier delivery again, once, even when
+// the webhook is disabled so the owner can check a fixed endpoint.
+func (ws *webhookService) Redeliver(id, delivery_id, user_id string) *types.ApplicationError {
+	webhook, appErr := ws.getManagedWebhook(id, user_id)
+	if appErr != nil {
+		return appErr
+	}
+
+	delivery, err := ws.store.GetWebhookDeliveryByID(webhook.ID, delivery_id)
+	if err != nil || delivery == nil {
+		return &types.ApplicationError{
+			Message:        "No delivery found with given id",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+
+	var event types.WebhookEvent
+	err = json.Unmarshal([]byte(delivery.Payload), &event)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to read the delivered event",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	task, err := ws.task.DeliverEvent(&tasks.WebhookDelivery{WebhookID: webhook.ID, Event: event, Manual: true})
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to queue the redelivery",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	go tasks.PerformNow(task)
+
+	return nil
+}
+
+// Dispatch runs in the worker and queues one delivery per active webhook of
+// the owner subscribed to the event.
+func (ws *webhookService) Dispatch(dispatch *tasks.WebhookDispatch) error {
+	var webhooks []*types.Webhook
+	var err error
+	if dispatch.WorkspaceID != (gocql.UUID{}) {
+		webhooks, err = ws.store.GetWebhooksOfWorkspace(dispatch.WorkspaceID.String())
+	} else {
+		webhooks, err = ws.store.GetWebhooksOfUser(dispatch.UserID.String())
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.IsActive() || !webhook.Subscribes(dispatch.Event.Type) {
+			continue
+		}
+		task, err := ws.task.DeliverEvent(&tasks.WebhookDelivery{WebhookID: webhook.ID, Event: dispatch.Event})
+		if err != nil {
+			return err
+		}
+		tasks.PerformNow(task)
+	}
+
+	return nil
+}
+
+// Deliver runs in the worker, posts the signed event and logs the attempt.
+// A returned error makes asynq retry the delivery with backoff.
+func (ws *webhookService) Deliver(ctx context.Context, delivery *tasks.WebhookDelivery) error {
+	webhook, err := ws.store.GetWebhookByID(delivery.WebhookID.String())
+	if err != nil {
+		return err
+	}
+	if webhook == nil || (!webhook.IsActive() && !delivery.Manual) {
+		return nil
+	}
+
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+	}
+
+	retried, _ := asynq.GetRetryCount(ctx)
+	record := &types.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   delivery.Event.ID,
+		Event:     delivery.Event.Type,
+		Payload:   string(body),
+		Attempt:   retried + 1,
+		Manual:    delivery.Manual,
+	}
+
+	startedAt := time.Now()
+	deliveryErr := postWebhook(ctx, webhook, delivery.Event, body, record)
+	record.DurationMs = int(time.Since(startedAt).Milliseconds())
+	record.Succeeded = deliveryErr == nil
+	if deliveryErr != nil {
+		record.Error = deliveryErr.Error()
+	}
+	if err := ws.store.CreateWebhookDelivery(record); err != nil {
+		log.Printf("Unable to log webhook delivery: %v", err)
+	}
+
+	if delivery.Manual {
+		return nil
+	}
+	if deliveryErr == nil {
+		if webhook.FailureCount > 0 {
+			webhook.FailureCount = 0
+			return ws.store.UpdateWebhook(webhook)
+		}
+		return nil
+	}
+
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	if retried >= maxRetry {
+		webhook.FailureCount++
+		if webhook.FailureCount >= webhookDisableAfterFailures {
+			webhook.Status, webhook.DisabledAt = types.WebhookStatusDisabled, time.Now()
+		}
+		if err := ws.store.UpdateWebhook(webhook); err != nil {
+			log.Printf("Unable to record webhook failure: %v", err)
+		}
+	}
+	return deliveryErr
+}
+
+// postWebhook signs the body with the webhook secret. Receivers recompute
+// HMAC-SHA256 over "<timestamp>.<body>" and compare it with v1.
+func postWebhook(ctx context.Context, webhook *types.Webhook, event types.WebhookEvent, body []byte, record *types.WebhookDelivery) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(webhook.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "urllite-webhooks/1.0")
+	req.Header.Set("X-Urllite-Event", event.Type)
+	req.Header.Set("X-Urllite-Delivery", event.ID.String())
+	req.Header.Set("X-Urllite-Signature", "t="+timestamp+",v1="+hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, maximumWebhookResponseSize))
+	record.StatusCode, record.Response = resp.StatusCode, string(response)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook endpoint responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// getManagedWebhook returns the webhook if the user owns it or administers
+// the workspace owning it.
+func (ws *webhookService) getManagedWebhook(id, user_id string) (*types.Webhook, *types.ApplicationError) {
+	webhook, err := ws.store.GetWebhookByID(id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find the webhook",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if webhook == nil {
+		return nil, &types.ApplicationError{
+			Message:        "No webhook found with given id",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	if webhook.WorkspaceID == (gocql.UUID{}) {
+		if webhook.UserID.String() != user_id {
+			return nil, &types.ApplicationError{
+				Message:        "No webhook found with given id",
+				HttpStatusCode: http.StatusNotFound,
+			}
+		}
+		return webhook, nil
+	}
+
+	member, err := ws.store.GetWorkspaceMember(webhook.WorkspaceID.String(), user_id)
+	if err != nil || !member.CanAct(types.WorkspaceRoleAdmin) {
+		return nil, &types.ApplicationError{
+			Message:        "No webhook found with given id",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+
+	return webhook, nil
+}
+
+func validateWebhookURL(rawURL string) (string, error) {
+	webhookURL, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (webhookURL.Scheme != "https" && webhookURL.Scheme != "http") || webhookURL.Hostname() == "" {
+		return "", errors.New("Webhook url must be an absolute http or https url")
+	}
+	if webhookURL.User != nil {
+		return "", errors.New("Webhook url must not contain credentials")
+	}
+
+	host := strings.ToLower(webhookURL.Hostname())
+	if ip := net.ParseIP(host); host == "localhost" || strings.HasSuffix(host, ".localhost") || (ip != nil && (ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified())) {
+		return "", errors.New("Webhook url must be publicly reachable")
+	}
+
+	return webhookURL.String(), nil
+}
+
+func normalizeWebhookEvents(events []string) ([]string, *types.ApplicationError) {
+	if len(events) == 0 {
+		return nil, &types.ApplicationError{
+			Message:        "Subscribe to at least one of " + strings.Join(types.WebhookEvents, ", "),
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	var normalized []string
+	for _, event := range events {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !types.IsValidWebhookEvent(event) {
+			return nil, &types.ApplicationError{
+				Message:        "Unknown webhook event " + event + ", use one of " + strings.Join(types.WebhookEvents, ", "),
+				HttpStatusCode: http.StatusBadRequest,
+			}
+		}
+		if !slices.Contains(normalized, event) {
+			normalized = append(normalized, event)
+		}
+	}
+	return normalized, nil
+}
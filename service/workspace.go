@@ -0,0 +1,420 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"urllite/store"
+	"urllite/types"
+	"urllite/types/dtos"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+)
+
+const workspaceInvitationTTL = 7 * 24 * time.Hour
+
+type workspaceService struct {
+	store store.Store
+}
+
+type WorkspaceService interface {
+	Create(workspaceDto dtos.WorkspaceDTO, user_id string) (*types.Workspace, *types.ApplicationError)
+	GetWorkspacesOfUser(user_id string) ([]*types.Workspace, *types.ApplicationError)
+	GetWorkspaceByID(id, user_id string) (*types.Workspace, []*types.WorkspaceMember, *types.ApplicationError)
+	Delete(id, user_id string) *types.ApplicationError
+	ChangeMemberRole(id, user_id, member_id, role string) *types.ApplicationError
+	RemoveMember(id, user_id, member_id string) *types.ApplicationError
+	Invite(id, user_id string, invitationDto dtos.WorkspaceInvitationDTO) (*types.WorkspaceInvitation, *types.ApplicationError)
+	AcceptInvitation(token, user_id string) (*types.WorkspaceMember, *types.ApplicationError)
+}
+
+func NewWorkspaceService() WorkspaceService {
+	s := store.NewStore()
+	return &workspaceService{store: s}
+}
+
+func (w *workspaceService) Create(workspaceDto dtos.WorkspaceDTO, user_id string) (*types.Workspace, *types.ApplicationError) {
+	name := strings.TrimSpace(workspaceDto.Name)
+	if name == "" || strings.ContainsAny(name, "\r\n") {
+		return nil, &types.ApplicationError{
+			Message:        "A valid workspace name is required",
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	ownerID, err := gocql.ParseUUID(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid user id",
+			HttpStatusCode: http.StatusBadRequest,
+			Err:            err,
+		}
+	}
+
+	workspace := &types.Workspace{Name: name, OwnerID: ownerID}
+	err = w.store.CreateWorkspace(workspace)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to create workspace",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	err = w.store.SaveWorkspaceMember(&types.WorkspaceMember{WorkspaceID: workspace.ID, UserID: ownerID, Role: types.WorkspaceRoleAdmin})
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to add workspace owner",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return workspace, nil
+}
+
+func (w *workspaceService) GetWorkspacesOfUser(user_id string) ([]*types.Workspace, *types.ApplicationError) {
+	memberships, err := w.store.GetWorkspaceMembershipsOfUser(user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to get workspaces",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	var workspaces []*types.Workspace
+	for _, membership := range memberships {
+		workspace, err := w.store.GetWorkspaceByID(membership.WorkspaceID.String())
+		if err != nil {
+			return nil, &types.ApplicationError{
+				Message:        "Unable to get workspaces",
+				HttpStatusCode: http.StatusInternalServerError,
+				Err:            err,
+			}
+		}
+		if workspace != nil {
+			workspaces = append(workspaces, workspace)
+		}
+	}
+
+	return workspaces, nil
+}
+
+func (w *workspaceService) GetWorkspaceByID(id, user_id string) (*types.Workspace, []*types.WorkspaceMember, *types.ApplicationError) {
+	workspace, _, appErr := w.authorize(id, user_id, types.WorkspaceRoleViewer)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	members, err := w.store.GetWorkspaceMembers(id)
+	if err != nil {
+		return nil, nil, &types.ApplicationError{
+			Message:        "Unable to get workspace members",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return workspace, members, nil
+}
+
+func (w *workspaceService) Delete(id, user_id string) *types.ApplicationError {
+	workspace, _, appErr := w.authorize(id, user_id, types.WorkspaceRoleAdmin)
+	if appErr != nil {
+		return appErr
+	}
+
+	urls, err := w.store.GetURLsOfWorkspace(id)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to get workspace urls",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if len(urls) > 0 {
+		return &types.ApplicationError{
+			Message:        "Transfer or delete the workspace links before deleting it",
+			HttpStatusCode: http.StatusConflict,
+		}
+	}
+
+	err = w.store.DeleteWorkspace(workspace)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to delete workspace",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+func (w *workspaceService) ChangeMemberRole(id, user_id, member_id, role string) *types.ApplicationError {
+	if !types.IsValidWorkspaceRole(role) {
+		return &types.ApplicationError{
+			Message:        "Invalid workspace role: " + role,
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	workspace, _, appErr := w.authorize(id, user_id, types.WorkspaceRoleAdmin)
+	if appErr != nil {
+		return appErr
+	}
+
+	member, appErr := w.getMember(id, member_id)
+	if appErr != nil {
+		return appErr
+	}
+	if member.UserID == workspace.OwnerID {
+		return &types.ApplicationError{
+			Message:        "The workspace owner always stays an admin",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	member.Role = role
+	err := w.store.SaveWorkspaceMember(member)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to update workspace member",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+// RemoveMember lets admins remove anyone but the owner, and members leave on
+// their own.
+func (w *workspaceService) RemoveMember(id, user_id, member_id string) *types.ApplicationError {
+	requiredRole := types.WorkspaceRoleAdmin
+	if user_id == member_id {
+		requiredRole = types.WorkspaceRoleViewer
+	}
+	workspace, _, appErr := w.authorize(id, user_id, requiredRole)
+	if appErr != nil {
+		return appErr
+	}
+
+	member, appErr := w.getMember(id, member_id)
+	if appErr != nil {
+		return appErr
+	}
+	if member.UserID == workspace.OwnerID {
+		return &types.ApplicationError{
+			Message:        "The workspace owner cannot be removed",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	err := w.store.DeleteWorkspaceMember(member)
+	if err != nil {
+		return &types.ApplicationError{
+			Message:        "Unable to remove workspace member",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return nil
+}
+
+func (w *workspaceService) Invite(id, user_id string, invitationDto dtos.WorkspaceInvitationDTO) (*types.WorkspaceInvitation, *types.ApplicationError) {
+	email := strings.ToLower(strings.TrimSpace(invitationDto.Email))
+	if !utils.EmailValidation(email) {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid Email ID",
+			HttpStatusCode: http.StatusNotAcceptable,
+		}
+	}
+
+	role := invitationDto.Role
+	if role == "" {
+		role = types.WorkspaceRoleViewer
+	}
+	if !types.IsValidWorkspaceRole(role) {
+		return nil, &types.ApplicationError{
+			Message:        "Invalid workspace role: " + role,
+			HttpStatusCode: http.StatusBadRequest,
+		}
+	}
+
+	workspace, inviter, appErr := w.authorize(id, user_id, types.WorkspaceRoleAdmin)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	token, err := utils.GenerateRandomString(32)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to create invitation",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	invitation := &types.WorkspaceInvitation{
+		WorkspaceID: workspace.ID,
+		Email:       email,
+		Role:        role,
+		HashedToken: utils.HashToken(token),
+		Status:      "pending",
+		InvitedBy:   inviter.ID,
+		ExpiresAt:   time.Now().Add(workspaceInvitationTTL),
+	}
+	err = w.store.CreateWorkspaceInvitation(invitation)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to create invitation",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	mailer := utils.NewMailer()
+	err = mailer.SendWorkspaceInvitation(email, workspace, inviter, token)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable sent email",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return invitation, nil
+}
+
+// AcceptInvitation only works for the account owning the invited email.
+func (w *workspaceService) AcceptInvitation(token, user_id string) (*types.WorkspaceMember, *types.ApplicationError) {
+	invitation, err := w.store.GetWorkspaceInvitationByHash(utils.HashToken(strings.TrimSpace(token)))
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find invitation",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if invitation == nil || invitation.Status != "pending" || invitation.ExpiresAt.Before(time.Now()) {
+		return nil, &types.ApplicationError{
+			Message:        "Invitation is invalid or expired",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	user, err := w.store.GetUserByID(user_id)
+	if err != nil || user == nil {
+		return nil, &types.ApplicationError{
+			Message:        "User not found",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, &types.ApplicationError{
+			Message:        "This invitation was sent to a different email",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	member := &types.WorkspaceMember{WorkspaceID: invitation.WorkspaceID, UserID: user.ID, Role: invitation.Role}
+	existingMember, err := w.store.GetWorkspaceMember(invitation.WorkspaceID.String(), user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find workspace membership",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if existingMember != nil {
+		member.CreatedAt = existingMember.CreatedAt
+	}
+
+	err = w.store.SaveWorkspaceMember(member)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to join workspace",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	err = w.store.ChangeWorkspaceInvitationStatus(invitation, "accepted")
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to accept invitation",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+
+	return member, nil
+}
+
+// authorize loads the workspace and checks the acting user holds at least
+// the required role in it.
+func (w *workspaceService) authorize(id, user_id, requiredRole string) (*types.Workspace, *types.User, *types.ApplicationError) {
+	workspace, err := w.store.GetWorkspaceByID(id)
+	if err != nil {
+		return nil, nil, &types.ApplicationError{
+			Message:        "Unable to find workspace",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if workspace == nil {
+		return nil, nil, &types.ApplicationError{
+			Message:        "No workspace found with given id",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	member, appErr := w.getMember(id, user_id)
+	if appErr != nil {
+		return nil, nil, &types.ApplicationError{
+			Message:        "No workspace found with given id",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+	if !member.CanAct(requiredRole) {
+		return nil, nil, &types.ApplicationError{
+			Message:        "You need the " + requiredRole + " role in this workspace",
+			HttpStatusCode: http.StatusForbidden,
+		}
+	}
+
+	user, err := w.store.GetUserByID(user_id)
+	if err != nil || user == nil {
+		return nil, nil, &types.ApplicationError{
+			Message:        "User not found",
+			HttpStatusCode: http.StatusNotFound,
+			Err:            err,
+		}
+	}
+
+	return workspace, user, nil
+}
+
+func (w *workspaceService) getMember(id, user_id string) (*types.WorkspaceMember, *types.ApplicationError) {
+	member, err := w.store.GetWorkspaceMember(id, user_id)
+	if err != nil {
+		return nil, &types.ApplicationError{
+			Message:        "Unable to find workspace member",
+			HttpStatusCode: http.StatusInternalServerError,
+			Err:            err,
+		}
+	}
+	if member == nil {
+		return nil, &types.ApplicationError{
+			Message:        "Workspace member not found",
+			HttpStatusCode: http.StatusNotFound,
+		}
+	}
+
+	return member, nil
+}
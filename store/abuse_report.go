@@ -0,0 +1,94 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+const abuseReportColumns = "id, url_id, domain, short_url, destination, category, details, reporter_email, status, reviewed_by, review_note, reviewed_at, created_at, updated_at"
+
+func abuseReportFields(report *types.AbuseReport) []interface{} {
+	return []interface{}{&report.ID, &report.UrlID, &report.Domain, &report.ShortUrl, &report.Destination, &report.Category, &report.Details, &report.ReporterEmail, &report.Status, &report.ReviewedBy, &report.ReviewNote, &report.ReviewedAt, &report.CreatedAt, &report.UpdatedAt}
+}
+
+func (s *store) CreateAbuseReport(report *types.AbuseReport) error {
+	createReportQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".abuse_reports (id, url_id, domain, short_url, destination, category, details, reporter_email, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	report.ID, report.CreatedAt, report.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+	return s.DBSession.Query(createReportQuery, report.ID, report.UrlID, report.Domain, report.ShortUrl, report.Destination, report.Category, report.Details, report.ReporterEmail, report.Status, report.CreatedAt, report.UpdatedAt).Exec()
+}
+
+func (s *store) GetAbuseReportByID(id string) (*types.AbuseReport, error) {
+	var report types.AbuseReport
+	getReportQuery := "SELECT " + abuseReportColumns + " FROM " + CASSANDRA_KEYSPACE + ".abuse_reports WHERE id = ?"
+	reportUUID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DBSession.Query(getReportQuery, reportUUID).Consistency(gocql.One).Scan(abuseReportFields(&report)...)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// GetAbuseReports returns the reports in the given state, every report when
+// the state is empty.
+func (s *store) GetAbuseReports(status string) ([]*types.AbuseReport, error) {
+	getReportsQuery := "SELECT " + abuseReportColumns + " FROM " + CASSANDRA_KEYSPACE + ".abuse_reports"
+	var iter *gocql.Iter
+	if status != "" {
+		iter = s.DBSession.Query(getReportsQuery+" WHERE status = ? ALLOW FILTERING", status).Iter()
+	} else {
+		iter = s.DBSession.Query(getReportsQuery).Iter()
+	}
+
+	var reports []*types.AbuseReport
+	for {
+		var report types.AbuseReport
+		if !iter.Scan(abuseReportFields(&report)...) {
+			break
+		}
+		reports = append(reports, &report)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (s *store) ReviewAbuseReport(report *types.AbuseReport) error {
+	reviewReportQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".abuse_reports SET status = ?, reviewed_by = ?, review_note = ?, reviewed_at = ?, updated_at = ? WHERE id = ?"
+	report.ReviewedAt, report.UpdatedAt = time.Now(), time.Now()
+	return s.DBSession.Query(reviewReportQuery, report.Status, report.ReviewedBy, report.ReviewNote, report.ReviewedAt, report.UpdatedAt, report.ID).Exec()
+}
+
+// GetUsersByRole is used to reach the admins, it scans the users table.
+func (s *store) GetUsersByRole(role string) ([]*types.User, error) {
+	getUsersQuery := "SELECT id, name, email, mobile, verified_email, status, role, created_at, updated_at, deleted_at FROM " + CASSANDRA_KEYSPACE + ".users WHERE role = ? ALLOW FILTERING"
+	iter := s.DBSession.Query(getUsersQuery, role).Iter()
+
+	var users []*types.User
+	for {
+		var user types.User
+		if !iter.Scan(&user.ID, &user.Name, &user.Email, &user.Mobile, &user.VerifiedEmail, &user.Status, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt) {
+			break
+		}
+		if user.DeletedAt.IsZero() {
+			users = append(users, &user)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+const apiKeyColumns = "id, user_id, name, prefix, hashed_key, scopes, status, expires_at, last_used_at, created_at, updated_at, revoked_at"
+
+func scanApiKey(scanner gocql.Scanner, apiKey *types.ApiKey) bool {
+	return scanner.Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.HashedKey, &apiKey.Scopes, &apiKey.Status, &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.CreatedAt, &apiKey.UpdatedAt, &apiKey.RevokedAt) == nil
+}
+
+func (s *store) CreateApiKey(apiKey *types.ApiKey) error {
+	createApiKeyQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".api_keys (id, user_id, name, prefix, hashed_key, scopes, status, expires_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	createApiKeyLookupQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".api_keys_by_hash (hashed_key, id) VALUES (?, ?)"
+	apiKey.ID, apiKey.CreatedAt, apiKey.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+
+	batch := s.DBSession.NewBatch(gocql.LoggedBatch)
+	batch.Query(createApiKeyQuery, apiKey.ID, apiKey.UserID, apiKey.Name, apiKey.Prefix, apiKey.HashedKey, apiKey.Scopes, apiKey.Status, apiKey.ExpiresAt, apiKey.CreatedAt, apiKey.UpdatedAt)
+	batch.Query(createApiKeyLookupQuery, apiKey.HashedKey, apiKey.ID)
+	return s.DBSession.ExecuteBatch(batch)
+}
+
+func (s *store) GetApiKeyByID(id string) (*types.ApiKey, error) {
+	var apiKey types.ApiKey
+	getApiKeyQuery := "SELECT " + apiKeyColumns + " FROM " + CASSANDRA_KEYSPACE + ".api_keys WHERE id = ?"
+	err := s.DBSession.Query(getApiKeyQuery, id).Consistency(gocql.One).Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.Prefix, &apiKey.HashedKey, &apiKey.Scopes, &apiKey.Status, &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.CreatedAt, &apiKey.UpdatedAt, &apiKey.RevokedAt)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+// GetApiKeyByHash goes through the api_keys_by_hash lookup table, it runs on
+// every api key request.
+func (s *store) GetApiKeyByHash(hashedKey string) (*types.ApiKey, error) {
+	var id gocql.UUID
+	getApiKeyIDQuery := "SELECT id FROM " + CASSANDRA_KEYSPACE + ".api_keys_by_hash WHERE hashed_key = ?"
+	err := s.DBSession.Query(getApiKeyIDQuery, hashedKey).Consistency(gocql.One).Scan(&id)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	apiKey, err := s.GetApiKeyByID(id.String())
+	if err != nil || apiKey == nil || apiKey.HashedKey != hashedKey {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+func (s *store) GetApiKeysOfUser(userID string) ([]*types.ApiKey, error) {
+	var apiKeys []*types.ApiKey
+	getApiKeysQuery := "SELECT " + apiKeyColumns + " FROM " + CASSANDRA_KEYSPACE + ".api_keys WHERE user_id = ? ALLOW FILTERING"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := s.DBSession.Query(getApiKeysQuery, userUUID).Iter()
+	scanner := iter.Scanner()
+	for scanner.Next() {
+		var apiKey types.ApiKey
+		if !scanApiKey(scanner, &apiKey) {
+			break
+		}
+		apiKeys = append(apiKeys, &apiKey)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (s *store) TouchApiKey(apiKey *types.ApiKey) error {
+	touchApiKeyQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".api_keys SET last_used_at = ? WHERE id = ?"
+	apiKey.LastUsedAt = time.Now()
+	return s.DBSession.Query(touchApiKeyQuery, apiKey.LastUsedAt, apiKey.ID).Exec()
+}
+
+func (s *store) RevokeApiKey(apiKey *types.ApiKey) error {
+	revokeApiKeyQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".api_keys SET status = ?, revoked_at = ?, updated_at = ? WHERE id = ?"
+	apiKey.Status, apiKey.RevokedAt, apiKey.UpdatedAt = "revoked", time.Now(), time.Now()
+	return s.DBSession.Query(revokeApiKeyQuery, apiKey.Status, apiKey.RevokedAt, apiKey.UpdatedAt, apiKey.ID).Exec()
+}
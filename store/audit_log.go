@@ -0,0 +1,140 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+const auditLogColumns = "day, id, actor_id, action, target_type, target_id, ip, user_agent, detail, changes, created_at"
+
+const auditLogDayFormat = "2006-01-02"
+
+func auditLogFields(auditLog *types.AuditLog) []interface{} {
+	return []interface{}{&auditLog.Day, &auditLog.ID, &auditLog.ActorID, &auditLog.Action, &auditLog.TargetType, &auditLog.TargetID, &auditLog.IP, &auditLog.UserAgent, &auditLog.Detail, &auditLog.Changes, &auditLog.CreatedAt}
+}
+
+// CreateAuditLog appends the entry to the partition of today, entries are
+// never deleted and only updated to pseudonymize an erased user.
+func (s *store) CreateAuditLog(auditLog *types.AuditLog) error {
+	createAuditLogQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".audit_logs (" + auditLogColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	auditLog.ID, auditLog.CreatedAt = gocql.TimeUUID(), time.Now()
+	auditLog.Day = auditLog.CreatedAt.UTC().Format(auditLogDayFormat)
+	return s.DBSession.Query(createAuditLogQuery, auditLog.Day, auditLog.ID, auditLog.ActorID, auditLog.Action, auditLog.TargetType, auditLog.TargetID, auditLog.IP, auditLog.UserAgent, auditLog.Detail, auditLog.Changes, auditLog.CreatedAt).Exec()
+}
+
+// GetAuditLogs reads the daily partitions of the period from the newest
+// until the limit is reached. The actor and target are filtered within a
+// partition.
+func (s *store) GetAuditLogs(filter types.AuditLogFilter) ([]*types.AuditLog, error) {
+	getAuditLogsQuery := "SELECT " + auditLogColumns + " FROM " + CASSANDRA_KEYSPACE + ".audit_logs WHERE day = ? AND id >= minTimeuuid(?) AND id <= maxTimeuuid(?)"
+	values := []interface{}{nil, filter.From, filter.To}
+	if filter.ActorID != "" {
+		actorUUID, err := gocql.ParseUUID(filter.ActorID)
+		if err != nil {
+			return nil, err
+		}
+		getAuditLogsQuery += " AND actor_id = ?"
+		values = append(values, actorUUID)
+	}
+	if filter.TargetID != "" {
+		getAuditLogsQuery += " AND target_id = ?"
+		values = append(values, filter.TargetID)
+	}
+	getAuditLogsQuery += " LIMIT ? ALLOW FILTERING"
+
+	auditLogs := []*types.AuditLog{}
+	from := filter.From.UTC().Format(auditLogDayFormat)
+	for day := filter.To.UTC(); day.Format(auditLogDayFormat) >= from && len(auditLogs) < filter.Limit; day = day.AddDate(0, 0, -1) {
+		values[0] = day.Format(auditLogDayFormat)
+		iter := s.DBSession.Query(getAuditLogsQuery, append(values, filter.Limit-len(auditLogs))...).Iter()
+		for {
+			var auditLog types.AuditLog
+			if !iter.Scan(auditLogFields(&auditLog)...) {
+				break
+			}
+			auditLogs = append(auditLogs, &auditLog)
+		}
+
+		if err := iter.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return auditLogs, nil
+}
+
+// PseudonymizeAuditLogs puts a random id in place of an erased user. The
+// entries stay, what was done to the service has to outlive the account,
+// but none of them points at the person anymore: their ip, user agent and
+// the recorded values of their account go as well.
+func (s *store) PseudonymizeAuditLogs(user *types.User) error {
+	getAuditLogsQuery := "SELECT " + auditLogColumns + " FROM " + CASSANDRA_KEYSPACE + ".audit_logs WHERE "
+	queries := []*gocql.Query{
+		s.DBSession.Query(getAuditLogsQuery+"actor_id = ? ALLOW FILTERING", user.ID),
+		s.DBSession.Query(getAuditLogsQuery+"target_id = ? ALLOW FILTERING", user.ID.String()),
+	}
+	if user.Email != "" {
+		queries = append(queries, s.DBSession.Query(getAuditLogsQuery+"target_id = ? ALLOW FILTERING", user.Email))
+	}
+
+	auditLogs := map[gocql.UUID]*types.AuditLog{}
+	for _, query := range queries {
+		iter := query.Iter()
+		for {
+			var auditLog types.AuditLog
+			if !iter.Scan(auditLogFields(&auditLog)...) {
+				break
+			}
+			auditLogs[auditLog.ID] = &auditLog
+		}
+
+		if err := iter.Close(); err != nil {
+			return err
+		}
+	}
+
+	updateAuditLogQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".audit_logs SET actor_id = ?, target_id = ?, ip = ?, user_agent = ?, changes = ? WHERE day = ? AND id = ?"
+	pseudonym := gocql.MustRandomUUID()
+	for _, auditLog := range auditLogs {
+		pseudonymizeAuditLog(auditLog, user, pseudonym)
+		err := s.DBSession.Query(updateAuditLogQuery, auditLog.ActorID, auditLog.TargetID, auditLog.IP, auditLog.UserAgent, auditLog.Changes, auditLog.Day, auditLog.ID).Exec()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pseudonymizeAuditLog replaces the user in the entry. The ip and user agent
+// are theirs when they acted or when nobody was logged in, a failed login
+// for their email, otherwise they belong to the admin who acted on them.
+func pseudonymizeAuditLog(auditLog *types.AuditLog, user *types.User, pseudonym gocql.UUID) {
+	isUser := func(value interface{}) bool {
+		return value == user.ID.String() || (user.Email != "" && value == user.Email)
+	}
+
+	if auditLog.ActorID == user.ID || auditLog.ActorID == (gocql.UUID{}) {
+		auditLog.IP, auditLog.UserAgent = "", ""
+	}
+	if auditLog.ActorID == user.ID {
+		auditLog.ActorID = pseudonym
+	}
+	if isUser(auditLog.TargetID) {
+		auditLog.TargetID = pseudonym.String()
+		if auditLog.TargetType == types.AuditTargetUser {
+			auditLog.Changes = nil
+		}
+	}
+
+	for field, change := range auditLog.Changes {
+		if isUser(change.Before) {
+			change.Before = pseudonym.String()
+		}
+		if isUser(change.After) {
+			change.After = pseudonym.String()
+		}
+		auditLog.Changes[field] = change
+	}
+}
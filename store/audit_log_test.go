@@ -0,0 +1,47 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+func TestPseudonymizeAuditLog(t *testing.T) {
+	user := &types.User{ID: gocql.MustRandomUUID(), Email: "erased@example.com"}
+	admin := gocql.MustRandomUUID()
+	pseudonym := gocql.MustRandomUUID()
+
+	tests := []struct {
+		name     string
+		auditLog types.AuditLog
+		want     types.AuditLog
+	}{
+		{
+			name:     "user acted",
+			auditLog: types.AuditLog{ActorID: user.ID, Action: types.AuditUrlCreated, TargetType: types.AuditTargetUrl, TargetID: "url-1", IP: "203.0.113.7", UserAgent: "Firefox", Changes: types.AuditChanges{"user_id": {After: user.ID.String()}, "long_url": {After: "https://example.com"}}},
+			want:     types.AuditLog{ActorID: pseudonym, Action: types.AuditUrlCreated, TargetType: types.AuditTargetUrl, TargetID: "url-1", Changes: types.AuditChanges{"user_id": {After: pseudonym.String()}, "long_url": {After: "https://example.com"}}},
+		},
+		{
+			name:     "admin acted on the user",
+			auditLog: types.AuditLog{ActorID: admin, Action: types.AuditUserSuspended, TargetType: types.AuditTargetUser, TargetID: user.ID.String(), IP: "198.51.100.1", UserAgent: "curl", Changes: types.AuditChanges{"status": {Before: "active", After: "suspended"}}},
+			want:     types.AuditLog{ActorID: admin, Action: types.AuditUserSuspended, TargetType: types.AuditTargetUser, TargetID: pseudonym.String(), IP: "198.51.100.1", UserAgent: "curl"},
+		},
+		{
+			name:     "failed login for the email",
+			auditLog: types.AuditLog{Action: types.AuditLoginFailed, TargetType: types.AuditTargetEmail, TargetID: user.Email, IP: "203.0.113.7", UserAgent: "Firefox", Detail: "wrong password"},
+			want:     types.AuditLog{Action: types.AuditLoginFailed, TargetType: types.AuditTargetEmail, TargetID: pseudonym.String(), Detail: "wrong password"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditLog := tt.auditLog
+			pseudonymizeAuditLog(&auditLog, user, pseudonym)
+			if !reflect.DeepEqual(auditLog, tt.want) {
+				t.Errorf("pseudonymized entry = %+v, want %+v", auditLog, tt.want)
+			}
+		})
+	}
+}
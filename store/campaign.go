@@ -0,0 +1,129 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+const campaignColumns = "id, name, user_id, workspace_id, starts_at, ends_at, budget_note, goals, created_at, updated_at, deleted_at"
+
+func campaignFields(campaign *types.Campaign) []interface{} {
+	return []interface{}{&campaign.ID, &campaign.Name, &campaign.UserID, &campaign.WorkspaceID, &campaign.StartsAt, &campaign.EndsAt, &campaign.BudgetNote, &campaign.Goals, &campaign.CreatedAt, &campaign.UpdatedAt, &campaign.DeletedAt}
+}
+
+func (s *store) CreateCampaign(campaign *types.Campaign) error {
+	createCampaignQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".campaigns (id, name, user_id, workspace_id, starts_at, ends_at, budget_note, goals, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	campaign.ID, campaign.CreatedAt, campaign.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+	return s.DBSession.Query(createCampaignQuery, campaign.ID, campaign.Name, campaign.UserID, campaign.WorkspaceID, campaign.StartsAt, campaign.EndsAt, campaign.BudgetNote, campaign.Goals, campaign.CreatedAt, campaign.UpdatedAt).Exec()
+}
+
+func (s *store) GetCampaignByID(id string) (*types.Campaign, error) {
+	var campaign types.Campaign
+	getCampaignQuery := "SELECT " + campaignColumns + " FROM " + CASSANDRA_KEYSPACE + ".campaigns WHERE id = ?"
+	campaignUUID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DBSession.Query(getCampaignQuery, campaignUUID).Consistency(gocql.One).Scan(campaignFields(&campaign)...)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !campaign.DeletedAt.IsZero() {
+		return nil, nil
+	}
+
+	return &campaign, nil
+}
+
+func (s *store) GetCampaignsOfUser(userID string) ([]*types.Campaign, error) {
+	getCampaignsQuery := "SELECT " + campaignColumns + " FROM " + CASSANDRA_KEYSPACE + ".campaigns WHERE user_id = ? ALLOW FILTERING"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	campaigns, err := s.scanCampaigns(s.DBSession.Query(getCampaignsQuery, userUUID).Iter())
+	if err != nil {
+		return nil, err
+	}
+
+	var personalCampaigns []*types.Campaign
+	for _, campaign := range campaigns {
+		if campaign.WorkspaceID == (gocql.UUID{}) {
+			personalCampaigns = append(personalCampaigns, campaign)
+		}
+	}
+	return personalCampaigns, nil
+}
+
+func (s *store) GetCampaignsOfWorkspace(workspaceID string) ([]*types.Campaign, error) {
+	getCampaignsQuery := "SELECT " + campaignColumns + " FROM " + CASSANDRA_KEYSPACE + ".campaigns WHERE workspace_id = ? ALLOW FILTERING"
+	workspaceUUID, err := gocql.ParseUUID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanCampaigns(s.DBSession.Query(getCampaignsQuery, workspaceUUID).Iter())
+}
+
+func (s *store) scanCampaigns(iter *gocql.Iter) ([]*types.Campaign, error) {
+	var campaigns []*types.Campaign
+	for {
+		var campaign types.Campaign
+		if !iter.Scan(campaignFields(&campaign)...) {
+			break
+		}
+		if campaign.DeletedAt.IsZero() {
+			campaigns = append(campaigns, &campaign)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+func (s *store) UpdateCampaign(campaign *types.Campaign) error {
+	updateCampaignQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".campaigns SET name = ?, starts_at = ?, ends_at = ?, budget_note = ?, goals = ?, updated_at = ? WHERE id = ?"
+	campaign.UpdatedAt = time.Now()
+	return s.DBSession.Query(updateCampaignQuery, campaign.Name, campaign.StartsAt, campaign.EndsAt, campaign.BudgetNote, campaign.Goals, campaign.UpdatedAt, campaign.ID).Exec()
+}
+
+func (s *store) DeleteCampaign(campaign *types.Campaign) error {
+	deleteCampaignQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".campaigns SET deleted_at = ? WHERE id = ?"
+	return s.DBSession.Query(deleteCampaignQuery, time.Now(), campaign.ID).Exec()
+}
+
+func (s *store) AddCampaignUrl(campaignID, urlID gocql.UUID) error {
+	addCampaignUrlQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".campaign_urls (campaign_id, url_id, created_at) VALUES (?, ?, ?)"
+	return s.DBSession.Query(addCampaignUrlQuery, campaignID, urlID, time.Now()).Exec()
+}
+
+func (s *store) RemoveCampaignUrl(campaignID, urlID gocql.UUID) error {
+	removeCampaignUrlQuery := "DELETE FROM " + CASSANDRA_KEYSPACE + ".campaign_urls WHERE campaign_id = ? AND url_id = ?"
+	return s.DBSession.Query(removeCampaignUrlQuery, campaignID, urlID).Exec()
+}
+
+func (s *store) GetCampaignUrlIDs(campaignID gocql.UUID) ([]gocql.UUID, error) {
+	getCampaignUrlsQuery := "SELECT url_id FROM " + CASSANDRA_KEYSPACE + ".campaign_urls WHERE campaign_id = ?"
+	iter := s.DBSession.Query(getCampaignUrlsQuery, campaignID).Iter()
+
+	var urlIDs []gocql.UUID
+	var urlID gocql.UUID
+	for iter.Scan(&urlID) {
+		urlIDs = append(urlIDs, urlID)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return urlIDs, nil
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+	"urllite/utils"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	clickRollupDayFormat = "2006-01-02"
+	// Logs stored before rollups existed are rolled up by the daily purge
+	// job, reads only look for them in this recent window.
+	clickRollupPendingWindow = 48 * time.Hour
+)
+
+func (s *store) incrementClickRollup(log *types.UrlLog) error {
+	incrementRollupQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".url_click_rollups SET clicks = clicks + 1 WHERE url_id = ? AND day = ? AND traffic_class = ? AND variant = ? AND country = ? AND channel = ?"
+	return s.DBSession.Query(incrementRollupQuery, log.UrlID, log.VisitedAt.UTC().Format(clickRollupDayFormat), log.TrafficClass, log.Variant, log.Country, utils.ReferrerChannel(log.Referrer)).Exec()
+}
+
+// GetClickRollups returns the daily click counts of the link. Recent logs
+// stored before rollups existed are added until the purge job has rolled
+// them up, older ones are counted once it has.
+func (s *store) GetClickRollups(urlID string) ([]*types.ClickRollup, error) {
+	urlUUID, err := gocql.ParseUUID(urlID)
+	if err != nil {
+		return nil, err
+	}
+
+	getRollupsQuery := "SELECT day, traffic_class, variant, country, channel, clicks FROM " + CASSANDRA_KEYSPACE + ".url_click_rollups WHERE url_id = ?"
+	iter := s.DBSession.Query(getRollupsQuery, urlUUID).Iter()
+
+	rollups := map[types.ClickRollup]*types.ClickRollup{}
+	var rollup types.ClickRollup
+	for iter.Scan(&rollup.Day, &rollup.TrafficClass, &rollup.Variant, &rollup.Country, &rollup.Channel, &rollup.Clicks) {
+		rollups[clickRollupKey(rollup)] = &types.ClickRollup{UrlID: urlUUID, Day: rollup.Day, TrafficClass: rollup.TrafficClass, Variant: rollup.Variant, Country: rollup.Country, Channel: rollup.Channel, Clicks: rollup.Clicks}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	pending, err := s.pendingUrlLogs(urlUUID, time.Now().Add(-clickRollupPendingWindow))
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range pending {
+		if !log.DeletedAt.IsZero() {
+			continue
+		}
+		key := clickRollupKey(logRollup(log))
+		if rollups[key] == nil {
+			rollup := logRollup(log)
+			rollups[key] = &rollup
+		}
+		rollups[key].Clicks++
+	}
+
+	var result []*types.ClickRollup
+	for _, rollup := range rollups {
+		result = append(result, rollup)
+	}
+	return result, nil
+}
+
+// clickRollupKey identifies the rollup row, it leaves out the count.
+func clickRollupKey(rollup types.ClickRollup) types.ClickRollup {
+	rollup.Clicks = 0
+	return rollup
+}
+
+func logRollup(log *types.UrlLog) types.ClickRollup {
+	return types.ClickRollup{UrlID: log.UrlID, Day: log.VisitedAt.UTC().Format(clickRollupDayFormat), TrafficClass: log.TrafficClass, Variant: log.Variant, Country: log.Country, Channel: utils.ReferrerChannel(log.Referrer)}
+}
+
+// pendingUrlLogs returns the logs of the link stored since the given time
+// that are not counted in the rollups yet, a zero time reads them all.
+func (s *store) pendingUrlLogs(urlID gocql.UUID, since time.Time) ([]*types.UrlLog, error) {
+	getLogsQuery := "SELECT " + urlLogColumns + " FROM " + CASSANDRA_KEYSPACE + ".url_logs WHERE url_id = ?"
+	var iter *gocql.Iter
+	if since.IsZero() {
+		iter = s.DBSession.Query(getLogsQuery, urlID).Iter()
+	} else {
+		iter = s.DBSession.Query(getLogsQuery+" AND created_at >= ?", urlID, since).Iter()
+	}
+
+	var logs []*types.UrlLog
+	for {
+		var log types.UrlLog
+		if !iter.Scan(urlLogFields(&log)...) {
+			break
+		}
+		if !log.RolledUp {
+			logs = append(logs, &log)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// RollUpUrlLogs counts the logs stored before rollups existed into the
+// rollups so they are not lost when the logs are purged.
+func (s *store) RollUpUrlLogs(urlID gocql.UUID) error {
+	logs, err := s.pendingUrlLogs(urlID, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	markRolledUpQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".url_logs SET rolled_up = ? WHERE url_id = ? AND created_at = ? AND id = ?"
+	for _, log := range logs {
+		if log.DeletedAt.IsZero() {
+			if err := s.incrementClickRollup(log); err != nil {
+				return err
+			}
+		}
+		if err := s.DBSession.Query(markRolledUpQuery, true, log.UrlID, log.CreatedAt, log.ID).Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeUrlLogs deletes the raw logs of the link stored before the given time.
+func (s *store) PurgeUrlLogs(urlID gocql.UUID, before time.Time) error {
+	purgeLogsQuery := "DELETE FROM " + CASSANDRA_KEYSPACE + ".url_logs WHERE url_id = ? AND created_at < ?"
+	return s.DBSession.Query(purgeLogsQuery, urlID, before).Exec()
+}
+
+// GetAllURLs returns every link, deleted ones included, for maintenance jobs.
+func (s *store) GetAllURLs() ([]*types.URL, error) {
+	getURLsQuery := "SELECT " + urlColumns + " FROM " + CASSANDRA_KEYSPACE + ".urls"
+	iter := s.DBSession.Query(getURLsQuery).Iter()
+
+	var urls []*types.URL
+	for {
+		var url types.URL
+		if !iter.Scan(urlFields(&url)...) {
+			break
+		}
+		urls = append(urls, &url)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+const domainColumns = "id, hostname, user_id, workspace_id, verification_token, status, verified_at, apple_app_ids, android_package_name, android_cert_fingerprints, created_at, updated_at, deleted_at"
+
+func domainFields(domain *types.Domain) []interface{} {
+	return []interface{}{&domain.ID, &domain.Hostname, &domain.UserID, &domain.WorkspaceID, &domain.VerificationToken, &domain.Status, &domain.VerifiedAt, &domain.AppleAppIDs, &domain.AndroidPackageName, &domain.AndroidCertFingerprints, &domain.CreatedAt, &domain.UpdatedAt, &domain.DeletedAt}
+}
+
+func (s *store) CreateDomain(domain *types.Domain) error {
+	createDomainQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".domains (id, hostname, user_id, workspace_id, verification_token, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	domain.ID, domain.CreatedAt, domain.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+	return s.DBSession.Query(createDomainQuery, domain.ID, domain.Hostname, domain.UserID, domain.WorkspaceID, domain.VerificationToken, domain.Status, domain.CreatedAt, domain.UpdatedAt).Exec()
+}
+
+func (s *store) GetDomainByID(id string) (*types.Domain, error) {
+	var domain types.Domain
+	getDomainQuery := "SELECT " + domainColumns + " FROM " + CASSANDRA_KEYSPACE + ".domains WHERE id = ?"
+	domainUUID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DBSession.Query(getDomainQuery, domainUUID).Consistency(gocql.One).Scan(domainFields(&domain)...)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !domain.DeletedAt.IsZero() {
+		return nil, nil
+	}
+
+	return &domain, nil
+}
+
+func (s *store) GetVerifiedDomainByHostname(hostname string) (*types.Domain, error) {
+	getDomainQuery := "SELECT " + domainColumns + " FROM " + CASSANDRA_KEYSPACE + ".domains WHERE hostname = ? ALLOW FILTERING"
+	domains, err := s.scanDomains(s.DBSession.Query(getDomainQuery, hostname).Iter())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, domain := range domains {
+		if domain.IsVerified() {
+			return domain, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (s *store) GetDomainsOfUser(userID string) ([]*types.Domain, error) {
+	getDomainsQuery := "SELECT " + domainColumns + " FROM " + CASSANDRA_KEYSPACE + ".domains WHERE user_id = ? ALLOW FILTERING"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanDomains(s.DBSession.Query(getDomainsQuery, userUUID).Iter())
+}
+
+func (s *store) GetDomainsOfWorkspace(workspaceID string) ([]*types.Domain, error) {
+	getDomainsQuery := "SELECT " + domainColumns + " FROM " + CASSANDRA_KEYSPACE + ".domains WHERE workspace_id = ? ALLOW FILTERING"
+	workspaceUUID, err := gocql.ParseUUID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanDomains(s.DBSession.Query(getDomainsQuery, workspaceUUID).Iter())
+}
+
+func (s *store) scanDomains(iter *gocql.Iter) ([]*types.Domain, error) {
+	var domains []*types.Domain
+	for {
+		var domain types.Domain
+		if !iter.Scan(domainFields(&domain)...) {
+			break
+		}
+		if domain.DeletedAt.IsZero() {
+			domains = append(domains, &domain)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return domains, nil
+}
+
+func (s *store) MarkDomainVerified(domain *types.Domain) error {
+	verifyDomainQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".domains SET status = ?, verified_at = ?, updated_at = ? WHERE id = ?"
+	domain.Status, domain.VerifiedAt, domain.UpdatedAt = "verified", time.Now(), time.Now()
+	return s.DBSession.Query(verifyDomainQuery, domain.Status, domain.VerifiedAt, domain.UpdatedAt, domain.ID).Exec()
+}
+
+func (s *store) UpdateDomainApps(domain *types.Domain) error {
+	updateAppsQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".domains SET apple_app_ids = ?, android_package_name = ?, android_cert_fingerprints = ?, updated_at = ? WHERE id = ?"
+	domain.UpdatedAt = time.Now()
+	return s.DBSession.Query(updateAppsQuery, domain.AppleAppIDs, domain.AndroidPackageName, domain.AndroidCertFingerprints, domain.UpdatedAt, domain.ID).Exec()
+}
+
+func (s *store) DeleteDomain(domain *types.Domain) error {
+	deleteDomainQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".domains SET deleted_at = ? WHERE id = ?"
+	return s.DBSession.Query(deleteDomainQuery, time.Now(), domain.ID).Exec()
+}
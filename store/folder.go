@@ -0,0 +1,102 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+const folderColumns = "id, name, user_id, workspace_id, created_at, updated_at, deleted_at"
+
+func folderFields(folder *types.Folder) []interface{} {
+	return []interface{}{&folder.ID, &folder.Name, &folder.UserID, &folder.WorkspaceID, &folder.CreatedAt, &folder.UpdatedAt, &folder.DeletedAt}
+}
+
+func (s *store) CreateFolder(folder *types.Folder) error {
+	createFolderQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".folders (id, name, user_id, workspace_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
+	folder.ID, folder.CreatedAt, folder.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+	return s.DBSession.Query(createFolderQuery, folder.ID, folder.Name, folder.UserID, folder.WorkspaceID, folder.CreatedAt, folder.UpdatedAt).Exec()
+}
+
+func (s *store) GetFolderByID(id string) (*types.Folder, error) {
+	var folder types.Folder
+	getFolderQuery := "SELECT " + folderColumns + " FROM " + CASSANDRA_KEYSPACE + ".folders WHERE id = ?"
+	folderUUID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DBSession.Query(getFolderQuery, folderUUID).Consistency(gocql.One).Scan(folderFields(&folder)...)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !folder.DeletedAt.IsZero() {
+		return nil, nil
+	}
+
+	return &folder, nil
+}
+
+func (s *store) GetFoldersOfUser(userID string) ([]*types.Folder, error) {
+	getFoldersQuery := "SELECT " + folderColumns + " FROM " + CASSANDRA_KEYSPACE + ".folders WHERE user_id = ? ALLOW FILTERING"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	folders, err := s.scanFolders(s.DBSession.Query(getFoldersQuery, userUUID).Iter())
+	if err != nil {
+		return nil, err
+	}
+
+	var personalFolders []*types.Folder
+	for _, folder := range folders {
+		if folder.WorkspaceID == (gocql.UUID{}) {
+			personalFolders = append(personalFolders, folder)
+		}
+	}
+	return personalFolders, nil
+}
+
+func (s *store) GetFoldersOfWorkspace(workspaceID string) ([]*types.Folder, error) {
+	getFoldersQuery := "SELECT " + folderColumns + " FROM " + CASSANDRA_KEYSPACE + ".folders WHERE workspace_id = ? ALLOW FILTERING"
+	workspaceUUID, err := gocql.ParseUUID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanFolders(s.DBSession.Query(getFoldersQuery, workspaceUUID).Iter())
+}
+
+func (s *store) scanFolders(iter *gocql.Iter) ([]*types.Folder, error) {
+	var folders []*types.Folder
+	for {
+		var folder types.Folder
+		if !iter.Scan(folderFields(&folder)...) {
+			break
+		}
+		if folder.DeletedAt.IsZero() {
+			folders = append(folders, &folder)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
+func (s *store) RenameFolder(folder *types.Folder) error {
+	renameFolderQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".folders SET name = ?, updated_at = ? WHERE id = ?"
+	folder.UpdatedAt = time.Now()
+	return s.DBSession.Query(renameFolderQuery, folder.Name, folder.UpdatedAt, folder.ID).Exec()
+}
+
+func (s *store) DeleteFolder(folder *types.Folder) error {
+	deleteFolderQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".folders SET deleted_at = ? WHERE id = ?"
+	return s.DBSession.Query(deleteFolderQuery, time.Now(), folder.ID).Exec()
+}
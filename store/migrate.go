@@ -2,7 +2,12 @@ package store
 
 import (
 	"log"
+	"os"
+	"time"
 	"urllite/config/database"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
 )
 
 func AutoMigrateTables() {
@@ -11,6 +16,39 @@ func AutoMigrateTables() {
 	migrateUrlTable()
 	migrateUrlLogTable()
 	migrateOtpTable()
+	migrateApiKeyTable()
+	migrateOAuthIdentityTable()
+	migrateTwoFactorTable()
+	migrateRoleChangeTable()
+	migrateWorkspaceTables()
+	migrateDomainTable()
+	migrateFolderTable()
+	migrateCampaignTables()
+	migrateWebhookTables()
+	migratePrivacySettingsTable()
+	migrateAbuseReportTable()
+	migrateSystemStatsTable()
+	migrateAuditLogTable()
+}
+
+// addColumnIfMissing brings tables created by an older release up to date,
+// CREATE TABLE IF NOT EXISTS leaves their columns untouched. It reports
+// whether the column was added.
+func addColumnIfMissing(session *gocql.Session, table, column, columnType string) bool {
+	var existingColumn string
+	findColumnQuery := "SELECT column_name FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ? AND column_name = ?"
+	err := session.Query(findColumnQuery, os.Getenv("CASSANDRA_URLLITE_KEYSPACE"), table, column).Scan(&existingColumn)
+	if err == nil {
+		return false
+	}
+	if err != gocql.ErrNotFound {
+		log.Fatal("Unable to read "+table+" schema:", err.Error())
+	}
+
+	if err := session.Query("ALTER TABLE " + table + " ADD " + column + " " + columnType).Exec(); err != nil {
+		log.Fatal("Unable to add "+column+" to "+table+":", err.Error())
+	}
+	return true
 }
 
 func migrateUserTable() {
@@ -68,7 +106,23 @@ func migrateUrlTable() {
 	CREATE TABLE IF NOT EXISTS urls (
 		id UUID PRIMARY KEY,
 		user_id UUID,
+		workspace_id UUID,
+		domain TEXT,
+		domain_id UUID,
 		long_url TEXT,
+		routing_rules TEXT,
+		variants TEXT,
+		deep_link TEXT,
+		utm_params MAP<TEXT, TEXT>,
+		forward_query BOOLEAN,
+		query_merge TEXT,
+		redirect_type TEXT,
+		interstitial_delay INT,
+		leaving_warning BOOLEAN,
+		no_index BOOLEAN,
+		tags SET<TEXT>,
+		folder_id UUID,
+		notes TEXT,
 		short_url TEXT,
 		status TEXT,
 		created_at TIMESTAMP,
@@ -84,6 +138,65 @@ func migrateUrlTable() {
 	if err := session.Query(createUrlTable).Exec(); err != nil {
 		log.Fatal("Unable to create url table:", err.Error())
 	}
+	addColumnIfMissing(session, "urls", "workspace_id", "UUID")
+	addColumnIfMissing(session, "urls", "domain", "TEXT")
+	addColumnIfMissing(session, "urls", "routing_rules", "TEXT")
+	addColumnIfMissing(session, "urls", "variants", "TEXT")
+	addColumnIfMissing(session, "urls", "deep_link", "TEXT")
+	addColumnIfMissing(session, "urls", "utm_params", "MAP<TEXT, TEXT>")
+	addColumnIfMissing(session, "urls", "forward_query", "BOOLEAN")
+	addColumnIfMissing(session, "urls", "query_merge", "TEXT")
+	addColumnIfMissing(session, "urls", "redirect_type", "TEXT")
+	addColumnIfMissing(session, "urls", "interstitial_delay", "INT")
+	addColumnIfMissing(session, "urls", "leaving_warning", "BOOLEAN")
+	addColumnIfMissing(session, "urls", "no_index", "BOOLEAN")
+	addColumnIfMissing(session, "urls", "tags", "SET<TEXT>")
+	addColumnIfMissing(session, "urls", "folder_id", "UUID")
+	addColumnIfMissing(session, "urls", "notes", "TEXT")
+	if addColumnIfMissing(session, "urls", "domain_id", "UUID") {
+		backfillUrlDomainIDs(session)
+	}
+}
+
+// backfillUrlDomainIDs ties the links stored before domain ids to the
+// registration currently verified for their hostname, links of a hostname
+// nobody holds any more stay unresolvable.
+func backfillUrlDomainIDs(session *gocql.Session) {
+	domainIDs := map[string]gocql.UUID{}
+	var urlID gocql.UUID
+	var hostname string
+	iter := session.Query("SELECT id, domain FROM urls").Iter()
+	for iter.Scan(&urlID, &hostname) {
+		if hostname == "" {
+			continue
+		}
+
+		domainID, found := domainIDs[hostname]
+		if !found {
+			var id gocql.UUID
+			var status string
+			var deletedAt time.Time
+			domainIter := session.Query("SELECT id, status, deleted_at FROM domains WHERE hostname = ? ALLOW FILTERING", hostname).Iter()
+			for domainIter.Scan(&id, &status, &deletedAt) {
+				if status == "verified" && deletedAt.IsZero() {
+					domainID = id
+				}
+			}
+			if err := domainIter.Close(); err != nil {
+				log.Fatal("Unable to read domains:", err.Error())
+			}
+			domainIDs[hostname] = domainID
+		}
+
+		if domainID != (gocql.UUID{}) {
+			if err := session.Query("UPDATE urls SET domain_id = ? WHERE id = ?", domainID, urlID).Exec(); err != nil {
+				log.Fatal("Unable to backfill url domains:", err.Error())
+			}
+		}
+	}
+	if err := iter.Close(); err != nil {
+		log.Fatal("Unable to read urls:", err.Error())
+	}
 }
 
 func migrateUrlLogTable() {
@@ -98,6 +211,14 @@ func migrateUrlLogTable() {
 	client_ip TEXT,
 	city TEXT,
 	country TEXT,
+	destination TEXT,
+	matched_rule TEXT,
+	variant TEXT,
+	referrer TEXT,
+	user_agent TEXT,
+	traffic_class TEXT,
+	do_not_track BOOLEAN,
+	rolled_up BOOLEAN,
 	created_at TIMESTAMP,
 	updated_at TIMESTAMP,
 	deleted_at TIMESTAMP,
@@ -112,6 +233,30 @@ func migrateUrlLogTable() {
 	if err := session.Query(createUrlLogTable).Exec(); err != nil {
 		log.Fatal("Unable to create url table:", err.Error())
 	}
+	addColumnIfMissing(session, "url_logs", "destination", "TEXT")
+	addColumnIfMissing(session, "url_logs", "matched_rule", "TEXT")
+	addColumnIfMissing(session, "url_logs", "variant", "TEXT")
+	addColumnIfMissing(session, "url_logs", "referrer", "TEXT")
+	addColumnIfMissing(session, "url_logs", "user_agent", "TEXT")
+	addColumnIfMissing(session, "url_logs", "traffic_class", "TEXT")
+	addColumnIfMissing(session, "url_logs", "do_not_track", "BOOLEAN")
+	addColumnIfMissing(session, "url_logs", "rolled_up", "BOOLEAN")
+
+	// Daily click counts kept after the raw logs expire.
+	createClickRollupTable := `
+	CREATE TABLE IF NOT EXISTS url_click_rollups (
+	url_id UUID,
+	day TEXT,
+	traffic_class TEXT,
+	variant TEXT,
+	country TEXT,
+	channel TEXT,
+	clicks COUNTER,
+	PRIMARY KEY ((url_id), day, traffic_class, variant, country, channel)
+	);`
+	if err := session.Query(createClickRollupTable).Exec(); err != nil {
+		log.Fatal("Unable to create click rollup table:", err.Error())
+	}
 }
 
 func migrateOtpTable() {
@@ -135,3 +280,503 @@ func migrateOtpTable() {
 		log.Fatal("Unable to create url table:", err.Error())
 	}
 }
+
+func migrateApiKeyTable() {
+	createApiKeyTable := `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id UUID PRIMARY KEY,
+		user_id UUID,
+		name TEXT,
+		prefix TEXT,
+		hashed_key TEXT,
+		scopes LIST<TEXT>,
+		status TEXT,
+		expires_at TIMESTAMP,
+		last_used_at TIMESTAMP,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		revoked_at TIMESTAMP
+	);`
+
+	createApiKeyLookupTable := `
+	CREATE TABLE IF NOT EXISTS api_keys_by_hash (
+		hashed_key TEXT PRIMARY KEY,
+		id UUID
+	);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createApiKeyTable).Exec(); err != nil {
+		log.Fatal("Unable to create api key table:", err.Error())
+	}
+	if err := session.Query(createApiKeyLookupTable).Exec(); err != nil {
+		log.Fatal("Unable to create api key lookup table:", err.Error())
+	}
+
+	// Keys created before the lookup table existed, inserting again is a no-op.
+	var id gocql.UUID
+	var hashedKey string
+	iter := session.Query("SELECT id, hashed_key FROM api_keys").Iter()
+	for iter.Scan(&id, &hashedKey) {
+		if err := session.Query("INSERT INTO api_keys_by_hash (hashed_key, id) VALUES (?, ?)", hashedKey, id).Exec(); err != nil {
+			log.Fatal("Unable to fill api key lookup table:", err.Error())
+		}
+	}
+	if err := iter.Close(); err != nil {
+		log.Fatal("Unable to fill api key lookup table:", err.Error())
+	}
+}
+
+func migrateOAuthIdentityTable() {
+	createOAuthIdentityTable := `
+	CREATE TABLE IF NOT EXISTS oauth_identities (
+		provider TEXT,
+		subject TEXT,
+		id UUID,
+		user_id UUID,
+		email TEXT,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		PRIMARY KEY ((provider), subject)
+	);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createOAuthIdentityTable).Exec(); err != nil {
+		log.Fatal("Unable to create oauth identity table:", err.Error())
+	}
+}
+
+func migrateTwoFactorTable() {
+	createTwoFactorTable := `
+	CREATE TABLE IF NOT EXISTS two_factors (
+		user_id UUID PRIMARY KEY,
+		secret TEXT,
+		status TEXT,
+		recovery_codes LIST<TEXT>,
+		last_used_step BIGINT,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		enabled_at TIMESTAMP
+	);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createTwoFactorTable).Exec(); err != nil {
+		log.Fatal("Unable to create two factor table:", err.Error())
+	}
+}
+
+func migrateRoleChangeTable() {
+	createRoleChangeTable := `
+	CREATE TABLE IF NOT EXISTS role_changes (
+		user_id UUID,
+		id TIMEUUID,
+		actor_id UUID,
+		previous_role TEXT,
+		new_role TEXT,
+		created_at TIMESTAMP,
+		PRIMARY KEY ((user_id), id)
+	) WITH CLUSTERING ORDER BY (id DESC);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createRoleChangeTable).Exec(); err != nil {
+		log.Fatal("Unable to create role change table:", err.Error())
+	}
+}
+
+func migrateWorkspaceTables() {
+	createWorkspaceTable := `
+	CREATE TABLE IF NOT EXISTS workspaces (
+		id UUID PRIMARY KEY,
+		name TEXT,
+		owner_id UUID,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		deleted_at TIMESTAMP
+	);`
+
+	createWorkspaceMemberTable := `
+	CREATE TABLE IF NOT EXISTS workspace_members (
+		workspace_id UUID,
+		user_id UUID,
+		role TEXT,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		PRIMARY KEY ((workspace_id), user_id)
+	);`
+
+	createWorkspaceInvitationTable := `
+	CREATE TABLE IF NOT EXISTS workspace_invitations (
+		id UUID PRIMARY KEY,
+		workspace_id UUID,
+		email TEXT,
+		role TEXT,
+		hashed_token TEXT,
+		status TEXT,
+		invited_by UUID,
+		expires_at TIMESTAMP,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP
+	);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createWorkspaceTable).Exec(); err != nil {
+		log.Fatal("Unable to create workspace table:", err.Error())
+	}
+	if err := session.Query(createWorkspaceMemberTable).Exec(); err != nil {
+		log.Fatal("Unable to create workspace member table:", err.Error())
+	}
+	if err := session.Query(createWorkspaceInvitationTable).Exec(); err != nil {
+		log.Fatal("Unable to create workspace invitation table:", err.Error())
+	}
+}
+
+func migrateDomainTable() {
+	createDomainTable := `
+	CREATE TABLE IF NOT EXISTS domains (
+		id UUID PRIMARY KEY,
+		hostname TEXT,
+		user_id UUID,
+		workspace_id UUID,
+		verification_token TEXT,
+		status TEXT,
+		verified_at TIMESTAMP,
+		apple_app_ids LIST<TEXT>,
+		android_package_name TEXT,
+		android_cert_fingerprints LIST<TEXT>,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		deleted_at TIMESTAMP
+	);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createDomainTable).Exec(); err != nil {
+		log.Fatal("Unable to create domain table:", err.Error())
+	}
+	addColumnIfMissing(session, "domains", "apple_app_ids", "LIST<TEXT>")
+	addColumnIfMissing(session, "domains", "android_package_name", "TEXT")
+	addColumnIfMissing(session, "domains", "android_cert_fingerprints", "LIST<TEXT>")
+}
+
+func migrateFolderTable() {
+	createFolderTable := `
+	CREATE TABLE IF NOT EXISTS folders (
+		id UUID PRIMARY KEY,
+		name TEXT,
+		user_id UUID,
+		workspace_id UUID,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		deleted_at TIMESTAMP
+	);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createFolderTable).Exec(); err != nil {
+		log.Fatal("Unable to create folder table:", err.Error())
+	}
+}
+
+func migrateCampaignTables() {
+	createCampaignTable := `
+	CREATE TABLE IF NOT EXISTS campaigns (
+		id UUID PRIMARY KEY,
+		name TEXT,
+		user_id UUID,
+		workspace_id UUID,
+		starts_at TIMESTAMP,
+		ends_at TIMESTAMP,
+		budget_note TEXT,
+		goals MAP<TEXT, INT>,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		deleted_at TIMESTAMP
+	);`
+
+	createCampaignUrlTable := `
+	CREATE TABLE IF NOT EXISTS campaign_urls (
+		campaign_id UUID,
+		url_id UUID,
+		created_at TIMESTAMP,
+		PRIMARY KEY ((campaign_id), url_id)
+	);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createCampaignTable).Exec(); err != nil {
+		log.Fatal("Unable to create campaign table:", err.Error())
+	}
+	if err := session.Query(createCampaignUrlTable).Exec(); err != nil {
+		log.Fatal("Unable to create campaign url table:", err.Error())
+	}
+}
+
+func migrateWebhookTables() {
+	createWebhookTable := `
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id UUID PRIMARY KEY,
+		user_id UUID,
+		workspace_id UUID,
+		url TEXT,
+		secret TEXT,
+		events SET<TEXT>,
+		status TEXT,
+		failure_count INT,
+		disabled_at TIMESTAMP,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		deleted_at TIMESTAMP
+	);`
+
+	// Deliveries are kept for 30 days.
+	createWebhookDeliveryTable := `
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		webhook_id UUID,
+		id TIMEUUID,
+		event_id UUID,
+		event TEXT,
+		payload TEXT,
+		attempt INT,
+		manual BOOLEAN,
+		status_code INT,
+		response TEXT,
+		error TEXT,
+		succeeded BOOLEAN,
+		duration_ms INT,
+		created_at TIMESTAMP,
+		PRIMARY KEY ((webhook_id), id)
+	) WITH CLUSTERING ORDER BY (id DESC) AND default_time_to_live = 2592000;`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createWebhookTable).Exec(); err != nil {
+		log.Fatal("Unable to create webhook table:", err.Error())
+	}
+	if err := session.Query(createWebhookDeliveryTable).Exec(); err != nil {
+		log.Fatal("Unable to create webhook delivery table:", err.Error())
+	}
+}
+
+func migratePrivacySettingsTable() {
+	createPrivacySettingsTable := `
+	CREATE TABLE IF NOT EXISTS privacy_settings (
+		user_id UUID PRIMARY KEY,
+		ip_mode TEXT,
+		retention_days INT,
+		honor_do_not_track BOOLEAN,
+		updated_at TIMESTAMP
+	);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createPrivacySettingsTable).Exec(); err != nil {
+		log.Fatal("Unable to create privacy settings table:", err.Error())
+	}
+}
+
+func migrateAbuseReportTable() {
+	createAbuseReportTable := `
+	CREATE TABLE IF NOT EXISTS abuse_reports (
+		id UUID PRIMARY KEY,
+		url_id UUID,
+		domain TEXT,
+		short_url TEXT,
+		destination TEXT,
+		category TEXT,
+		details TEXT,
+		reporter_email TEXT,
+		status TEXT,
+		reviewed_by UUID,
+		review_note TEXT,
+		reviewed_at TIMESTAMP,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP
+	);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createAbuseReportTable).Exec(); err != nil {
+		log.Fatal("Unable to create abuse report table:", err.Error())
+	}
+}
+
+func migrateSystemStatsTable() {
+	createSystemStatsTable := `
+	CREATE TABLE IF NOT EXISTS system_stats (
+		metric TEXT,
+		day TEXT,
+		count COUNTER,
+		PRIMARY KEY ((metric), day)
+	);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createSystemStatsTable).Exec(); err != nil {
+		log.Fatal("Unable to create system stats table:", err.Error())
+	}
+	backfillSystemStats(session)
+}
+
+// systemStatsBackfilled marks the counters as filled in from the tables.
+const systemStatsBackfilled = "backfilled"
+
+// backfillSystemStats counts what was stored before the counters existed,
+// once. Each counter is moved by the difference to what the tables hold,
+// so what was counted since the counters were added is not counted twice.
+func backfillSystemStats(session *gocql.Session) {
+	var done int64
+	err := session.Query("SELECT count FROM system_stats WHERE metric = ? AND day = ?", systemStatsBackfilled, types.StatTotalDay).Scan(&done)
+	if err != nil && err != gocql.ErrNotFound {
+		log.Fatal("Unable to read system stats:", err.Error())
+	}
+	if done > 0 {
+		return
+	}
+
+	// Days count what was created on them, the total leaves out what was
+	// deleted since, as IncrementSystemStat does.
+	counts := map[string]map[string]int64{}
+	count := func(metric, day string, n int64, live bool) {
+		if counts[metric] == nil {
+			counts[metric] = map[string]int64{}
+		}
+		counts[metric][day] += n
+		if live {
+			counts[metric][types.StatTotalDay] += n
+		}
+	}
+
+	var email, verifiedEmail, status string
+	var createdAt, visitedAt, deletedAt time.Time
+	iter := session.Query("SELECT email, verified_email, status, created_at FROM users").Iter()
+	for iter.Scan(&email, &verifiedEmail, &status, &createdAt) {
+		day := createdAt.UTC().Format(systemStatDayFormat)
+		count(types.StatUsers, day, 1, status != types.UserStatusDeleted)
+		if verifiedEmail != "" && verifiedEmail == email {
+			count(types.StatVerifiedUsers, day, 1, status != types.UserStatusDeleted)
+		}
+	}
+	if err := iter.Close(); err != nil {
+		log.Fatal("Unable to count users:", err.Error())
+	}
+
+	iter = session.Query("SELECT created_at, deleted_at FROM urls").Iter()
+	for iter.Scan(&createdAt, &deletedAt) {
+		count(types.StatLinks, createdAt.UTC().Format(systemStatDayFormat), 1, deletedAt.IsZero())
+	}
+	if err := iter.Close(); err != nil {
+		log.Fatal("Unable to count links:", err.Error())
+	}
+
+	// Clicks are counted from the rollups, the logs outlive them only until
+	// they are rolled up.
+	var day, trafficClass string
+	var clicks int64
+	iter = session.Query("SELECT day, traffic_class, clicks FROM url_click_rollups").Iter()
+	for iter.Scan(&day, &trafficClass, &clicks) {
+		if types.IsHumanTraffic(trafficClass) {
+			count(types.StatClicks, day, clicks, true)
+		}
+	}
+	if err := iter.Close(); err != nil {
+		log.Fatal("Unable to count clicks:", err.Error())
+	}
+
+	var rolledUp bool
+	iter = session.Query("SELECT visited_at, traffic_class, rolled_up FROM url_logs").Iter()
+	for iter.Scan(&visitedAt, &trafficClass, &rolledUp) {
+		if !rolledUp && types.IsHumanTraffic(trafficClass) {
+			count(types.StatClicks, visitedAt.UTC().Format(systemStatDayFormat), 1, true)
+		}
+	}
+	if err := iter.Close(); err != nil {
+		log.Fatal("Unable to count clicks:", err.Error())
+	}
+
+	incrementStatQuery := "UPDATE system_stats SET count = count + ? WHERE metric = ? AND day = ?"
+	for metric, days := range counts {
+		for day, want := range days {
+			var current int64
+			err := session.Query("SELECT count FROM system_stats WHERE metric = ? AND day = ?", metric, day).Scan(&current)
+			if err != nil && err != gocql.ErrNotFound {
+				log.Fatal("Unable to read system stats:", err.Error())
+			}
+			if want == current {
+				continue
+			}
+			if err := session.Query(incrementStatQuery, want-current, metric, day).Exec(); err != nil {
+				log.Fatal("Unable to backfill system stats:", err.Error())
+			}
+		}
+	}
+	if err := session.Query(incrementStatQuery, 1, systemStatsBackfilled, types.StatTotalDay).Exec(); err != nil {
+		log.Fatal("Unable to backfill system stats:", err.Error())
+	}
+}
+
+func migrateAuditLogTable() {
+	createAuditLogTable := `
+	CREATE TABLE IF NOT EXISTS audit_logs (
+		day TEXT,
+		id TIMEUUID,
+		actor_id UUID,
+		action TEXT,
+		target_type TEXT,
+		target_id TEXT,
+		ip TEXT,
+		user_agent TEXT,
+		detail TEXT,
+		changes TEXT,
+		created_at TIMESTAMP,
+		PRIMARY KEY ((day), id)
+	) WITH CLUSTERING ORDER BY (id DESC);`
+
+	session, err := database.CreateSession()
+	if err != nil {
+		log.Fatal("Unable to create session:", err.Error())
+	}
+	defer session.Close()
+	if err := session.Query(createAuditLogTable).Exec(); err != nil {
+		log.Fatal("Unable to create audit log table:", err.Error())
+	}
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+func (s *store) CreateOAuthIdentity(identity *types.OAuthIdentity) error {
+	createIdentityQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".oauth_identities (provider, subject, id, user_id, email, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	identity.ID, identity.CreatedAt, identity.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+	return s.DBSession.Query(createIdentityQuery, identity.Provider, identity.Subject, identity.ID, identity.UserID, identity.Email, identity.CreatedAt, identity.UpdatedAt).Exec()
+}
+
+func (s *store) GetOAuthIdentity(provider, subject string) (*types.OAuthIdentity, error) {
+	var identity types.OAuthIdentity
+	getIdentityQuery := "SELECT provider, subject, id, user_id, email, created_at, updated_at FROM " + CASSANDRA_KEYSPACE + ".oauth_identities WHERE provider = ? AND subject = ?"
+	err := s.DBSession.Query(getIdentityQuery, provider, subject).Consistency(gocql.One).Scan(&identity.Provider, &identity.Subject, &identity.ID, &identity.UserID, &identity.Email, &identity.CreatedAt, &identity.UpdatedAt)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (s *store) GetOAuthIdentitiesOfUser(userID string) ([]*types.OAuthIdentity, error) {
+	getIdentitiesQuery := "SELECT provider, subject, id, user_id, email, created_at, updated_at FROM " + CASSANDRA_KEYSPACE + ".oauth_identities WHERE user_id = ? ALLOW FILTERING"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	iter := s.DBSession.Query(getIdentitiesQuery, userUUID).Iter()
+
+	var identities []*types.OAuthIdentity
+	for {
+		var identity types.OAuthIdentity
+		if !iter.Scan(&identity.Provider, &identity.Subject, &identity.ID, &identity.UserID, &identity.Email, &identity.CreatedAt, &identity.UpdatedAt) {
+			break
+		}
+		identities = append(identities, &identity)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+func (s *store) SavePrivacySettings(settings *types.PrivacySettings) error {
+	savePrivacySettingsQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".privacy_settings (user_id, ip_mode, retention_days, honor_do_not_track, updated_at) VALUES (?, ?, ?, ?, ?)"
+	settings.UpdatedAt = time.Now()
+	return s.DBSession.Query(savePrivacySettingsQuery, settings.UserID, settings.IPMode, settings.RetentionDays, settings.HonorDoNotTrack, settings.UpdatedAt).Exec()
+}
+
+func (s *store) GetPrivacySettingsByUserID(userID string) (*types.PrivacySettings, error) {
+	var settings types.PrivacySettings
+	getPrivacySettingsQuery := "SELECT user_id, ip_mode, retention_days, honor_do_not_track, updated_at FROM " + CASSANDRA_KEYSPACE + ".privacy_settings WHERE user_id = ?"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DBSession.Query(getPrivacySettingsQuery, userUUID).Consistency(gocql.One).Scan(&settings.UserID, &settings.IPMode, &settings.RetentionDays, &settings.HonorDoNotTrack, &settings.UpdatedAt)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
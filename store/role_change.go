@@ -0,0 +1,38 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+func (s *store) CreateRoleChange(roleChange *types.RoleChange) error {
+	createRoleChangeQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".role_changes (user_id, id, actor_id, previous_role, new_role, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+	roleChange.ID, roleChange.CreatedAt = gocql.TimeUUID(), time.Now()
+	return s.DBSession.Query(createRoleChangeQuery, roleChange.UserID, roleChange.ID, roleChange.ActorID, roleChange.PreviousRole, roleChange.NewRole, roleChange.CreatedAt).Exec()
+}
+
+func (s *store) GetRoleChangesByUserID(userID string) ([]*types.RoleChange, error) {
+	var roleChanges []*types.RoleChange
+	getRoleChangesQuery := "SELECT user_id, id, actor_id, previous_role, new_role, created_at FROM " + CASSANDRA_KEYSPACE + ".role_changes WHERE user_id = ?"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	iter := s.DBSession.Query(getRoleChangesQuery, userUUID).Iter()
+	for {
+		var roleChange types.RoleChange
+		if !iter.Scan(&roleChange.UserID, &roleChange.ID, &roleChange.ActorID, &roleChange.PreviousRole, &roleChange.NewRole, &roleChange.CreatedAt) {
+			break
+		}
+		roleChanges = append(roleChanges, &roleChange)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return roleChanges, nil
+}
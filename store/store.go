@@ -23,6 +23,8 @@ type Store interface {
 	SearchUsers(filter types.UserFilter) ([]*types.User, error)
 	UpdateUser(user *types.User) error
 	DeleteUser(user *types.User) error
+	EraseUser(user *types.User) error
+	GetUsersByRole(role string) ([]*types.User, error)
 
 	//Password Store
 	CreatePassword(password *types.Password) error
@@ -33,20 +35,126 @@ type Store interface {
 	//URL Store
 	CreateURL(url *types.URL) error
 	GetUrlByID(id string) (*types.URL, error)
-	GetUrlByShortUrl(short_url string) (*types.URL, error)
+	GetUrlByShortUrl(domain *types.Domain, short_url string) (*types.URL, error)
 	GetURLsOfUser(user_id string) ([]*types.URL, error)
+	GetURLsOfWorkspace(workspace_id string) ([]*types.URL, error)
+	GetURLsCreatedByUser(user_id string) ([]*types.URL, error)
+	UpdateUrlOwner(url *types.URL) error
+	UpdateURL(url *types.URL) error
+	UpdateUrlStatus(url *types.URL) error
 	DeleteURL(url *types.URL) error
 
 	//URL Logs
-	CreateUrlLog(log *types.UrlLog) error
+	CreateUrlLog(log *types.UrlLog, retention time.Duration) error
 	DeleteUrlLogsByUrlId(urlID string, deletedTime time.Time) error
 	GetUrlLogsByUrlId(urlID string) ([]*types.UrlLog, error)
-	CountInteractions(urlId string) (int, error)
+	CountInteractions(urlId string, includeBots bool) (int, error)
+	CountInteractionsByVariant(urlId string, includeBots bool) (map[string]int, error)
+	GetClickRollups(urlID string) ([]*types.ClickRollup, error)
+	GetAllURLs() ([]*types.URL, error)
+	RollUpUrlLogs(urlID gocql.UUID) error
+	PurgeUrlLogs(urlID gocql.UUID, before time.Time) error
+
+	// Privacy settings
+	SavePrivacySettings(settings *types.PrivacySettings) error
+	GetPrivacySettingsByUserID(userID string) (*types.PrivacySettings, error)
 
 	// OTP
 	CreateOtp(otp *types.Otp) (*types.Otp, error)
 	GetOtpByUserIdAndOtp(userId, key, otpValue string) ([]*types.Otp, error)
 	ChangeOtpStatus(otp *types.Otp, status string) error
+	GetOtpsOfUser(userID string) ([]*types.Otp, error)
+
+	// API Keys
+	CreateApiKey(apiKey *types.ApiKey) error
+	GetApiKeyByID(id string) (*types.ApiKey, error)
+	GetApiKeyByHash(hashedKey string) (*types.ApiKey, error)
+	GetApiKeysOfUser(userID string) ([]*types.ApiKey, error)
+	TouchApiKey(apiKey *types.ApiKey) error
+	RevokeApiKey(apiKey *types.ApiKey) error
+
+	// OAuth identities
+	CreateOAuthIdentity(identity *types.OAuthIdentity) error
+	GetOAuthIdentity(provider, subject string) (*types.OAuthIdentity, error)
+	GetOAuthIdentitiesOfUser(userID string) ([]*types.OAuthIdentity, error)
+
+	// Two factor authentication
+	SaveTwoFactor(twoFactor *types.TwoFactor) error
+	GetTwoFactorByUserID(userID string) (*types.TwoFactor, error)
+	DeleteTwoFactor(twoFactor *types.TwoFactor) error
+
+	// Role changes
+	CreateRoleChange(roleChange *types.RoleChange) error
+	GetRoleChangesByUserID(userID string) ([]*types.RoleChange, error)
+
+	// Workspaces
+	CreateWorkspace(workspace *types.Workspace) error
+	GetWorkspaceByID(id string) (*types.Workspace, error)
+	DeleteWorkspace(workspace *types.Workspace) error
+	SaveWorkspaceMember(member *types.WorkspaceMember) error
+	GetWorkspaceMember(workspaceID, userID string) (*types.WorkspaceMember, error)
+	GetWorkspaceMembers(workspaceID string) ([]*types.WorkspaceMember, error)
+	GetWorkspaceMembershipsOfUser(userID string) ([]*types.WorkspaceMember, error)
+	DeleteWorkspaceMember(member *types.WorkspaceMember) error
+	CreateWorkspaceInvitation(invitation *types.WorkspaceInvitation) error
+	GetWorkspaceInvitationByHash(hashedToken string) (*types.WorkspaceInvitation, error)
+	ChangeWorkspaceInvitationStatus(invitation *types.WorkspaceInvitation, status string) error
+
+	// Custom domains
+	CreateDomain(domain *types.Domain) error
+	GetDomainByID(id string) (*types.Domain, error)
+	GetVerifiedDomainByHostname(hostname string) (*types.Domain, error)
+	GetDomainsOfUser(userID string) ([]*types.Domain, error)
+	GetDomainsOfWorkspace(workspaceID string) ([]*types.Domain, error)
+	MarkDomainVerified(domain *types.Domain) error
+	UpdateDomainApps(domain *types.Domain) error
+	DeleteDomain(domain *types.Domain) error
+
+	// Folders
+	CreateFolder(folder *types.Folder) error
+	GetFolderByID(id string) (*types.Folder, error)
+	GetFoldersOfUser(userID string) ([]*types.Folder, error)
+	GetFoldersOfWorkspace(workspaceID string) ([]*types.Folder, error)
+	RenameFolder(folder *types.Folder) error
+	DeleteFolder(folder *types.Folder) error
+
+	// Campaigns
+	CreateCampaign(campaign *types.Campaign) error
+	GetCampaignByID(id string) (*types.Campaign, error)
+	GetCampaignsOfUser(userID string) ([]*types.Campaign, error)
+	GetCampaignsOfWorkspace(workspaceID string) ([]*types.Campaign, error)
+	UpdateCampaign(campaign *types.Campaign) error
+	DeleteCampaign(campaign *types.Campaign) error
+	AddCampaignUrl(campaignID, urlID gocql.UUID) error
+	RemoveCampaignUrl(campaignID, urlID gocql.UUID) error
+	GetCampaignUrlIDs(campaignID gocql.UUID) ([]gocql.UUID, error)
+
+	// Webhooks
+	CreateWebhook(webhook *types.Webhook) error
+	GetWebhookByID(id string) (*types.Webhook, error)
+	GetWebhooksOfUser(userID string) ([]*types.Webhook, error)
+	GetWebhooksOfWorkspace(workspaceID string) ([]*types.Webhook, error)
+	UpdateWebhook(webhook *types.Webhook) error
+	DeleteWebhook(webhook *types.Webhook) error
+	CreateWebhookDelivery(delivery *types.WebhookDelivery) error
+	GetWebhookDeliveryByID(webhookID gocql.UUID, id string) (*types.WebhookDelivery, error)
+	GetWebhookDeliveries(webhookID gocql.UUID, limit int) ([]*types.WebhookDelivery, error)
+
+	// Abuse reports
+	CreateAbuseReport(report *types.AbuseReport) error
+	GetAbuseReportByID(id string) (*types.AbuseReport, error)
+	GetAbuseReports(status string) ([]*types.AbuseReport, error)
+	ReviewAbuseReport(report *types.AbuseReport) error
+
+	// System stats
+	IncrementSystemStat(metric string, delta int64) error
+	GetSystemStatTotal(metric string) (int64, error)
+	GetSystemStatDays(metric string, from, to time.Time) (map[string]int64, error)
+
+	// Audit logs
+	CreateAuditLog(auditLog *types.AuditLog) error
+	GetAuditLogs(filter types.AuditLogFilter) ([]*types.AuditLog, error)
+	PseudonymizeAuditLogs(user *types.User) error
 }
 
 var CASSANDRA_HOST, CASSANDRA_KEYSPACE string
@@ -65,10 +173,19 @@ func NewStore() Store {
 }
 
 func (s *store) CreateUser(user *types.User) error {
-	createUserQuery := `INSERT INTO ` + CASSANDRA_KEYSPACE + `.users (id, name, email, mobile, status, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	createUserQuery := `INSERT INTO ` + CASSANDRA_KEYSPACE + `.users (id, name, email, verified_email, mobile, status, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	user.CreatedAt, user.UpdatedAt, user.ID = time.Now(), time.Now(), gocql.TimeUUID() // Generate a new UUID for the user adn set timestamps
 	user.Status, user.Role = "active", "user"
-	return s.DBSession.Query(createUserQuery, user.ID, user.Name, user.Email, user.Mobile, user.Status, user.Role, user.CreatedAt, user.UpdatedAt).Exec()
+	err := s.DBSession.Query(createUserQuery, user.ID, user.Name, user.Email, user.VerifiedEmail, user.Mobile, user.Status, user.Role, user.CreatedAt, user.UpdatedAt).Exec()
+	if err != nil {
+		return err
+	}
+
+	s.countSystemStat(types.StatUsers, 1)
+	if user.VerifiedEmail != "" && user.IsEmailVerified() {
+		s.countSystemStat(types.StatVerifiedUsers, 1)
+	}
+	return nil
 }
 
 func (s *store) GetUserByID(id string) (*types.User, error) {
@@ -108,7 +225,7 @@ func (s *store) GetUserByEmail(email string) (*types.User, error) {
 
 func (s *store) SearchUsers(filter types.UserFilter) ([]*types.User, error) {
 	var users []*types.User
-	searchUsersQuery := `SELECT id, name, email, mobile, verified_email, status, created_at, updated_at, deleted_at FROM ` + CASSANDRA_KEYSPACE + `.users`
+	searchUsersQuery := `SELECT id, name, email, mobile, verified_email, status, role, created_at, updated_at, deleted_at FROM ` + CASSANDRA_KEYSPACE + `.users`
 	if filter.Name != "" || filter.Email != "" || filter.Mobile != "" || filter.Status != "" {
 		searchUsersQuery += ` WHERE`
 	}
@@ -169,7 +286,7 @@ func (s *store) SearchUsers(filter types.UserFilter) ([]*types.User, error) {
 	// Iterate over the results
 	for {
 		var user types.User
-		if !iter.Scan(&user.ID, &user.Name, &user.Email, &user.Mobile, &user.VerifiedEmail, &user.Status, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt) {
+		if !iter.Scan(&user.ID, &user.Name, &user.Email, &user.Mobile, &user.VerifiedEmail, &user.Status, &user.Role, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt) {
 			break
 		}
 		if user.DeletedAt.IsZero() {
@@ -207,15 +324,25 @@ func (s *store) DeleteUser(user *types.User) error {
 	}
 
 	keyspace := os.Getenv("CASSANDRA_URLLITE_KEYSPACE")
-	userDeleteQuery := "UPDATE " + keyspace + ".users SET deleted_at = ? WHERE id = ?"
+	userDeleteQuery := "UPDATE " + keyspace + ".users SET status = ?, deleted_at = ? WHERE id = ?"
+	user.Status, user.DeletedAt = types.UserStatusDeleted, time.Now()
 
-	return s.DBSession.Query(userDeleteQuery, time.Now(), user.ID.String()).Exec()
+	err := s.DBSession.Query(userDeleteQuery, user.Status, user.DeletedAt, user.ID).Exec()
+	if err != nil {
+		return err
+	}
+
+	s.countSystemStat(types.StatUsers, -1)
+	if user.VerifiedEmail != "" && user.IsEmailVerified() {
+		s.countSystemStat(types.StatVerifiedUsers, -1)
+	}
+	return nil
 }
 
 func (s *store) CreatePassword(password *types.Password) error {
 	_, err := s.GetPasswordByUserID(password.UserID.String())
 	if err != gocql.ErrNotFound {
-		return fmt.Errorf("Password already setted for the user_id: ", password.UserID)
+		return fmt.Errorf("Password already setted for the user_id: %s", password.UserID)
 	} else if err != gocql.ErrNotFound && err != nil {
 		return err
 	}
@@ -253,16 +380,29 @@ func (s *store) DeletePassword(password *types.Password) error {
 	return s.DBSession.Query(deletePasswordQuery, time.Now(), password.ID).Exec()
 }
 
+const urlColumns = "id, user_id, workspace_id, domain, domain_id, long_url, short_url, status, routing_rules, variants, deep_link, utm_params, forward_query, query_merge, redirect_type, interstitial_delay, leaving_warning, no_index, tags, folder_id, notes, created_at, updated_at, deleted_at"
+
+// urlFields returns the scan destinations matching urlColumns.
+func urlFields(url *types.URL) []interface{} {
+	return []interface{}{&url.ID, &url.UserID, &url.WorkspaceID, &url.Domain, &url.DomainID, &url.LongUrl, &url.ShortUrl, &url.Status, &url.RoutingRules, &url.Variants, &url.DeepLink, &url.UTMParams, &url.ForwardQuery, &url.QueryMerge, &url.RedirectType, &url.InterstitialDelay, &url.LeavingWarning, &url.NoIndex, &url.Tags, &url.FolderID, &url.Notes, &url.CreatedAt, &url.UpdatedAt, &url.DeletedAt}
+}
+
 func (s *store) CreateURL(url *types.URL) error {
-	createUrlQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".urls (id, user_id, long_url, short_url, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	createUrlQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".urls (id, user_id, workspace_id, domain, domain_id, long_url, short_url, status, routing_rules, variants, deep_link, utm_params, forward_query, query_merge, redirect_type, interstitial_delay, leaving_warning, no_index, tags, folder_id, notes, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 	url.ID, url.CreatedAt, url.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
-	return s.DBSession.Query(createUrlQuery, url.ID, url.UserID, url.LongUrl, url.ShortUrl, url.Status, url.CreatedAt, url.UpdatedAt).Exec()
+	err := s.DBSession.Query(createUrlQuery, url.ID, url.UserID, url.WorkspaceID, url.Domain, url.DomainID, url.LongUrl, url.ShortUrl, url.Status, url.RoutingRules, url.Variants, url.DeepLink, url.UTMParams, url.ForwardQuery, url.QueryMerge, url.RedirectType, url.InterstitialDelay, url.LeavingWarning, url.NoIndex, url.Tags, url.FolderID, url.Notes, url.CreatedAt, url.UpdatedAt).Exec()
+	if err != nil {
+		return err
+	}
+
+	s.countSystemStat(types.StatLinks, 1)
+	return nil
 }
 
 func (s *store) GetUrlByID(id string) (*types.URL, error) {
 	var url types.URL
-	selectUrlByIdQuery := "SELECT id, user_id, long_url, short_url, status, created_at, updated_at, deleted_at FROM " + CASSANDRA_KEYSPACE + ".urls WHERE id = ?"
-	err := s.DBSession.Query(selectUrlByIdQuery, id).Consistency(gocql.One).Scan(&url.ID, &url.UserID, &url.LongUrl, &url.ShortUrl, &url.Status, &url.CreatedAt, &url.UpdatedAt, &url.DeletedAt)
+	selectUrlByIdQuery := "SELECT " + urlColumns + " FROM " + CASSANDRA_KEYSPACE + ".urls WHERE id = ?"
+	err := s.DBSession.Query(selectUrlByIdQuery, id).Consistency(gocql.One).Scan(urlFields(&url)...)
 
 	if err == gocql.ErrNotFound {
 		return nil, nil
@@ -273,26 +413,35 @@ func (s *store) GetUrlByID(id string) (*types.URL, error) {
 	return &url, nil
 }
 
-func (s *store) GetUrlByShortUrl(short_url string) (*types.URL, error) {
-	var url types.URL
-	selectUrlByIdQuery := "SELECT id, user_id, long_url, short_url, status, created_at, updated_at, deleted_at FROM " + CASSANDRA_KEYSPACE + ".urls WHERE short_url = ? ALLOW FILTERING"
-	err := s.DBSession.Query(selectUrlByIdQuery, short_url).Consistency(gocql.One).Scan(&url.ID, &url.UserID, &url.LongUrl, &url.ShortUrl, &url.Status, &url.CreatedAt, &url.UpdatedAt, &url.DeletedAt)
-	if !url.DeletedAt.IsZero() {
-		return nil, nil
+// GetUrlByShortUrl resolves a slug within a domain, the nil domain is the
+// default short link host. Links created before custom domains have no
+// domain stored, which scans as the empty domain.
+func (s *store) GetUrlByShortUrl(domain *types.Domain, short_url string) (*types.URL, error) {
+	selectUrlByIdQuery := "SELECT " + urlColumns + " FROM " + CASSANDRA_KEYSPACE + ".urls WHERE short_url = ? ALLOW FILTERING"
+	iter := s.DBSession.Query(selectUrlByIdQuery, short_url).Iter()
+
+	var found *types.URL
+	for {
+		var url types.URL
+		if !iter.Scan(urlFields(&url)...) {
+			break
+		}
+		if url.DeletedAt.IsZero() && domain.Serves(&url) {
+			found = &url
+			break
+		}
 	}
 
-	if err == gocql.ErrNotFound {
-		return nil, nil
-	} else if err != nil {
+	if err := iter.Close(); err != nil {
 		return nil, err
 	}
 
-	return &url, nil
+	return found, nil
 }
 
 func (s *store) GetURLsOfUser(user_id string) ([]*types.URL, error) {
 	var urls []*types.URL
-	getURLsQuery := "SELECT id, user_id, long_url, short_url, status, created_at, updated_at, deleted_at FROM " + CASSANDRA_KEYSPACE + ".urls WHERE user_id = ? ALLOW FILTERING"
+	getURLsQuery := "SELECT " + urlColumns + " FROM " + CASSANDRA_KEYSPACE + ".urls WHERE user_id = ? ALLOW FILTERING"
 	iter := s.DBSession.Query(getURLsQuery, user_id).Iter()
 
 	defer iter.Close()
@@ -300,7 +449,36 @@ func (s *store) GetURLsOfUser(user_id string) ([]*types.URL, error) {
 	// Iterate over the results
 	for {
 		var url types.URL
-		if !iter.Scan(&url.ID, &url.UserID, &url.LongUrl, &url.ShortUrl, &url.Status, &url.CreatedAt, &url.UpdatedAt, &url.DeletedAt) {
+		if !iter.Scan(urlFields(&url)...) {
+			break
+		}
+		if url.DeletedAt.IsZero() && url.WorkspaceID == (gocql.UUID{}) {
+			urls = append(urls, &url)
+		}
+	}
+
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	return urls, nil
+
+}
+
+func (s *store) GetURLsOfWorkspace(workspace_id string) ([]*types.URL, error) {
+	var urls []*types.URL
+	getURLsQuery := "SELECT " + urlColumns + " FROM " + CASSANDRA_KEYSPACE + ".urls WHERE workspace_id = ? ALLOW FILTERING"
+	workspaceUUID, err := gocql.ParseUUID(workspace_id)
+	if err != nil {
+		return nil, err
+	}
+	iter := s.DBSession.Query(getURLsQuery, workspaceUUID).Iter()
+
+	defer iter.Close()
+
+	for {
+		var url types.URL
+		if !iter.Scan(urlFields(&url)...) {
 			break
 		}
 		if url.DeletedAt.IsZero() {
@@ -313,22 +491,93 @@ func (s *store) GetURLsOfUser(user_id string) ([]*types.URL, error) {
 	}
 
 	return urls, nil
+}
+
+// GetURLsCreatedByUser returns the personal links of the user and the links
+// they created in workspaces.
+func (s *store) GetURLsCreatedByUser(user_id string) ([]*types.URL, error) {
+	var urls []*types.URL
+	getURLsQuery := "SELECT " + urlColumns + " FROM " + CASSANDRA_KEYSPACE + ".urls WHERE user_id = ? ALLOW FILTERING"
+	userUUID, err := gocql.ParseUUID(user_id)
+	if err != nil {
+		return nil, err
+	}
+	iter := s.DBSession.Query(getURLsQuery, userUUID).Iter()
+
+	for {
+		var url types.URL
+		if !iter.Scan(urlFields(&url)...) {
+			break
+		}
+		if url.DeletedAt.IsZero() {
+			urls = append(urls, &url)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+func (s *store) UpdateUrlOwner(url *types.URL) error {
+	updateUrlOwnerQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".urls SET user_id = ?, workspace_id = ?, folder_id = ?, updated_at = ? WHERE id = ?"
+	url.UpdatedAt = time.Now()
+	return s.DBSession.Query(updateUrlOwnerQuery, url.UserID, url.WorkspaceID, url.FolderID, url.UpdatedAt, url.ID).Exec()
+}
 
+// UpdateURL saves the editable link settings, ownership changes go through
+// UpdateUrlOwner.
+func (s *store) UpdateURL(url *types.URL) error {
+	updateUrlQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".urls SET long_url = ?, routing_rules = ?, variants = ?, deep_link = ?, utm_params = ?, forward_query = ?, query_merge = ?, redirect_type = ?, interstitial_delay = ?, leaving_warning = ?, no_index = ?, tags = ?, folder_id = ?, notes = ?, updated_at = ? WHERE id = ?"
+	url.UpdatedAt = time.Now()
+	return s.DBSession.Query(updateUrlQuery, url.LongUrl, url.RoutingRules, url.Variants, url.DeepLink, url.UTMParams, url.ForwardQuery, url.QueryMerge, url.RedirectType, url.InterstitialDelay, url.LeavingWarning, url.NoIndex, url.Tags, url.FolderID, url.Notes, url.UpdatedAt, url.ID).Exec()
+}
+
+func (s *store) UpdateUrlStatus(url *types.URL) error {
+	updateUrlStatusQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".urls SET status = ?, updated_at = ? WHERE id = ?"
+	url.UpdatedAt = time.Now()
+	return s.DBSession.Query(updateUrlStatusQuery, url.Status, url.UpdatedAt, url.ID).Exec()
 }
 
 func (s *store) DeleteURL(url *types.URL) error {
 	deleteUrlQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".urls SET deleted_at = ? WHERE id = ?"
-	return s.DBSession.Query(deleteUrlQuery, time.Now(), url.ID).Exec()
+	err := s.DBSession.Query(deleteUrlQuery, time.Now(), url.ID).Exec()
+	if err != nil {
+		return err
+	}
+
+	s.countSystemStat(types.StatLinks, -1)
+	return nil
 }
 
-func (s *store) CreateUrlLog(log *types.UrlLog) error {
-	insertUrlLogQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".url_logs (id, url_id, visited_at, redirect_status, http_status_code, client_ip, city, country, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
-	log.ID, log.CreatedAt, log.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
-	return s.DBSession.Query(insertUrlLogQuery, log.ID, log.UrlID, log.VisitedAt, log.RedirectStatus, log.HttpStatusCode, log.ClientIP, log.City, log.Country, log.CreatedAt, log.UpdatedAt).Exec()
+const urlLogColumns = "id, client_ip, city, country, url_id, visited_at, redirect_status, http_status_code, destination, matched_rule, variant, referrer, user_agent, traffic_class, do_not_track, rolled_up, created_at, updated_at, deleted_at"
+
+// urlLogFields returns the scan destinations matching urlLogColumns.
+func urlLogFields(log *types.UrlLog) []interface{} {
+	return []interface{}{&log.ID, &log.ClientIP, &log.City, &log.Country, &log.UrlID, &log.VisitedAt, &log.RedirectStatus, &log.HttpStatusCode, &log.Destination, &log.MatchedRule, &log.Variant, &log.Referrer, &log.UserAgent, &log.TrafficClass, &log.DoNotTrack, &log.RolledUp, &log.CreatedAt, &log.UpdatedAt, &log.DeletedAt}
+}
+
+// CreateUrlLog stores the click and counts it in the daily rollups. A
+// retention above zero makes Cassandra expire the raw log after it, the
+// rollup is kept.
+func (s *store) CreateUrlLog(log *types.UrlLog, retention time.Duration) error {
+	insertUrlLogQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".url_logs (id, url_id, visited_at, redirect_status, http_status_code, client_ip, city, country, destination, matched_rule, variant, referrer, user_agent, traffic_class, do_not_track, rolled_up, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?"
+	log.ID, log.CreatedAt, log.UpdatedAt, log.RolledUp = gocql.TimeUUID(), time.Now(), time.Now(), true
+	err := s.DBSession.Query(insertUrlLogQuery, log.ID, log.UrlID, log.VisitedAt, log.RedirectStatus, log.HttpStatusCode, log.ClientIP, log.City, log.Country, log.Destination, log.MatchedRule, log.Variant, log.Referrer, log.UserAgent, log.TrafficClass, log.DoNotTrack, log.RolledUp, log.CreatedAt, log.UpdatedAt, int(retention.Seconds())).Exec()
+	if err != nil {
+		return err
+	}
+
+	if types.IsHumanTraffic(log.TrafficClass) {
+		s.countSystemStat(types.StatClicks, 1)
+	}
+	return s.incrementClickRollup(log)
 }
 
 func (s *store) GetUrlLogsByUrlId(urlID string) ([]*types.UrlLog, error) {
-	searchLogsQuery := "SELECT id, client_ip, city, country, url_id, visited_at, redirect_status, http_status_code, created_at, updated_at, deleted_at FROM " + CASSANDRA_KEYSPACE + ".url_logs WHERE url_id = ? ORDER BY created_at DESC" 
+	searchLogsQuery := "SELECT " + urlLogColumns + " FROM " + CASSANDRA_KEYSPACE + ".url_logs WHERE url_id = ? ORDER BY created_at DESC"
 	url, err := s.GetUrlByID(urlID)
 	if err != nil {
 		return nil, err
@@ -339,7 +588,7 @@ func (s *store) GetUrlLogsByUrlId(urlID string) ([]*types.UrlLog, error) {
 
 	for {
 		var log types.UrlLog
-		if !iter.Scan(&log.ID, &log.ClientIP, &log.City, &log.Country, &log.UrlID, &log.VisitedAt, &log.RedirectStatus, &log.HttpStatusCode, &log.CreatedAt, &log.UpdatedAt, &log.DeletedAt) {
+		if !iter.Scan(urlLogFields(&log)...) {
 			break
 		}
 		if log.DeletedAt.IsZero() {
@@ -429,13 +678,39 @@ func (s *store) ChangeOtpStatus(otp *types.Otp, status string) error {
 	return s.DBSession.Query(updateOtpQuery, status, otp.ID).Exec()
 }
 
-func (s *store) CountInteractions(urlId string) (int, error) {
-	var count int
-	countInteractonsQuery := "SELECT COUNT(*) FROM " + CASSANDRA_KEYSPACE + ".url_logs WHERE url_id =? ALLOW FILTERING"
-	err := s.DBSession.Query(countInteractonsQuery, urlId).Scan(&count)
+// CountInteractions counts the clicks of a link, only human clicks unless
+// includeBots is set. Clicks are counted from the rollups so they survive
+// the log retention.
+func (s *store) CountInteractions(urlId string, includeBots bool) (int, error) {
+	rollups, err := s.GetClickRollups(urlId)
 	if err != nil {
 		return 0, err
 	}
 
+	count := 0
+	for _, rollup := range rollups {
+		if includeBots || types.IsHumanTraffic(rollup.TrafficClass) {
+			count += int(rollup.Clicks)
+		}
+	}
+
 	return count, nil
 }
+
+// CountInteractionsByVariant groups the clicks of a split tested link by the
+// variant that was served.
+func (s *store) CountInteractionsByVariant(urlId string, includeBots bool) (map[string]int, error) {
+	rollups, err := s.GetClickRollups(urlId)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, rollup := range rollups {
+		if rollup.Variant != "" && (includeBots || types.IsHumanTraffic(rollup.TrafficClass)) {
+			counts[rollup.Variant] += int(rollup.Clicks)
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"log"
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+const systemStatDayFormat = "2006-01-02"
+
+// IncrementSystemStat adds to the total of the metric and counts additions
+// on today as well. A negative delta only takes back from the total, so the
+// daily counts stay counts of what was created that day.
+func (s *store) IncrementSystemStat(metric string, delta int64) error {
+	incrementStatQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".system_stats SET count = count + ? WHERE metric = ? AND day = ?"
+	if delta > 0 {
+		err := s.DBSession.Query(incrementStatQuery, delta, metric, time.Now().UTC().Format(systemStatDayFormat)).Exec()
+		if err != nil {
+			return err
+		}
+	}
+	return s.DBSession.Query(incrementStatQuery, delta, metric, types.StatTotalDay).Exec()
+}
+
+// countSystemStat is IncrementSystemStat for the stores, a lost count is
+// logged rather than failing the write it counts.
+func (s *store) countSystemStat(metric string, delta int64) {
+	if err := s.IncrementSystemStat(metric, delta); err != nil {
+		log.Printf("Unable to count %s: %v", metric, err)
+	}
+}
+
+func (s *store) GetSystemStatTotal(metric string) (int64, error) {
+	var count int64
+	getTotalQuery := "SELECT count FROM " + CASSANDRA_KEYSPACE + ".system_stats WHERE metric = ? AND day = ?"
+	err := s.DBSession.Query(getTotalQuery, metric, types.StatTotalDay).Scan(&count)
+	if err != nil && err != gocql.ErrNotFound {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetSystemStatDays returns the daily counts of the metric between the two
+// days, days without a count are left out.
+func (s *store) GetSystemStatDays(metric string, from, to time.Time) (map[string]int64, error) {
+	getDaysQuery := "SELECT day, count FROM " + CASSANDRA_KEYSPACE + ".system_stats WHERE metric = ? AND day >= ? AND day <= ?"
+	iter := s.DBSession.Query(getDaysQuery, metric, from.UTC().Format(systemStatDayFormat), to.UTC().Format(systemStatDayFormat)).Iter()
+
+	counts := map[string]int64{}
+	var day string
+	var count int64
+	for iter.Scan(&day, &count) {
+		counts[day] = count
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+// SaveTwoFactor inserts or overwrites the two factor settings of a user,
+// there is only one row per user.
+func (s *store) SaveTwoFactor(twoFactor *types.TwoFactor) error {
+	saveTwoFactorQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".two_factors (user_id, secret, status, recovery_codes, last_used_step, created_at, updated_at, enabled_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	if twoFactor.CreatedAt.IsZero() {
+		twoFactor.CreatedAt = time.Now()
+	}
+	twoFactor.UpdatedAt = time.Now()
+	return s.DBSession.Query(saveTwoFactorQuery, twoFactor.UserID, twoFactor.Secret, twoFactor.Status, twoFactor.RecoveryCodes, twoFactor.LastUsedStep, twoFactor.CreatedAt, twoFactor.UpdatedAt, twoFactor.EnabledAt).Exec()
+}
+
+func (s *store) GetTwoFactorByUserID(userID string) (*types.TwoFactor, error) {
+	var twoFactor types.TwoFactor
+	getTwoFactorQuery := "SELECT user_id, secret, status, recovery_codes, last_used_step, created_at, updated_at, enabled_at FROM " + CASSANDRA_KEYSPACE + ".two_factors WHERE user_id = ?"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DBSession.Query(getTwoFactorQuery, userUUID).Consistency(gocql.One).Scan(&twoFactor.UserID, &twoFactor.Secret, &twoFactor.Status, &twoFactor.RecoveryCodes, &twoFactor.LastUsedStep, &twoFactor.CreatedAt, &twoFactor.UpdatedAt, &twoFactor.EnabledAt)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &twoFactor, nil
+}
+
+func (s *store) DeleteTwoFactor(twoFactor *types.TwoFactor) error {
+	deleteTwoFactorQuery := "DELETE FROM " + CASSANDRA_KEYSPACE + ".two_factors WHERE user_id = ?"
+	return s.DBSession.Query(deleteTwoFactorQuery, twoFactor.UserID).Exec()
+}
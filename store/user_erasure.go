@@ -0,0 +1,229 @@
+package store
+
+import (
+	"fmt"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+func (s *store) GetOtpsOfUser(userID string) ([]*types.Otp, error) {
+	getOtpsQuery := "SELECT id, user_id, key, otp, status, created_at, expired_at FROM " + CASSANDRA_KEYSPACE + ".otp WHERE user_id = ? ALLOW FILTERING"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	iter := s.DBSession.Query(getOtpsQuery, userUUID).Iter()
+
+	var otps []*types.Otp
+	for {
+		var otp types.Otp
+		if !iter.Scan(&otp.ID, &otp.UserID, &otp.Key, &otp.Otp, &otp.Status, &otp.CreatedAt, &otp.ExpiredAt) {
+			break
+		}
+		otps = append(otps, &otp)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return otps, nil
+}
+
+// EraseUser removes the user and everything stored about them. Personal
+// links, domains, folders, campaigns and webhooks are deleted with their
+// logs and deliveries, workspace resources stay with the workspace. Audit
+// log entries are kept under a pseudonym.
+func (s *store) EraseUser(user *types.User) error {
+	if user.ID == (gocql.UUID{}) {
+		return fmt.Errorf("No user id found")
+	}
+
+	// Soft deleted users and links were taken off the totals already.
+	liveUrls, err := s.GetURLsOfUser(user.ID.String())
+	if err != nil {
+		return err
+	}
+
+	urlIDs, err := s.personalRowIDs("urls", user.ID)
+	if err != nil {
+		return err
+	}
+	for _, urlID := range urlIDs {
+		if err := s.deleteRows("url_logs", "url_id = ?", urlID); err != nil {
+			return err
+		}
+		if err := s.deleteRows("url_click_rollups", "url_id = ?", urlID); err != nil {
+			return err
+		}
+		if err := s.deleteRows("urls", "id = ?", urlID); err != nil {
+			return err
+		}
+	}
+
+	campaignIDs, err := s.personalRowIDs("campaigns", user.ID)
+	if err != nil {
+		return err
+	}
+	for _, campaignID := range campaignIDs {
+		if err := s.deleteRows("campaign_urls", "campaign_id = ?", campaignID); err != nil {
+			return err
+		}
+		if err := s.deleteRows("campaigns", "id = ?", campaignID); err != nil {
+			return err
+		}
+	}
+
+	webhookIDs, err := s.personalRowIDs("webhooks", user.ID)
+	if err != nil {
+		return err
+	}
+	for _, webhookID := range webhookIDs {
+		if err := s.deleteRows("webhook_deliveries", "webhook_id = ?", webhookID); err != nil {
+			return err
+		}
+		if err := s.deleteRows("webhooks", "id = ?", webhookID); err != nil {
+			return err
+		}
+	}
+
+	for _, table := range []string{"domains", "folders"} {
+		ids, err := s.personalRowIDs(table, user.ID)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := s.deleteRows(table, "id = ?", id); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, table := range []string{"passwords", "otp"} {
+		ids, err := s.userRowIDs(table, user.ID)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := s.deleteRows(table, "id = ?", id); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Revoked keys included, the hash lookup rows go with the keys.
+	apiKeys, err := s.GetApiKeysOfUser(user.ID.String())
+	if err != nil {
+		return err
+	}
+	for _, apiKey := range apiKeys {
+		if err := s.deleteRows("api_keys_by_hash", "hashed_key = ?", apiKey.HashedKey); err != nil {
+			return err
+		}
+		if err := s.deleteRows("api_keys", "id = ?", apiKey.ID); err != nil {
+			return err
+		}
+	}
+
+	identities, err := s.GetOAuthIdentitiesOfUser(user.ID.String())
+	if err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if err := s.deleteRows("oauth_identities", "provider = ? AND subject = ?", identity.Provider, identity.Subject); err != nil {
+			return err
+		}
+	}
+
+	memberships, err := s.GetWorkspaceMembershipsOfUser(user.ID.String())
+	if err != nil {
+		return err
+	}
+	for _, member := range memberships {
+		if err := s.DeleteWorkspaceMember(member); err != nil {
+			return err
+		}
+	}
+
+	invitationIDs, err := s.rowIDs("SELECT id FROM "+CASSANDRA_KEYSPACE+".workspace_invitations WHERE email = ? ALLOW FILTERING", user.Email)
+	if err != nil {
+		return err
+	}
+	for _, invitationID := range invitationIDs {
+		if err := s.deleteRows("workspace_invitations", "id = ?", invitationID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.PseudonymizeAuditLogs(user); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"two_factors", "role_changes", "privacy_settings"} {
+		if err := s.deleteRows(table, "user_id = ?", user.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.deleteRows("users", "id = ?", user.ID); err != nil {
+		return err
+	}
+
+	if len(liveUrls) > 0 {
+		s.countSystemStat(types.StatLinks, -int64(len(liveUrls)))
+	}
+	if user.Status != types.UserStatusDeleted {
+		s.countSystemStat(types.StatUsers, -1)
+		if user.VerifiedEmail != "" && user.IsEmailVerified() {
+			s.countSystemStat(types.StatVerifiedUsers, -1)
+		}
+	}
+	return nil
+}
+
+func (s *store) deleteRows(table, where string, values ...interface{}) error {
+	return s.DBSession.Query("DELETE FROM "+CASSANDRA_KEYSPACE+"."+table+" WHERE "+where, values...).Exec()
+}
+
+// userRowIDs returns the ids of every row of the user in the table, deleted
+// ones included.
+func (s *store) userRowIDs(table string, userID gocql.UUID) ([]gocql.UUID, error) {
+	return s.rowIDs("SELECT id FROM "+CASSANDRA_KEYSPACE+"."+table+" WHERE user_id = ? ALLOW FILTERING", userID)
+}
+
+// personalRowIDs is userRowIDs without the rows the user created in a
+// workspace.
+func (s *store) personalRowIDs(table string, userID gocql.UUID) ([]gocql.UUID, error) {
+	iter := s.DBSession.Query("SELECT id, workspace_id FROM "+CASSANDRA_KEYSPACE+"."+table+" WHERE user_id = ? ALLOW FILTERING", userID).Iter()
+
+	var ids []gocql.UUID
+	var id, workspaceID gocql.UUID
+	for iter.Scan(&id, &workspaceID) {
+		if workspaceID == (gocql.UUID{}) {
+			ids = append(ids, id)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (s *store) rowIDs(query string, values ...interface{}) ([]gocql.UUID, error) {
+	iter := s.DBSession.Query(query, values...).Iter()
+
+	var ids []gocql.UUID
+	var id gocql.UUID
+	for iter.Scan(&id) {
+		ids = append(ids, id)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+const webhookColumns = "id, user_id, workspace_id, url, secret, events, status, failure_count, disabled_at, created_at, updated_at, deleted_at"
+
+func webhookFields(webhook *types.Webhook) []interface{} {
+	return []interface{}{&webhook.ID, &webhook.UserID, &webhook.WorkspaceID, &webhook.URL, &webhook.Secret, &webhook.Events, &webhook.Status, &webhook.FailureCount, &webhook.DisabledAt, &webhook.CreatedAt, &webhook.UpdatedAt, &webhook.DeletedAt}
+}
+
+const webhookDeliveryColumns = "id, webhook_id, event_id, event, payload, attempt, manual, status_code, response, error, succeeded, duration_ms, created_at"
+
+func webhookDeliveryFields(delivery *types.WebhookDelivery) []interface{} {
+	return []interface{}{&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.Event, &delivery.Payload, &delivery.Attempt, &delivery.Manual, &delivery.StatusCode, &delivery.Response, &delivery.Error, &delivery.Succeeded, &delivery.DurationMs, &delivery.CreatedAt}
+}
+
+func (s *store) CreateWebhook(webhook *types.Webhook) error {
+	createWebhookQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".webhooks (id, user_id, workspace_id, url, secret, events, status, failure_count, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	webhook.ID, webhook.CreatedAt, webhook.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+	return s.DBSession.Query(createWebhookQuery, webhook.ID, webhook.UserID, webhook.WorkspaceID, webhook.URL, webhook.Secret, webhook.Events, webhook.Status, webhook.FailureCount, webhook.CreatedAt, webhook.UpdatedAt).Exec()
+}
+
+func (s *store) GetWebhookByID(id string) (*types.Webhook, error) {
+	var webhook types.Webhook
+	getWebhookQuery := "SELECT " + webhookColumns + " FROM " + CASSANDRA_KEYSPACE + ".webhooks WHERE id = ?"
+	webhookUUID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DBSession.Query(getWebhookQuery, webhookUUID).Consistency(gocql.One).Scan(webhookFields(&webhook)...)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !webhook.DeletedAt.IsZero() {
+		return nil, nil
+	}
+
+	return &webhook, nil
+}
+
+func (s *store) GetWebhooksOfUser(userID string) ([]*types.Webhook, error) {
+	getWebhooksQuery := "SELECT " + webhookColumns + " FROM " + CASSANDRA_KEYSPACE + ".webhooks WHERE user_id = ? ALLOW FILTERING"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.scanWebhooks(s.DBSession.Query(getWebhooksQuery, userUUID).Iter())
+	if err != nil {
+		return nil, err
+	}
+
+	var personalWebhooks []*types.Webhook
+	for _, webhook := range webhooks {
+		if webhook.WorkspaceID == (gocql.UUID{}) {
+			personalWebhooks = append(personalWebhooks, webhook)
+		}
+	}
+	return personalWebhooks, nil
+}
+
+func (s *store) GetWebhooksOfWorkspace(workspaceID string) ([]*types.Webhook, error) {
+	getWebhooksQuery := "SELECT " + webhookColumns + " FROM " + CASSANDRA_KEYSPACE + ".webhooks WHERE workspace_id = ? ALLOW FILTERING"
+	workspaceUUID, err := gocql.ParseUUID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanWebhooks(s.DBSession.Query(getWebhooksQuery, workspaceUUID).Iter())
+}
+
+func (s *store) scanWebhooks(iter *gocql.Iter) ([]*types.Webhook, error) {
+	var webhooks []*types.Webhook
+	for {
+		var webhook types.Webhook
+		if !iter.Scan(webhookFields(&webhook)...) {
+			break
+		}
+		if webhook.DeletedAt.IsZero() {
+			webhooks = append(webhooks, &webhook)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (s *store) UpdateWebhook(webhook *types.Webhook) error {
+	updateWebhookQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".webhooks SET url = ?, events = ?, status = ?, failure_count = ?, disabled_at = ?, updated_at = ? WHERE id = ?"
+	webhook.UpdatedAt = time.Now()
+	return s.DBSession.Query(updateWebhookQuery, webhook.URL, webhook.Events, webhook.Status, webhook.FailureCount, webhook.DisabledAt, webhook.UpdatedAt, webhook.ID).Exec()
+}
+
+func (s *store) DeleteWebhook(webhook *types.Webhook) error {
+	deleteWebhookQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".webhooks SET deleted_at = ? WHERE id = ?"
+	return s.DBSession.Query(deleteWebhookQuery, time.Now(), webhook.ID).Exec()
+}
+
+func (s *store) CreateWebhookDelivery(delivery *types.WebhookDelivery) error {
+	createDeliveryQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".webhook_deliveries (" + webhookDeliveryColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	delivery.ID, delivery.CreatedAt = gocql.TimeUUID(), time.Now()
+	return s.DBSession.Query(createDeliveryQuery, delivery.ID, delivery.WebhookID, delivery.EventID, delivery.Event, delivery.Payload, delivery.Attempt, delivery.Manual, delivery.StatusCode, delivery.Response, delivery.Error, delivery.Succeeded, delivery.DurationMs, delivery.CreatedAt).Exec()
+}
+
+func (s *store) GetWebhookDeliveryByID(webhookID gocql.UUID, id string) (*types.WebhookDelivery, error) {
+	var delivery types.WebhookDelivery
+	getDeliveryQuery := "SELECT " + webhookDeliveryColumns + " FROM " + CASSANDRA_KEYSPACE + ".webhook_deliveries WHERE webhook_id = ? AND id = ?"
+	deliveryUUID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DBSession.Query(getDeliveryQuery, webhookID, deliveryUUID).Consistency(gocql.One).Scan(webhookDeliveryFields(&delivery)...)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// GetWebhookDeliveries returns the latest deliveries of the webhook first.
+func (s *store) GetWebhookDeliveries(webhookID gocql.UUID, limit int) ([]*types.WebhookDelivery, error) {
+	getDeliveriesQuery := "SELECT " + webhookDeliveryColumns + " FROM " + CASSANDRA_KEYSPACE + ".webhook_deliveries WHERE webhook_id = ? LIMIT ?"
+	iter := s.DBSession.Query(getDeliveriesQuery, webhookID, limit).Iter()
+
+	deliveries := []*types.WebhookDelivery{}
+	for {
+		var delivery types.WebhookDelivery
+		if !iter.Scan(webhookDeliveryFields(&delivery)...) {
+			break
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+)
+
+func (s *store) CreateWorkspace(workspace *types.Workspace) error {
+	createWorkspaceQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".workspaces (id, name, owner_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?)"
+	workspace.ID, workspace.CreatedAt, workspace.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+	return s.DBSession.Query(createWorkspaceQuery, workspace.ID, workspace.Name, workspace.OwnerID, workspace.CreatedAt, workspace.UpdatedAt).Exec()
+}
+
+func (s *store) GetWorkspaceByID(id string) (*types.Workspace, error) {
+	var workspace types.Workspace
+	getWorkspaceQuery := "SELECT id, name, owner_id, created_at, updated_at, deleted_at FROM " + CASSANDRA_KEYSPACE + ".workspaces WHERE id = ?"
+	workspaceUUID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DBSession.Query(getWorkspaceQuery, workspaceUUID).Consistency(gocql.One).Scan(&workspace.ID, &workspace.Name, &workspace.OwnerID, &workspace.CreatedAt, &workspace.UpdatedAt, &workspace.DeletedAt)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !workspace.DeletedAt.IsZero() {
+		return nil, nil
+	}
+
+	return &workspace, nil
+}
+
+func (s *store) DeleteWorkspace(workspace *types.Workspace) error {
+	deleteWorkspaceQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".workspaces SET deleted_at = ? WHERE id = ?"
+	return s.DBSession.Query(deleteWorkspaceQuery, time.Now(), workspace.ID).Exec()
+}
+
+func (s *store) SaveWorkspaceMember(member *types.WorkspaceMember) error {
+	saveMemberQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".workspace_members (workspace_id, user_id, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?)"
+	if member.CreatedAt.IsZero() {
+		member.CreatedAt = time.Now()
+	}
+	member.UpdatedAt = time.Now()
+	return s.DBSession.Query(saveMemberQuery, member.WorkspaceID, member.UserID, member.Role, member.CreatedAt, member.UpdatedAt).Exec()
+}
+
+func (s *store) GetWorkspaceMember(workspaceID, userID string) (*types.WorkspaceMember, error) {
+	var member types.WorkspaceMember
+	getMemberQuery := "SELECT workspace_id, user_id, role, created_at, updated_at FROM " + CASSANDRA_KEYSPACE + ".workspace_members WHERE workspace_id = ? AND user_id = ?"
+	workspaceUUID, err := gocql.ParseUUID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.DBSession.Query(getMemberQuery, workspaceUUID, userUUID).Consistency(gocql.One).Scan(&member.WorkspaceID, &member.UserID, &member.Role, &member.CreatedAt, &member.UpdatedAt)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (s *store) GetWorkspaceMembers(workspaceID string) ([]*types.WorkspaceMember, error) {
+	getMembersQuery := "SELECT workspace_id, user_id, role, created_at, updated_at FROM " + CASSANDRA_KEYSPACE + ".workspace_members WHERE workspace_id = ?"
+	workspaceUUID, err := gocql.ParseUUID(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanWorkspaceMembers(s.DBSession.Query(getMembersQuery, workspaceUUID).Iter())
+}
+
+func (s *store) GetWorkspaceMembershipsOfUser(userID string) ([]*types.WorkspaceMember, error) {
+	getMembershipsQuery := "SELECT workspace_id, user_id, role, created_at, updated_at FROM " + CASSANDRA_KEYSPACE + ".workspace_members WHERE user_id = ? ALLOW FILTERING"
+	userUUID, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.scanWorkspaceMembers(s.DBSession.Query(getMembershipsQuery, userUUID).Iter())
+}
+
+func (s *store) scanWorkspaceMembers(iter *gocql.Iter) ([]*types.WorkspaceMember, error) {
+	var members []*types.WorkspaceMember
+	for {
+		var member types.WorkspaceMember
+		if !iter.Scan(&member.WorkspaceID, &member.UserID, &member.Role, &member.CreatedAt, &member.UpdatedAt) {
+			break
+		}
+		members = append(members, &member)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (s *store) DeleteWorkspaceMember(member *types.WorkspaceMember) error {
+	deleteMemberQuery := "DELETE FROM " + CASSANDRA_KEYSPACE + ".workspace_members WHERE workspace_id = ? AND user_id = ?"
+	return s.DBSession.Query(deleteMemberQuery, member.WorkspaceID, member.UserID).Exec()
+}
+
+func (s *store) CreateWorkspaceInvitation(invitation *types.WorkspaceInvitation) error {
+	createInvitationQuery := "INSERT INTO " + CASSANDRA_KEYSPACE + ".workspace_invitations (id, workspace_id, email, role, hashed_token, status, invited_by, expires_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	invitation.ID, invitation.CreatedAt, invitation.UpdatedAt = gocql.TimeUUID(), time.Now(), time.Now()
+	return s.DBSession.Query(createInvitationQuery, invitation.ID, invitation.WorkspaceID, invitation.Email, invitation.Role, invitation.HashedToken, invitation.Status, invitation.InvitedBy, invitation.ExpiresAt, invitation.CreatedAt, invitation.UpdatedAt).Exec()
+}
+
+func (s *store) GetWorkspaceInvitationByHash(hashedToken string) (*types.WorkspaceInvitation, error) {
+	var invitation types.WorkspaceInvitation
+	getInvitationQuery := "SELECT id, workspace_id, email, role, hashed_token, status, invited_by, expires_at, created_at, updated_at FROM " + CASSANDRA_KEYSPACE + ".workspace_invitations WHERE hashed_token = ? ALLOW FILTERING"
+	err := s.DBSession.Query(getInvitationQuery, hashedToken).Consistency(gocql.One).Scan(&invitation.ID, &invitation.WorkspaceID, &invitation.Email, &invitation.Role, &invitation.HashedToken, &invitation.Status, &invitation.InvitedBy, &invitation.ExpiresAt, &invitation.CreatedAt, &invitation.UpdatedAt)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+func (s *store) ChangeWorkspaceInvitationStatus(invitation *types.WorkspaceInvitation, status string) error {
+	updateInvitationQuery := "UPDATE " + CASSANDRA_KEYSPACE + ".workspace_invitations SET status = ?, updated_at = ? WHERE id = ?"
+	invitation.Status, invitation.UpdatedAt = status, time.Now()
+	return s.DBSession.Query(updateInvitationQuery, invitation.Status, invitation.UpdatedAt, invitation.ID).Exec()
+}
@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -14,7 +15,7 @@ func PerformAync(task *asynq.Task) {
 
 	_, err := client.Enqueue(task, asynq.ProcessAt(time.Now()))
 	if err != nil {
-		fmt.Println("Failed to enqueue log task: %v", err)
+		fmt.Printf("Failed to enqueue log task: %v\n", err)
 	}
 }
 
@@ -24,7 +25,7 @@ func PerformNow(task *asynq.Task) {
 
 	_, err := client.Enqueue(task)
 	if err != nil {
-		fmt.Println("Failed to enqueue log task: %v", err)
+		fmt.Printf("Failed to enqueue log task: %v\n", err)
 	}
 }
 
@@ -34,7 +35,7 @@ func PerformAfter(task *asynq.Task, duration time.Duration) {
 
 	_, err := client.Enqueue(task, asynq.ProcessIn(duration))
 	if err != nil {
-		fmt.Println("Failed to enqueue log task: %v", err)
+		fmt.Printf("Failed to enqueue log task: %v\n", err)
 	}
 }
 
@@ -44,6 +45,18 @@ func PerformLater(task *asynq.Task, time time.Time) {
 
 	_, err := client.Enqueue(task, asynq.ProcessAt(time))
 	if err != nil {
-		fmt.Println("Failed to enqueue log task: %v", err)
+		fmt.Printf("Failed to enqueue log task: %v\n", err)
+	}
+}
+
+// PerformOnce enqueues the task unless a task with the same id was enqueued
+// within the retention period.
+func PerformOnce(task *asynq.Task, taskID string, retention time.Duration) {
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: os.Getenv("REDIS_ADDR")})
+	defer client.Close()
+
+	_, err := client.Enqueue(task, asynq.TaskID(taskID), asynq.Retention(retention))
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		fmt.Printf("Failed to enqueue task: %v\n", err)
 	}
 }
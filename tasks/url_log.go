@@ -3,6 +3,7 @@ package tasks
 import (
 	"encoding/json"
 	"time"
+	"urllite/types"
 
 	"github.com/hibiken/asynq"
 )
@@ -11,26 +12,36 @@ type urlLog struct {
 }
 
 type UrlLog interface {
-	CreateLog(urlID, clientIP, country, city string, visitedAt time.Time) (*asynq.Task, error)
+	CreateLog(log *types.UrlLog) (*asynq.Task, error)
+	PurgeLogs() *asynq.Task
 }
 
-const TypeCreateUrlLog = "urllog:create"
+const (
+	TypeCreateUrlLog = "urllog:create"
+	TypePurgeUrlLogs = "urllog:purge"
+
+	// UrlLogQueue holds the click logs apart from the other tasks so its
+	// depth shows how far the worker is behind on clicks.
+	UrlLogQueue = "url_logs"
+)
 
 func NewUrlLogTask() UrlLog {
 	return &urlLog{}
 }
 
-func (ul *urlLog) CreateLog(urlID, clientIP, country, city string, visitedAt time.Time) (*asynq.Task, error) {
-	payload, err := json.Marshal(map[string]interface{}{
-		"url_id":    urlID,
-		"client_ip": clientIP,
-		"country":   country,
-		"city":      city,
-	})
-
+// CreateLog carries the click as the privacy settings allow it to be stored,
+// the worker fills in the destination status and stores it.
+func (ul *urlLog) CreateLog(log *types.UrlLog) (*asynq.Task, error) {
+	payload, err := json.Marshal(log)
 	if err != nil {
 		return nil, err
 	}
 
-	return asynq.NewTask(TypeCreateUrlLog, payload), nil
+	return asynq.NewTask(TypeCreateUrlLog, payload, asynq.Queue(UrlLogQueue)), nil
+}
+
+// PurgeLogs rolls up and deletes the logs older than the retention of their
+// owner, the worker schedules it daily.
+func (ul *urlLog) PurgeLogs() *asynq.Task {
+	return asynq.NewTask(TypePurgeUrlLogs, nil, asynq.MaxRetry(3), asynq.Timeout(time.Hour))
 }
@@ -0,0 +1,57 @@
+package tasks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	TypeExportUserData = "user:export"
+	TypePurgeUserData  = "user:purge"
+)
+
+// UserDataExport asks the worker to archive the data of the user and mail
+// them a download link.
+type UserDataExport struct {
+	UserID gocql.UUID `json:"user_id"`
+}
+
+// UserDataPurge erases a deleted user. It carries the email because the
+// deleted user can no longer be looked up.
+type UserDataPurge struct {
+	UserID gocql.UUID `json:"user_id"`
+	Email  string     `json:"email"`
+}
+
+type userData struct {
+}
+
+type UserData interface {
+	ExportData(export *UserDataExport) (*asynq.Task, error)
+	PurgeData(purge *UserDataPurge) (*asynq.Task, error)
+}
+
+func NewUserDataTask() UserData {
+	return &userData{}
+}
+
+func (ud *userData) ExportData(export *UserDataExport) (*asynq.Task, error) {
+	payload, err := json.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+
+	return asynq.NewTask(TypeExportUserData, payload, asynq.MaxRetry(3), asynq.Timeout(30*time.Minute)), nil
+}
+
+func (ud *userData) PurgeData(purge *UserDataPurge) (*asynq.Task, error) {
+	payload, err := json.Marshal(purge)
+	if err != nil {
+		return nil, err
+	}
+
+	return asynq.NewTask(TypePurgeUserData, payload, asynq.MaxRetry(10), asynq.Timeout(30*time.Minute)), nil
+}
@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"encoding/json"
+	"time"
+	"urllite/types"
+
+	"github.com/gocql/gocql"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	TypeDispatchWebhookEvent = "webhook:dispatch"
+	TypeDeliverWebhook       = "webhook:deliver"
+
+	// WebhookMaxRetry with WebhookRetryDelay keeps retrying a delivery for
+	// about a day.
+	WebhookMaxRetry = 12
+)
+
+// WebhookDispatch carries an event to the worker, which posts it to every
+// webhook of the owner subscribed to it.
+type WebhookDispatch struct {
+	UserID      gocql.UUID         `json:"user_id"`
+	WorkspaceID gocql.UUID         `json:"workspace_id"`
+	Event       types.WebhookEvent `json:"event"`
+}
+
+// WebhookDelivery posts one event to one webhook. Manual deliveries are
+// redeliveries asked for by the owner and are tried once.
+type WebhookDelivery struct {
+	WebhookID gocql.UUID         `json:"webhook_id"`
+	Event     types.WebhookEvent `json:"event"`
+	Manual    bool               `json:"manual"`
+}
+
+type webhook struct {
+}
+
+type Webhook interface {
+	DispatchEvent(dispatch *WebhookDispatch) (*asynq.Task, error)
+	DeliverEvent(delivery *WebhookDelivery) (*asynq.Task, error)
+}
+
+func NewWebhookTask() Webhook {
+	return &webhook{}
+}
+
+func (w *webhook) DispatchEvent(dispatch *WebhookDispatch) (*asynq.Task, error) {
+	payload, err := json.Marshal(dispatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return asynq.NewTask(TypeDispatchWebhookEvent, payload), nil
+}
+
+func (w *webhook) DeliverEvent(delivery *WebhookDelivery) (*asynq.Task, error) {
+	payload, err := json.Marshal(delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	maxRetry := WebhookMaxRetry
+	if delivery.Manual {
+		maxRetry = 0
+	}
+	return asynq.NewTask(TypeDeliverWebhook, payload, asynq.MaxRetry(maxRetry), asynq.Timeout(time.Minute)), nil
+}
+
+// WebhookRetryDelay backs off exponentially from 30 seconds up to 6 hours.
+func WebhookRetryDelay(retried int) time.Duration {
+	delay := 30 * time.Second
+	for i := 0; i < retried && delay < 6*time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, 6*time.Hour)
+}
@@ -3,17 +3,16 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"time"
 	"urllite/config/env"
+	"urllite/service"
 	"urllite/store"
 	"urllite/tasks"
 	"urllite/types"
 
-	"github.com/gocql/gocql"
 	"github.com/hibiken/asynq"
 )
 
@@ -21,57 +20,157 @@ func main() {
 	env.EnableEnvVariables()
 	srv := asynq.NewServer(
 		asynq.RedisClientOpt{Addr: os.Getenv("REDIS_ADDR")},
-		asynq.Config{Concurrency: 10},
+		asynq.Config{
+			Concurrency: 10,
+			Queues: map[string]int{
+				tasks.UrlLogQueue: 5,
+				"default":         5,
+			},
+			RetryDelayFunc: func(retried int, err error, task *asynq.Task) time.Duration {
+				if task.Type() == tasks.TypeDeliverWebhook {
+					return tasks.WebhookRetryDelay(retried)
+				}
+				return asynq.DefaultRetryDelayFunc(retried, err, task)
+			},
+			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
+				service.RecordError("task "+task.Type(), err.Error())
+			}),
+		},
 	)
 	mux := asynq.NewServeMux()
+	webhookService := service.NewWebhookService()
+	liveClickService := service.NewLiveClickService()
+	userDataService := service.NewUserDataService()
 	mux.HandleFunc(tasks.TypeCreateUrlLog, func(ctx context.Context, task *asynq.Task) error {
-		var p map[string]interface{}
-		if err := json.Unmarshal(task.Payload(), &p); err != nil {
+		var urlLog types.UrlLog
+		if err := json.Unmarshal(task.Payload(), &urlLog); err != nil {
 			return err
 		}
 
-		urlId, ok := p["url_id"].(string)
-		if !ok {
-			fmt.Println("Something went wrong for url id")
-		}
-		clientIP, ok := p["client_ip"].(string)
-		if !ok {
-			fmt.Println("Something went wrong for client ip: " + clientIP)
-		}
-		city, ok := p["city"].(string)
-		if !ok {
-			fmt.Println("Something went wrong for city: " + city)
-		}
-		country, ok := p["country"].(string)
-		if !ok {
-			fmt.Println("Something went wrong for country: " + country)
-		}
-
 		// Create log in DB
 		s := store.NewStore()
-		url, err := s.GetUrlByID(urlId)
+		url, err := s.GetUrlByID(urlLog.UrlID.String())
 		if err != nil {
 			return err
 		}
+		if url == nil {
+			return nil
+		}
 
-		urlidUUID, err := gocql.ParseUUID(urlId)
-		if err != nil {
-			return err
+		if urlLog.VisitedAt.IsZero() {
+			urlLog.VisitedAt = time.Now()
+		}
+		if urlLog.Destination == "" {
+			urlLog.Destination = url.LongUrl
 		}
-		urlLog := &types.UrlLog{UrlID: urlidUUID, ClientIP: clientIP, VisitedAt: time.Now(), City: city, Country: country}
-		resp, err := http.Get(url.LongUrl)
+		resp, err := http.Get(urlLog.Destination)
 		if err != nil {
 			urlLog.HttpStatusCode = http.StatusInternalServerError
 			urlLog.RedirectStatus = err.Error()
 		} else {
+			resp.Body.Close()
 			urlLog.HttpStatusCode = resp.StatusCode
 			urlLog.RedirectStatus = resp.Status
 		}
 
-		s.CreateUrlLog(urlLog)
+		// The click was classified and anonymized before it was queued.
+		settings, err := service.EffectivePrivacySettings(s, url.UserID.String())
+		if err != nil {
+			return err
+		}
+
+		err = s.CreateUrlLog(&urlLog, settings.Retention())
+		if err != nil {
+			return err
+		}
+		service.RecordTopClick(url, &urlLog)
+		if err := liveClickService.PublishClick(url, &urlLog); err != nil {
+			log.Printf("Unable to publish click to live streams: %v", err)
+		}
+
+		click := map[string]interface{}{"url_id": url.ID, "short_url": url.ShortUrl, "log": urlLog}
+		service.PublishWebhookEvent(types.WebhookEventUrlClicked, url.UserID, url.WorkspaceID, click)
+		if urlLog.HttpStatusCode >= http.StatusBadRequest {
+			// Report a broken destination at most once a day per link.
+			service.PublishWebhookEventOnce(types.WebhookEventUrlBroken, url.UserID, url.WorkspaceID, click, url.ID.String()+":"+urlLog.VisitedAt.UTC().Format("2006-01-02"), 24*time.Hour)
+		}
 		return nil
 	})
 
+	mux.HandleFunc(tasks.TypePurgeUrlLogs, func(ctx context.Context, task *asynq.Task) error {
+		s := store.NewStore()
+		urls, err := s.GetAllURLs()
+		if err != nil {
+			return err
+		}
+
+		// Logs are written with a TTL, this catches the ones written before
+		// the retention was set or shortened.
+		retentions := map[string]time.Duration{}
+		for _, url := range urls {
+			if err := s.RollUpUrlLogs(url.ID); err != nil {
+				return err
+			}
+			owner := url.UserID.String()
+			retention, ok := retentions[owner]
+			if !ok {
+				settings, err := service.EffectivePrivacySettings(s, owner)
+				if err != nil {
+					return err
+				}
+				retention = settings.Retention()
+				retentions[owner] = retention
+			}
+			if retention > 0 {
+				if err := s.PurgeUrlLogs(url.ID, time.Now().Add(-retention)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+
+	mux.HandleFunc(tasks.TypeExportUserData, func(ctx context.Context, task *asynq.Task) error {
+		var export tasks.UserDataExport
+		if err := json.Unmarshal(task.Payload(), &export); err != nil {
+			return err
+		}
+		return userDataService.BuildExport(export.UserID.String())
+	})
+
+	mux.HandleFunc(tasks.TypePurgeUserData, func(ctx context.Context, task *asynq.Task) error {
+		var purge tasks.UserDataPurge
+		if err := json.Unmarshal(task.Payload(), &purge); err != nil {
+			return err
+		}
+		return store.NewStore().EraseUser(&types.User{ID: purge.UserID, Email: purge.Email})
+	})
+
+	mux.HandleFunc(tasks.TypeDispatchWebhookEvent, func(ctx context.Context, task *asynq.Task) error {
+		var dispatch tasks.WebhookDispatch
+		if err := json.Unmarshal(task.Payload(), &dispatch); err != nil {
+			return err
+		}
+		return webhookService.Dispatch(&dispatch)
+	})
+
+	mux.HandleFunc(tasks.TypeDeliverWebhook, func(ctx context.Context, task *asynq.Task) error {
+		var delivery tasks.WebhookDelivery
+		if err := json.Unmarshal(task.Payload(), &delivery); err != nil {
+			return err
+		}
+		return webhookService.Deliver(ctx, &delivery)
+	})
+
+	scheduler := asynq.NewScheduler(asynq.RedisClientOpt{Addr: os.Getenv("REDIS_ADDR")}, nil)
+	if _, err := scheduler.Register("0 3 * * *", tasks.NewUrlLogTask().PurgeLogs()); err != nil {
+		log.Fatalf("Unable to schedule log purge: %v", err)
+	}
+	if err := scheduler.Start(); err != nil {
+		log.Fatalf("Asynq scheduler error: %v", err)
+	}
+	defer scheduler.Shutdown()
+
 	if err := srv.Run(mux); err != nil {
 		log.Fatalf("Asynq server error: %v", err)
 	}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	AbuseCategoryPhishing = "phishing"
+	AbuseCategoryMalware  = "malware"
+	AbuseCategorySpam     = "spam"
+)
+
+var AbuseCategories = []string{AbuseCategoryPhishing, AbuseCategoryMalware, AbuseCategorySpam}
+
+// Moderation states of a report, every state but pending records the action
+// the moderator took.
+const (
+	AbuseReportPending        = "pending"
+	AbuseReportDismissed      = "dismissed"
+	AbuseReportLinkDisabled   = "link_disabled"
+	AbuseReportOwnerSuspended = "owner_suspended"
+)
+
+// Actions a moderator can take on a report.
+const (
+	AbuseActionDismiss      = "dismiss"
+	AbuseActionDisableLink  = "disable_link"
+	AbuseActionSuspendOwner = "suspend_owner"
+)
+
+type AbuseReport struct {
+	ID            gocql.UUID `json:"id"`
+	UrlID         gocql.UUID `json:"url_id"`
+	Domain        string     `json:"domain"`
+	ShortUrl      string     `json:"short_url"`
+	Destination   string     `json:"destination"`
+	Category      string     `json:"category"`
+	Details       string     `json:"details"`
+	ReporterEmail string     `json:"reporter_email"`
+	Status        string     `json:"status"`
+	ReviewedBy    gocql.UUID `json:"reviewed_by"`
+	ReviewNote    string     `json:"review_note"`
+	ReviewedAt    time.Time  `json:"reviewed_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func IsValidAbuseCategory(category string) bool {
+	for _, c := range AbuseCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	ApiKeyPrefix = "ulk_"
+
+	ScopeUrlsRead      = "urls:read"
+	ScopeUrlsWrite     = "urls:write"
+	ScopeAnalyticsRead = "analytics:read"
+)
+
+var ApiKeyScopes = []string{ScopeUrlsRead, ScopeUrlsWrite, ScopeAnalyticsRead}
+
+type ApiKey struct {
+	ID         gocql.UUID `json:"id"`
+	UserID     gocql.UUID `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	HashedKey  string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	Status     string     `json:"status"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+func (k *ApiKey) IsActive() bool {
+	if k.Status != "active" || !k.RevokedAt.IsZero() {
+		return false
+	}
+	return k.ExpiresAt.IsZero() || k.ExpiresAt.After(time.Now())
+}
+
+func (k *ApiKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidApiKeyScope(scope string) bool {
+	for _, s := range ApiKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Actions kept in the audit log.
+const (
+	AuditLoginSucceeded      = "login.succeeded"
+	AuditLoginFailed         = "login.failed"
+	AuditPasswordChanged     = "password.changed"
+	AuditPasswordReset       = "password.reset"
+	AuditOtpSent             = "otp.sent"
+	AuditUserMadeAdmin       = "user.made_admin"
+	AuditUserDeleted         = "user.deleted"
+	AuditUserSuspended       = "user.suspended"
+	AuditUserReinstated      = "user.reinstated"
+	AuditRoleAssigned        = "role.assigned"
+	AuditRoleRevoked         = "role.revoked"
+	AuditUrlCreated          = "url.created"
+	AuditUrlUpdated          = "url.updated"
+	AuditUrlDeleted          = "url.deleted"
+	AuditLockoutCleared      = "lockout.cleared"
+	AuditAbuseReportReviewed = "abuse_report.reviewed"
+	AuditTwoFactorEnabled    = "two_factor.enabled"
+	AuditTwoFactorDisabled   = "two_factor.disabled"
+	AuditApiKeyCreated       = "api_key.created"
+	AuditApiKeyRevoked       = "api_key.revoked"
+)
+
+// Kinds of target an entry is about. A failed login for an unknown address
+// targets the email, there is no user to point at.
+const (
+	AuditTargetUser        = "user"
+	AuditTargetUrl         = "url"
+	AuditTargetEmail       = "email"
+	AuditTargetAbuseReport = "abuse_report"
+	AuditTargetApiKey      = "api_key"
+)
+
+const (
+	DefaultAuditLogLimit  = 100
+	MaxAuditLogLimit      = 1000
+	DefaultAuditLogPeriod = 7 * 24 * time.Hour
+	// Every day of the period is a partition to read.
+	MaxAuditLogPeriod = 366 * 24 * time.Hour
+)
+
+// AuditChange is the value of a field before and after the action, nil on
+// the side where the field did not exist.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges maps the changed fields to their values.
+type AuditChanges map[string]AuditChange
+
+func (a AuditChanges) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(map[string]AuditChange(a))
+}
+
+func (a *AuditChanges) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	if len(data) == 0 {
+		*a = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*map[string]AuditChange)(a))
+}
+
+// AuditLog is an entry of the append-only audit trail, partitioned by the
+// day it was recorded on. The actor is empty when nobody was logged in, a
+// failed login for one.
+type AuditLog struct {
+	Day        string       `json:"day"`
+	ID         gocql.UUID   `json:"id"`
+	ActorID    gocql.UUID   `json:"actor_id"`
+	Action     string       `json:"action"`
+	TargetType string       `json:"target_type"`
+	TargetID   string       `json:"target_id"`
+	IP         string       `json:"ip"`
+	UserAgent  string       `json:"user_agent"`
+	Detail     string       `json:"detail"`
+	Changes    AuditChanges `json:"changes"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditLogFilter narrows the audit log to an actor or a target within a
+// period, newest entries first.
+type AuditLogFilter struct {
+	ActorID  string
+	TargetID string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
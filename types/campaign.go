@@ -0,0 +1,66 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Campaign goal metrics.
+const (
+	CampaignGoalClicks    = "clicks"
+	CampaignGoalCountries = "countries"
+)
+
+// Campaign groups links of one owner, the user for personal links or the
+// workspace for workspace links, and reports on them together.
+type Campaign struct {
+	ID          gocql.UUID     `json:"id"`
+	Name        string         `json:"name"`
+	UserID      gocql.UUID     `json:"user_id"`
+	WorkspaceID gocql.UUID     `json:"workspace_id"`
+	StartsAt    time.Time      `json:"starts_at"`
+	EndsAt      time.Time      `json:"ends_at"`
+	BudgetNote  string         `json:"budget_note"`
+	Goals       map[string]int `json:"goals"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func IsValidCampaignGoal(goal string) bool {
+	return goal == CampaignGoalClicks || goal == CampaignGoalCountries
+}
+
+// InPeriod reports whether a click happened while the campaign ran, open
+// ended campaigns count everything on their side.
+func (c *Campaign) InPeriod(t time.Time) bool {
+	if !c.StartsAt.IsZero() && t.Before(c.StartsAt) {
+		return false
+	}
+	if !c.EndsAt.IsZero() && t.After(c.EndsAt) {
+		return false
+	}
+	return true
+}
+
+type CampaignReport struct {
+	Clicks         int                      `json:"clicks"`
+	UniqueVisitors int64                    `json:"unique_visitors"`
+	Daily          []*DailyClicks           `json:"daily"`
+	TopLinks       []*LinkInteractions      `json:"top_links"`
+	Countries      map[string]int           `json:"countries"`
+	Channels       map[string]int           `json:"channels"`
+	Goals          map[string]*GoalProgress `json:"goals"`
+}
+
+type DailyClicks struct {
+	Date   string `json:"date"`
+	Clicks int    `json:"clicks"`
+}
+
+type GoalProgress struct {
+	Target int `json:"target"`
+	Actual int `json:"actual"`
+}
@@ -0,0 +1,53 @@
+package types
+
+import (
+	"encoding/json"
+
+	"github.com/gocql/gocql"
+)
+
+// AppLink opens a link inside a mobile app. URI uses the app's own scheme,
+// UniversalLink is the https link the OS hands to the app when installed and
+// StoreURL points at the app store listing.
+type AppLink struct {
+	URI           string `json:"uri"`
+	UniversalLink string `json:"universal_link"`
+	StoreURL      string `json:"store_url"`
+}
+
+// DeepLink holds the app links of a link per platform.
+type DeepLink struct {
+	IOS     *AppLink `json:"ios,omitempty"`
+	Android *AppLink `json:"android,omitempty"`
+}
+
+func (d DeepLink) IsZero() bool {
+	return d.IOS == nil && d.Android == nil
+}
+
+// ForOS returns the app link for the visitor platform, nil when the link has
+// no app configured for it.
+func (d DeepLink) ForOS(os string) *AppLink {
+	switch os {
+	case "ios":
+		return d.IOS
+	case "android":
+		return d.Android
+	}
+	return nil
+}
+
+func (d DeepLink) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return json.Marshal(d)
+}
+
+func (d *DeepLink) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	*d = DeepLink{}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, d)
+}
@@ -0,0 +1,48 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const DomainVerificationPrefix = "_urllite-verification."
+
+type Domain struct {
+	ID                gocql.UUID `json:"id"`
+	Hostname          string     `json:"hostname"`
+	UserID            gocql.UUID `json:"user_id"`
+	WorkspaceID       gocql.UUID `json:"workspace_id"`
+	VerificationToken string     `json:"verification_token"`
+	Status            string     `json:"status"`
+	VerifiedAt        time.Time  `json:"verified_at"`
+
+	// App association published on the domain so links open the apps.
+	AppleAppIDs             []string `json:"apple_app_ids"`
+	AndroidPackageName      string   `json:"android_package_name"`
+	AndroidCertFingerprints []string `json:"android_cert_fingerprints"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (d *Domain) IsVerified() bool {
+	return d != nil && d.Status == "verified"
+}
+
+// Serves reports whether the link was created on this registration of the
+// domain, a nil domain is the default short link host. A hostname deleted and
+// registered again, by anyone, does not take over the links of the old one.
+func (d *Domain) Serves(url *URL) bool {
+	if d == nil {
+		return url.Domain == ""
+	}
+	return url.Domain == d.Hostname && url.DomainID == d.ID
+}
+
+// VerificationRecord is the TXT record name and value the owner has to add
+// to prove control of the domain.
+func (d *Domain) VerificationRecord() (string, string) {
+	return DomainVerificationPrefix + d.Hostname, "urllite-verification=" + d.VerificationToken
+}
@@ -0,0 +1,18 @@
+package dtos
+
+type AbuseReportDTO struct {
+	Category string `json:"category" form:"category"`
+	Details  string `json:"details" form:"details"`
+	Email    string `json:"email" form:"email"`
+}
+
+type AbuseReportFilterDTO struct {
+	Status string `form:"status"`
+}
+
+// AbuseReviewDTO resolves a report with one of dismiss, disable_link or
+// suspend_owner. Suspending the owner also pauses the link.
+type AbuseReviewDTO struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
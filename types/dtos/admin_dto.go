@@ -0,0 +1,8 @@
+package dtos
+
+// AdminStatsFilterDTO bounds the dashboard queries. Days defaults to 30 and
+// Limit to 10.
+type AdminStatsFilterDTO struct {
+	Days  int `form:"days"`
+	Limit int `form:"limit"`
+}
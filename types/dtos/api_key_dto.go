@@ -0,0 +1,9 @@
+package dtos
+
+import "time"
+
+type ApiKeyDTO struct {
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,13 @@
+package dtos
+
+import "time"
+
+// AuditLogFilterDTO takes the period as RFC 3339 timestamps, the last seven
+// days when left out.
+type AuditLogFilterDTO struct {
+	ActorID  string    `form:"actor_id"`
+	TargetID string    `form:"target_id"`
+	From     time.Time `form:"from"`
+	To       time.Time `form:"to"`
+	Limit    int       `form:"limit"`
+}
@@ -0,0 +1,16 @@
+package dtos
+
+import "time"
+
+type CampaignDTO struct {
+	Name        string         `json:"name"`
+	WorkspaceID string         `json:"workspace_id"`
+	StartsAt    time.Time      `json:"starts_at"`
+	EndsAt      time.Time      `json:"ends_at"`
+	BudgetNote  string         `json:"budget_note"`
+	Goals       map[string]int `json:"goals"`
+}
+
+type CampaignUrlsDTO struct {
+	UrlIDs []string `json:"url_ids"`
+}
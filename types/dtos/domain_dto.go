@@ -0,0 +1,12 @@
+package dtos
+
+type DomainDTO struct {
+	Hostname    string `json:"hostname"`
+	WorkspaceID string `json:"workspace_id"`
+}
+
+type DomainAppsDTO struct {
+	AppleAppIDs             []string `json:"apple_app_ids"`
+	AndroidPackageName      string   `json:"android_package_name"`
+	AndroidCertFingerprints []string `json:"android_cert_fingerprints"`
+}
@@ -0,0 +1,10 @@
+package dtos
+
+type FolderDTO struct {
+	Name        string `json:"name"`
+	WorkspaceID string `json:"workspace_id"`
+}
+
+type TagDTO struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,10 @@
+package dtos
+
+// PrivacySettingsDTO changes the privacy settings of a user, fields left out
+// keep their current value. A retention of zero keeps logs as long as the
+// deployment does.
+type PrivacySettingsDTO struct {
+	IPMode          *string `json:"ip_mode"`
+	RetentionDays   *int    `json:"retention_days"`
+	HonorDoNotTrack *bool   `json:"honor_do_not_track"`
+}
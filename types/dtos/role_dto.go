@@ -0,0 +1,5 @@
+package dtos
+
+type RoleDTO struct {
+	Role string `json:"role"`
+}
@@ -0,0 +1,15 @@
+package dtos
+
+type TwoFactorCodeDTO struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorDisableDTO struct {
+	Password string `json:"password"`
+}
+
+type TwoFactorLoginDTO struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
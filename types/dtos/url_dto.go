@@ -1,6 +1,58 @@
 package dtos
 
+import "urllite/types"
+
 type UrlDTO struct {
-	LongUrl  string `json:"long_url"`
-	ShortUrl string `json:"short_url"`
+	LongUrl      string              `json:"long_url"`
+	ShortUrl     string              `json:"short_url"`
+	WorkspaceID  string              `json:"workspace_id"`
+	Domain       string              `json:"domain"`
+	RoutingRules []types.RoutingRule `json:"routing_rules"`
+	Variants     []types.Variant     `json:"variants"`
+	DeepLink     *types.DeepLink     `json:"deep_link"`
+	UTMParams    types.UTMParams     `json:"utm_params"`
+	ForwardQuery bool                `json:"forward_query"`
+	QueryMerge   string              `json:"query_merge"`
+
+	RedirectType      string `json:"redirect_type"`
+	InterstitialDelay *int   `json:"interstitial_delay"`
+	LeavingWarning    bool   `json:"leaving_warning"`
+	NoIndex           bool   `json:"no_index"`
+
+	Tags     []string `json:"tags"`
+	FolderID string   `json:"folder_id"`
+	Notes    string   `json:"notes"`
+}
+
+// UrlUpdateDTO changes the settings of an existing link, fields left out
+// keep their current value.
+type UrlUpdateDTO struct {
+	LongUrl      *string              `json:"long_url"`
+	RoutingRules *[]types.RoutingRule `json:"routing_rules"`
+	Variants     *[]types.Variant     `json:"variants"`
+	DeepLink     *types.DeepLink      `json:"deep_link"`
+	UTMParams    *types.UTMParams     `json:"utm_params"`
+	ForwardQuery *bool                `json:"forward_query"`
+	QueryMerge   *string              `json:"query_merge"`
+
+	RedirectType      *string `json:"redirect_type"`
+	InterstitialDelay *int    `json:"interstitial_delay"`
+	LeavingWarning    *bool   `json:"leaving_warning"`
+	NoIndex           *bool   `json:"no_index"`
+
+	Tags     *[]string `json:"tags"`
+	FolderID *string   `json:"folder_id"`
+	Notes    *string   `json:"notes"`
+}
+
+// UrlFilterDTO narrows link listings down from the query string.
+type UrlFilterDTO struct {
+	Tag      string `form:"tag"`
+	FolderID string `form:"folder"`
+}
+
+// AnalyticsFilterDTO toggles which clicks analytics count, bots, link
+// previews and suspicious clicks are left out unless include_bots is set.
+type AnalyticsFilterDTO struct {
+	IncludeBots bool `form:"include_bots"`
 }
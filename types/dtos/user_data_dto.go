@@ -0,0 +1,8 @@
+package dtos
+
+// EraseAccountDTO confirms the erasure with the password, or with a two
+// factor code for accounts that only log in through a provider.
+type EraseAccountDTO struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
@@ -0,0 +1,15 @@
+package dtos
+
+type WebhookDTO struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	WorkspaceID string   `json:"workspace_id"`
+}
+
+// WebhookUpdateDTO changes an existing webhook, fields left out keep their
+// current value. Setting active re-enables a disabled webhook.
+type WebhookUpdateDTO struct {
+	URL    *string   `json:"url"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
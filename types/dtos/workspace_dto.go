@@ -0,0 +1,23 @@
+package dtos
+
+type WorkspaceDTO struct {
+	Name string `json:"name"`
+}
+
+type WorkspaceMemberDTO struct {
+	Role string `json:"role"`
+}
+
+type WorkspaceInvitationDTO struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type AcceptInvitationDTO struct {
+	Token string `json:"token"`
+}
+
+type UrlTransferDTO struct {
+	UserID      string `json:"user_id"`
+	WorkspaceID string `json:"workspace_id"`
+}
@@ -17,6 +17,8 @@ func (e *ApplicationError) HttpResponse(c *gin.Context) {
 		return
 	}
 
+	// Kept on the context for the middlewares that record server errors.
+	c.Error(e.Err).SetMeta(e.Message)
 	c.JSON(e.HttpStatusCode, gin.H{
 		"status":  "failed",
 		"message": e.Message,
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Folder groups links. Personal folders hold personal links, workspace
+// folders hold the links of their workspace.
+type Folder struct {
+	ID          gocql.UUID `json:"id"`
+	Name        string     `json:"name"`
+	UserID      gocql.UUID `json:"user_id"`
+	WorkspaceID gocql.UUID `json:"workspace_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// LinkAnalytics sums the clicks of a group of links, e.g. a tag or a folder.
+type LinkAnalytics struct {
+	Links          int                 `json:"links"`
+	Interactions   int                 `json:"interactions"`
+	UniqueVisitors int64               `json:"unique_visitors"`
+	Urls           []*LinkInteractions `json:"urls"`
+}
+
+type LinkInteractions struct {
+	ID             gocql.UUID `json:"id"`
+	ShortUrl       string     `json:"short_url"`
+	Interactions   int        `json:"interactions"`
+	UniqueVisitors int64      `json:"unique_visitors"`
+}
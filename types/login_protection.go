@@ -0,0 +1,27 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// LoginLockout is the state of the failed login tracking of an account.
+type LoginLockout struct {
+	UserID         gocql.UUID `json:"user_id"`
+	Email          string     `json:"email"`
+	FailedAttempts int64      `json:"failed_attempts"`
+	Locked         bool       `json:"locked"`
+	LockedUntil    time.Time  `json:"locked_until"`
+}
+
+// LoginSource is where a successful login came from, as far as the ip and
+// the user agent tell.
+type LoginSource struct {
+	IP      string
+	Device  string
+	OS      string
+	Country string
+	City    string
+	At      time.Time
+}
@@ -0,0 +1,18 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+type OAuthIdentity struct {
+	ID       gocql.UUID `json:"id"`
+	UserID   gocql.UUID `json:"user_id"`
+	Provider string     `json:"provider"`
+	Subject  string     `json:"subject"`
+	Email    string     `json:"email"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
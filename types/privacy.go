@@ -0,0 +1,68 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// How client addresses are stored with click logs, from the most to the
+// least revealing.
+const (
+	IPModeFull     = "full"
+	IPModeTruncate = "truncate"
+	IPModeHash     = "hash"
+	IPModeNone     = "none"
+)
+
+var ipModeStrictness = map[string]int{IPModeFull: 0, IPModeTruncate: 1, IPModeHash: 2, IPModeNone: 3}
+
+func IsValidIPMode(mode string) bool {
+	_, ok := ipModeStrictness[mode]
+	return ok
+}
+
+// PrivacySettings control what is stored about the visitors of a user's
+// links. An empty IPMode and zero RetentionDays leave the deployment
+// default in place.
+type PrivacySettings struct {
+	UserID          gocql.UUID `json:"user_id"`
+	IPMode          string     `json:"ip_mode"`
+	RetentionDays   int        `json:"retention_days"`
+	HonorDoNotTrack bool       `json:"honor_do_not_track"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Stricter combines two settings keeping the more private choice of each,
+// users can tighten the deployment policy but not loosen it.
+func (p *PrivacySettings) Stricter(other *PrivacySettings) *PrivacySettings {
+	combined := *p
+	if other == nil {
+		return &combined
+	}
+	if IsValidIPMode(other.IPMode) && ipModeStrictness[other.IPMode] > ipModeStrictness[combined.IPMode] {
+		combined.IPMode = other.IPMode
+	}
+	if other.RetentionDays > 0 && (combined.RetentionDays == 0 || other.RetentionDays < combined.RetentionDays) {
+		combined.RetentionDays = other.RetentionDays
+	}
+	combined.HonorDoNotTrack = combined.HonorDoNotTrack || other.HonorDoNotTrack
+	return &combined
+}
+
+// Retention is how long raw click logs are kept, zero keeps them forever.
+func (p *PrivacySettings) Retention() time.Duration {
+	return time.Duration(p.RetentionDays) * 24 * time.Hour
+}
+
+// ClickRollup counts the clicks of a link on a day, it outlives the raw logs
+// it was counted from.
+type ClickRollup struct {
+	UrlID        gocql.UUID `json:"url_id"`
+	Day          string     `json:"day"`
+	TrafficClass string     `json:"traffic_class"`
+	Variant      string     `json:"variant"`
+	Country      string     `json:"country"`
+	Channel      string     `json:"channel"`
+	Clicks       int64      `json:"clicks"`
+}
@@ -0,0 +1,40 @@
+package types
+
+import "net/http"
+
+const (
+	RedirectMovedPermanently  = "301"
+	RedirectFound             = "302"
+	RedirectTemporary         = "307"
+	RedirectPermanent         = "308"
+	RedirectInterstitial      = "interstitial"
+	DefaultInterstitialDelay  = 5
+	MaximumInterstitialDelay  = 30
+	DefaultRedirectCacheLimit = 3600
+)
+
+func IsValidRedirectType(redirectType string) bool {
+	switch redirectType {
+	case RedirectMovedPermanently, RedirectFound, RedirectTemporary, RedirectPermanent, RedirectInterstitial:
+		return true
+	}
+	return false
+}
+
+// RedirectStatusCode maps the redirect type of a link to the HTTP status,
+// links saved before redirect types existed keep using 302.
+func RedirectStatusCode(redirectType string) int {
+	switch redirectType {
+	case RedirectMovedPermanently:
+		return http.StatusMovedPermanently
+	case RedirectTemporary:
+		return http.StatusTemporaryRedirect
+	case RedirectPermanent:
+		return http.StatusPermanentRedirect
+	}
+	return http.StatusFound
+}
+
+func IsPermanentRedirect(redirectType string) bool {
+	return redirectType == RedirectMovedPermanently || redirectType == RedirectPermanent
+}
@@ -0,0 +1,76 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	RoleOwner   = "owner"
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+	RoleAnalyst = "analyst"
+	RoleUser    = "user"
+)
+
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersWrite  = "users:write"
+	PermissionUsersDelete = "users:delete"
+	PermissionRolesManage = "roles:manage"
+	PermissionStatsRead   = "stats:read"
+)
+
+// roleRanks orders the roles, a user can only hand out roles up to their own
+// rank and only change users ranked below them.
+var roleRanks = map[string]int{
+	RoleUser:    1,
+	RoleAnalyst: 2,
+	RoleSupport: 3,
+	RoleAdmin:   4,
+	RoleOwner:   5,
+}
+
+var RolePermissions = map[string][]string{
+	RoleOwner:   {PermissionUsersRead, PermissionUsersWrite, PermissionUsersDelete, PermissionRolesManage, PermissionStatsRead},
+	RoleAdmin:   {PermissionUsersRead, PermissionUsersWrite, PermissionUsersDelete, PermissionRolesManage, PermissionStatsRead},
+	RoleSupport: {PermissionUsersRead},
+	RoleAnalyst: {PermissionStatsRead},
+	RoleUser:    {},
+}
+
+type RoleChange struct {
+	ID           gocql.UUID `json:"id"`
+	UserID       gocql.UUID `json:"user_id"`
+	ActorID      gocql.UUID `json:"actor_id"`
+	PreviousRole string     `json:"previous_role"`
+	NewRole      string     `json:"new_role"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+func RoleRank(role string) int {
+	if rank, ok := roleRanks[role]; ok {
+		return rank
+	}
+	return roleRanks[RoleUser]
+}
+
+func HasPermission(role, permission string) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func IsAdminRole(role string) bool {
+	return role == RoleAdmin || role == RoleOwner
+}
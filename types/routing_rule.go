@@ -0,0 +1,101 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	RoutingRuleCountry  = "country"
+	RoutingRuleDevice   = "device"
+	RoutingRuleOS       = "os"
+	RoutingRuleLanguage = "language"
+
+	// RoutingRuleDefault is recorded when no rule matched and the visitor
+	// was sent to the long url.
+	RoutingRuleDefault = "default"
+)
+
+// RoutingRule sends visitors matching any of the values to the destination.
+// Rules are evaluated in order and the first match wins.
+type RoutingRule struct {
+	Type        string   `json:"type"`
+	Values      []string `json:"values"`
+	Destination string   `json:"destination"`
+}
+
+// RoutingRules is stored as a JSON document in a single TEXT column so the
+// redirect path keeps reading a link with one query.
+type RoutingRules []RoutingRule
+
+func (r RoutingRules) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	return json.Marshal([]RoutingRule(r))
+}
+
+func (r *RoutingRules) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	if len(data) == 0 {
+		*r = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]RoutingRule)(r))
+}
+
+func IsValidRoutingRuleType(ruleType string) bool {
+	switch ruleType {
+	case RoutingRuleCountry, RoutingRuleDevice, RoutingRuleOS, RoutingRuleLanguage:
+		return true
+	}
+	return false
+}
+
+// Matches reports whether the visitor falls under the rule. Languages match
+// on the full tag or on the base language, so "pt" covers "pt-BR".
+func (r RoutingRule) Matches(visit *Visit) bool {
+	var visitorValue string
+	switch r.Type {
+	case RoutingRuleCountry:
+		visitorValue = visit.Country
+	case RoutingRuleDevice:
+		visitorValue = visit.Device
+	case RoutingRuleOS:
+		visitorValue = visit.OS
+	case RoutingRuleLanguage:
+		visitorValue = visit.Language
+	}
+	if visitorValue == "" {
+		return false
+	}
+
+	for _, value := range r.Values {
+		if strings.EqualFold(value, visitorValue) {
+			return true
+		}
+		if r.Type == RoutingRuleLanguage {
+			base, _, _ := strings.Cut(visitorValue, "-")
+			if strings.EqualFold(value, base) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Label identifies the rule in the click logs, e.g. "country:DE".
+func (r RoutingRule) Label(visit *Visit) string {
+	switch r.Type {
+	case RoutingRuleCountry:
+		return r.Type + ":" + visit.Country
+	case RoutingRuleDevice:
+		return r.Type + ":" + visit.Device
+	case RoutingRuleOS:
+		return r.Type + ":" + visit.OS
+	case RoutingRuleLanguage:
+		return r.Type + ":" + visit.Language
+	}
+	return r.Type
+}
@@ -0,0 +1,75 @@
+package types
+
+import "time"
+
+// Counters kept for the admin dashboard, each is counted per day and in
+// total. Clicks only count human traffic.
+const (
+	StatUsers         = "users"
+	StatVerifiedUsers = "verified_users"
+	StatLinks         = "links"
+	StatClicks        = "clicks"
+
+	StatTotalDay = "total"
+)
+
+type DailyCount struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+type UserStats struct {
+	Total         int64         `json:"total"`
+	Verified      int64         `json:"verified"`
+	Unverified    int64         `json:"unverified"`
+	SignupsPerDay []*DailyCount `json:"signups_per_day"`
+}
+
+type LinkStats struct {
+	Total         int64         `json:"total"`
+	CreatedPerDay []*DailyCount `json:"created_per_day"`
+}
+
+type ClickStats struct {
+	Total  int64         `json:"total"`
+	PerDay []*DailyCount `json:"per_day"`
+}
+
+type SystemStats struct {
+	Users  UserStats  `json:"users"`
+	Links  LinkStats  `json:"links"`
+	Clicks ClickStats `json:"clicks"`
+}
+
+type TopLink struct {
+	UrlID    string `json:"url_id"`
+	Domain   string `json:"domain"`
+	ShortUrl string `json:"short_url"`
+	UserID   string `json:"user_id"`
+	Clicks   int64  `json:"clicks"`
+}
+
+type TopUser struct {
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Clicks int64  `json:"clicks"`
+}
+
+type QueueStats struct {
+	Queue     string `json:"queue"`
+	Size      int    `json:"size"`
+	Pending   int    `json:"pending"`
+	Active    int    `json:"active"`
+	Scheduled int    `json:"scheduled"`
+	Retry     int    `json:"retry"`
+	Archived  int    `json:"archived"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// RecordedError is a server error or failed task kept for the dashboard.
+type RecordedError struct {
+	Source     string    `json:"source"`
+	Message    string    `json:"message"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
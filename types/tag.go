@@ -0,0 +1,7 @@
+package types
+
+// TagUsage tells how many links carry a tag.
+type TagUsage struct {
+	Name  string `json:"name"`
+	Links int    `json:"links"`
+}
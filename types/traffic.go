@@ -0,0 +1,15 @@
+package types
+
+// Traffic classes of a click.
+const (
+	TrafficHuman      = "human"
+	TrafficBot        = "bot"
+	TrafficPreview    = "preview"
+	TrafficSuspicious = "suspicious"
+)
+
+// IsHumanTraffic reports whether a click counts in analytics by default.
+// Clicks logged before classification existed have no class and count.
+func IsHumanTraffic(trafficClass string) bool {
+	return trafficClass == "" || trafficClass == TrafficHuman
+}
@@ -0,0 +1,23 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+type TwoFactor struct {
+	UserID        gocql.UUID `json:"user_id"`
+	Secret        string     `json:"-"`
+	Status        string     `json:"status"`
+	RecoveryCodes []string   `json:"-"`
+	LastUsedStep  int64      `json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	EnabledAt time.Time `json:"enabled_at"`
+}
+
+func (t *TwoFactor) IsEnabled() bool {
+	return t != nil && t.Status == "enabled"
+}
@@ -0,0 +1,16 @@
+package types
+
+// UniqueVisitors are HyperLogLog estimates of the distinct human visitors of
+// a link. Visitors are told apart by a hash salted once a day, the same
+// visitor cannot be recognised the day after. Over the life of the link
+// there is only VisitorDays, a visitor counted once for every day they came.
+type UniqueVisitors struct {
+	Today       int64            `json:"today"`
+	VisitorDays int64            `json:"visitor_days"`
+	Daily       []*DailyVisitors `json:"daily"`
+}
+
+type DailyVisitors struct {
+	Date     string `json:"date"`
+	Visitors int64  `json:"visitors"`
+}
@@ -6,14 +6,66 @@ import (
 	"github.com/gocql/gocql"
 )
 
+// Link states, paused links belong to a suspended user and come back when the
+// user is reinstated, disabled links stay off.
+const (
+	UrlStatusActive   = "active"
+	UrlStatusPaused   = "paused"
+	UrlStatusDisabled = "disabled"
+)
+
 type URL struct {
-	ID       gocql.UUID `json:"id"`
-	UserID   gocql.UUID `json:"user_id"`
-	LongUrl  string     `josn:"long_url"`
-	ShortUrl string     `json:"short_url"`
-	Status   string     `json:"status"`
+	ID          gocql.UUID `json:"id"`
+	UserID      gocql.UUID `json:"user_id"`
+	WorkspaceID gocql.UUID `json:"workspace_id"`
+	Domain      string     `json:"domain"`
+	DomainID    gocql.UUID `json:"domain_id"`
+	LongUrl     string     `josn:"long_url"`
+	ShortUrl    string     `json:"short_url"`
+	Status      string     `json:"status"`
+
+	RoutingRules RoutingRules `json:"routing_rules"`
+	Variants     Variants     `json:"variants"`
+	DeepLink     DeepLink     `json:"deep_link"`
+	UTMParams    UTMParams    `json:"utm_params"`
+	ForwardQuery bool         `json:"forward_query"`
+	QueryMerge   string       `json:"query_merge"`
+
+	RedirectType      string `json:"redirect_type"`
+	InterstitialDelay int    `json:"interstitial_delay"`
+	LeavingWarning    bool   `json:"leaving_warning"`
+	NoIndex           bool   `json:"no_index"`
+
+	Tags     []string   `json:"tags"`
+	FolderID gocql.UUID `json:"folder_id"`
+	Notes    string     `json:"notes"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	DeletedAt time.Time `json:"deleted_at"`
 }
+
+// IsPersonalized reports whether visitors may be sent to different
+// destinations, such redirects must not be cached by shared caches.
+func (u *URL) IsPersonalized() bool {
+	return len(u.RoutingRules) > 0 || len(u.Variants) > 0 || u.ForwardQuery || !u.DeepLink.IsZero()
+}
+
+// IsActive reports whether the link redirects, links stored before link
+// states have no status.
+func (u *URL) IsActive() bool {
+	return u.Status == "" || u.Status == UrlStatusActive
+}
+
+func (u *URL) HasTag(tag string) bool {
+	for _, urlTag := range u.Tags {
+		if urlTag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *URL) VariantCookieName() string {
+	return VariantCookiePrefix + u.ID.String()
+}
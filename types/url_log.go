@@ -8,13 +8,21 @@ import (
 
 type UrlLog struct {
 	ID             gocql.UUID `json:"id"`
-	UrlID          gocql.UUID `json:"url_id`
+	UrlID          gocql.UUID `json:"url_id"`
 	VisitedAt      time.Time  `json:"visited_at"`
 	RedirectStatus string     `json:"redirect_status"`
 	HttpStatusCode int        `json:"http_status_code"`
 	ClientIP       string     `json:"client_ip"`
 	City           string     `json:"city"`
 	Country        string     `json:"country"`
+	Destination    string     `json:"destination"`
+	MatchedRule    string     `json:"matched_rule"`
+	Variant        string     `json:"variant"`
+	Referrer       string     `json:"referrer"`
+	UserAgent      string     `json:"user_agent"`
+	TrafficClass   string     `json:"traffic_class"`
+	DoNotTrack     bool       `json:"do_not_track"`
+	RolledUp       bool       `json:"-"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -6,6 +6,14 @@ import (
 	"github.com/gocql/gocql"
 )
 
+// Lifecycle states of a user. Suspended users cannot log in and their links
+// are paused, deleted users have their links disabled and their data purged.
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusDeleted   = "deleted"
+)
+
 type User struct {
 	ID            gocql.UUID `json:"id"`
 	Name          string     `json:"name"`
@@ -13,7 +21,7 @@ type User struct {
 	VerifiedEmail string     `json:"verified_email"`
 	Mobile        string     `json:"mobile"`
 	Status        string     `json:"status"`
-	Role          string     `json:"status"`
+	Role          string     `json:"role"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -28,6 +36,11 @@ type UserFilter struct {
 	Status string     `json:"status"`
 }
 
+// IsActive reports whether the user may log in and use the api.
+func (u *User) IsActive() bool {
+	return u.Status != UserStatusSuspended && u.Status != UserStatusDeleted
+}
+
 func (u *User) IsEmailVerified() bool {
 	return u.Email == u.VerifiedEmail
 }
@@ -0,0 +1,32 @@
+package types
+
+var UTMKeys = []string{"source", "medium", "campaign", "term", "content"}
+
+// Query merge rules used when the visitor query string is forwarded and a key
+// is present on both sides.
+const (
+	QueryMergeKeepDestination = "destination"
+	QueryMergeKeepIncoming    = "incoming"
+	QueryMergeAppend          = "append"
+)
+
+// UTMParams maps the UTM keys without their "utm_" prefix to values, e.g.
+// {"source": "newsletter"}.
+type UTMParams map[string]string
+
+func IsValidUTMKey(key string) bool {
+	for _, utmKey := range UTMKeys {
+		if key == utmKey {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidQueryMerge(rule string) bool {
+	switch rule {
+	case QueryMergeKeepDestination, QueryMergeKeepIncoming, QueryMergeAppend:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"encoding/json"
+	"hash/fnv"
+
+	"github.com/gocql/gocql"
+)
+
+// VariantCookiePrefix names the cookie keeping a visitor on the same
+// variant, the link id is appended.
+const VariantCookiePrefix = "urllite_variant_"
+
+// Variant is one destination of a split test. Weights are percentages and
+// add up to 100 across the variants of a link.
+type Variant struct {
+	Name        string `json:"name"`
+	Destination string `json:"destination"`
+	Weight      int    `json:"weight"`
+}
+
+// Variants are the split test destinations of a link.
+type Variants []Variant
+
+func (v Variants) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	return json.Marshal([]Variant(v))
+}
+
+func (v *Variants) UnmarshalCQL(info gocql.TypeInfo, data []byte) error {
+	if len(data) == 0 {
+		*v = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]Variant)(v))
+}
+
+func (v Variants) ByName(name string) *Variant {
+	for i := range v {
+		if v[i].Name == name {
+			return &v[i]
+		}
+	}
+	return nil
+}
+
+// Pick assigns a visitor to a variant by hashing the key into the weight
+// range, so the same key always lands on the same variant.
+func (v Variants) Pick(key string) *Variant {
+	if len(v) == 0 {
+		return nil
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	bucket := int(hash.Sum32() % 100)
+	for i := range v {
+		if bucket < v[i].Weight {
+			return &v[i]
+		}
+		bucket -= v[i].Weight
+	}
+	return &v[len(v)-1]
+}
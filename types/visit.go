@@ -0,0 +1,32 @@
+package types
+
+import "net/url"
+
+// Visit describes the visitor of a short link as seen by the redirect
+// handler. The location is looked up lazily since it needs a remote call.
+type Visit struct {
+	ClientIP  string
+	UserAgent string
+	Device    string
+	OS        string
+	Language  string
+	Query     url.Values
+	Referrer  string
+
+	// DoNotTrack is set when the browser sends DNT: 1 or Sec-GPC: 1.
+	DoNotTrack bool
+
+	// AssignedVariant is the split test variant from an earlier visit.
+	AssignedVariant string
+
+	Located bool
+	Country string
+	City    string
+}
+
+// RedirectTarget is where a visit ends up and why.
+type RedirectTarget struct {
+	Destination string
+	MatchedRule string
+	Variant     string
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Webhook events users can subscribe to.
+const (
+	WebhookEventUrlCreated   = "url.created"
+	WebhookEventUrlDeleted   = "url.deleted"
+	WebhookEventUrlClicked   = "url.clicked"
+	WebhookEventUrlBroken    = "url.broken"
+	WebhookEventUserVerified = "user.verified"
+)
+
+var WebhookEvents = []string{WebhookEventUrlCreated, WebhookEventUrlDeleted, WebhookEventUrlClicked, WebhookEventUrlBroken, WebhookEventUserVerified}
+
+const (
+	WebhookStatusActive   = "active"
+	WebhookStatusDisabled = "disabled"
+)
+
+// Webhook is an endpoint receiving events of the links of its owner, the
+// user for personal links or the workspace for workspace links.
+type Webhook struct {
+	ID           gocql.UUID `json:"id"`
+	UserID       gocql.UUID `json:"user_id"`
+	WorkspaceID  gocql.UUID `json:"workspace_id"`
+	URL          string     `json:"url"`
+	Secret       string     `json:"-"`
+	Events       []string   `json:"events"`
+	Status       string     `json:"status"`
+	FailureCount int        `json:"failure_count"`
+	DisabledAt   time.Time  `json:"disabled_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func IsValidWebhookEvent(event string) bool {
+	return slices.Contains(WebhookEvents, event)
+}
+
+func (w *Webhook) IsActive() bool {
+	return w.Status == WebhookStatusActive
+}
+
+func (w *Webhook) Subscribes(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// WebhookEvent is the body posted to webhook endpoints.
+type WebhookEvent struct {
+	ID        gocql.UUID      `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookDelivery records one attempt to post an event to a webhook.
+type WebhookDelivery struct {
+	ID         gocql.UUID `json:"id"`
+	WebhookID  gocql.UUID `json:"webhook_id"`
+	EventID    gocql.UUID `json:"event_id"`
+	Event      string     `json:"event"`
+	Payload    string     `json:"payload"`
+	Attempt    int        `json:"attempt"`
+	Manual     bool       `json:"manual"`
+	StatusCode int        `json:"status_code"`
+	Response   string     `json:"response"`
+	Error      string     `json:"error"`
+	Succeeded  bool       `json:"succeeded"`
+	DurationMs int        `json:"duration_ms"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	WorkspaceRoleViewer = "viewer"
+	WorkspaceRoleEditor = "editor"
+	WorkspaceRoleAdmin  = "admin"
+)
+
+var workspaceRoleRanks = map[string]int{
+	WorkspaceRoleViewer: 1,
+	WorkspaceRoleEditor: 2,
+	WorkspaceRoleAdmin:  3,
+}
+
+type Workspace struct {
+	ID      gocql.UUID `json:"id"`
+	Name    string     `json:"name"`
+	OwnerID gocql.UUID `json:"owner_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID gocql.UUID `json:"workspace_id"`
+	UserID      gocql.UUID `json:"user_id"`
+	Role        string     `json:"role"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WorkspaceInvitation struct {
+	ID          gocql.UUID `json:"id"`
+	WorkspaceID gocql.UUID `json:"workspace_id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	HashedToken string     `json:"-"`
+	Status      string     `json:"status"`
+	InvitedBy   gocql.UUID `json:"invited_by"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func IsValidWorkspaceRole(role string) bool {
+	_, ok := workspaceRoleRanks[role]
+	return ok
+}
+
+// CanAct reports whether the member role is at least the required role.
+func (m *WorkspaceMember) CanAct(requiredRole string) bool {
+	return m != nil && workspaceRoleRanks[m.Role] >= workspaceRoleRanks[requiredRole]
+}
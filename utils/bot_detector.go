@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strings"
+	"urllite/types"
+)
+
+// previewAgents fetch a link to render a preview card when it is pasted in
+// a chat or social post, nobody clicked it.
+var previewAgents = []string{
+	"slackbot-linkexpanding", "slack-imgproxy", "slackbot", "facebookexternalhit", "facebot", "twitterbot",
+	"linkedinbot", "whatsapp", "telegrambot", "discordbot", "skypeuripreview", "microsoftpreview",
+	"teamsbot", "redditbot", "embedly", "iframely", "vkshare", "mastodon",
+	"pinterestbot", "google-pagerenderer", "applebot", "bitlybot", "line-poker", "kakaotalk-scrap",
+}
+
+// botAgents are crawlers, http libraries and the link scanners of mail
+// security gateways.
+var botAgents = []string{
+	"googlebot", "bingbot", "yandex", "baiduspider", "duckduckbot", "ahrefsbot", "semrushbot", "mj12bot",
+	"dotbot", "petalbot", "bytespider", "gptbot", "claudebot", "ccbot", "amazonbot", "facebookbot",
+	"headlesschrome", "phantomjs", "puppeteer", "playwright", "selenium", "lighthouse",
+	"curl/", "wget/", "python-requests", "python-urllib", "aiohttp", "httpx", "go-http-client", "okhttp",
+	"java/", "apache-httpclient", "libwww-perl", "node-fetch", "axios/", "postmanruntime", "insomnia",
+	"barracuda", "proofpoint", "mimecast", "urlscan", "safebrowsing", "virustotal", "forcepoint",
+	"trendmicro", "symantec", "sophos", "fortiguard", "zscaler", "checkpoint", "paloalto",
+	"bot/", "bot;", "bot)", "crawler", "spider", "scanner", "uptime", "preview", "fetcher",
+}
+
+// ClassifyUserAgent sorts a user agent into link previews, bots or
+// suspicious empty agents, anything else is reported as human.
+func ClassifyUserAgent(userAgent string) string {
+	userAgent = strings.ToLower(strings.TrimSpace(userAgent))
+	if userAgent == "" {
+		return types.TrafficSuspicious
+	}
+	for _, agent := range previewAgents {
+		if strings.Contains(userAgent, agent) {
+			return types.TrafficPreview
+		}
+	}
+	for _, agent := range botAgents {
+		if strings.Contains(userAgent, agent) {
+			return types.TrafficBot
+		}
+	}
+	return types.TrafficHuman
+}
+
+// IPRanges is a set of networks, used for the hosting and cloud providers
+// where real visitors rarely come from.
+type IPRanges struct {
+	networks []*net.IPNet
+}
+
+// LoadIPRanges reads one CIDR range or address per line, blank lines and
+// lines starting with # are skipped.
+func LoadIPRanges(path string) (*IPRanges, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ranges := &IPRanges{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.Contains(line, "/") {
+			if strings.Contains(line, ":") {
+				line += "/128"
+			} else {
+				line += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(line)
+		if err != nil {
+			return nil, err
+		}
+		ranges.networks = append(ranges.networks, network)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ranges, nil
+}
+
+func (r *IPRanges) Contains(ipStr string) bool {
+	if r == nil {
+		return false
+	}
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range r.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"context"
+	"net"
+)
+
+// TXTResolver is the part of net.Resolver used for domain verification, so
+// it can be swapped for a stub.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+func NewTXTResolver() TXTResolver {
+	return net.DefaultResolver
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"net/smtp"
 	"os"
+	"strings"
+	"time"
 	"urllite/types"
 )
 
@@ -15,6 +17,11 @@ type mailer struct {
 
 type Mailer interface {
 	SendOtpForEmailVerification(user *types.User, otp *types.Otp) error
+	SendWorkspaceInvitation(email string, workspace *types.Workspace, inviter *types.User, token string) error
+	SendDataExport(user *types.User, downloadUrl string) error
+	SendAbuseReportNotice(admin *types.User, report *types.AbuseReport) error
+	SendNewLoginAlert(user *types.User, source *types.LoginSource) error
+	SendAccountLocked(user *types.User, lockedUntil time.Time) error
 }
 
 func NewMailer() Mailer {
@@ -39,3 +46,62 @@ func (m *mailer) SendOtpForEmailVerification(user *types.User, otp *types.Otp) e
 	}
 	return nil
 }
+
+func (m *mailer) SendWorkspaceInvitation(email string, workspace *types.Workspace, inviter *types.User, token string) error {
+	subject := "You are invited to " + workspace.Name + " on urllite"
+	body := inviter.Name + " invited you to join the " + workspace.Name + " workspace. Your invitation code is: " + token + ". This invitation is valid only for 7 days."
+	if appUrl := os.Getenv("APP_URL"); appUrl != "" {
+		body += "\r\n\r\nAccept the invitation: " + appUrl + "/workspaces/invitations/accept?token=" + token
+	}
+	return m.send(email, subject, body)
+}
+
+func (m *mailer) SendDataExport(user *types.User, downloadUrl string) error {
+	subject := "Your urllite data export is ready"
+	body := "Dear " + user.Name + ", the export of your urllite data is ready. Download it here: " + downloadUrl + "\r\n\r\nThis link is valid only for 24 hours."
+	return m.send(user.Email, subject, body)
+}
+
+func (m *mailer) SendAbuseReportNotice(admin *types.User, report *types.AbuseReport) error {
+	subject := "Abuse report: " + report.Category + " on /" + report.ShortUrl
+	body := "Dear " + admin.Name + ", the short link /" + report.ShortUrl + " was reported as " + report.Category + ".\r\n\r\n" +
+		"Destination: " + report.Destination + "\r\n" +
+		"Details: " + report.Details + "\r\n" +
+		"Report id: " + report.ID.String()
+	return m.send(admin.Email, subject, body)
+}
+
+func (m *mailer) SendNewLoginAlert(user *types.User, source *types.LoginSource) error {
+	location := "an unknown location"
+	if source.Country != "" {
+		location = strings.TrimPrefix(source.City+", "+source.Country, ", ")
+	}
+	device := "an unknown device"
+	if source.Device != "" {
+		device = source.Device + " (" + source.OS + ")"
+	}
+	subject := "New login to your urllite account"
+	body := "Dear " + user.Name + ", your account was just logged into from a device or location we have not seen before.\r\n\r\n" +
+		"Device: " + device + "\r\n" +
+		"Location: " + location + "\r\n" +
+		"IP address: " + source.IP + "\r\n" +
+		"Time: " + source.At.UTC().Format(time.RFC1123) + "\r\n\r\n" +
+		"If this was not you, change your password right away."
+	return m.send(user.Email, subject, body)
+}
+
+func (m *mailer) SendAccountLocked(user *types.User, lockedUntil time.Time) error {
+	subject := "Your urllite account is temporarily locked"
+	body := "Dear " + user.Name + ", there were too many failed attempts to log into your account, so logins are blocked until " + lockedUntil.UTC().Format(time.RFC1123) + ".\r\n\r\n" +
+		"If this was not you, someone may be guessing your password. You can reset it with the forgot password link."
+	return m.send(user.Email, subject, body)
+}
+
+func (m *mailer) send(to, subject, body string) error {
+	message := "From: " + m.mailerEmail + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n\r\n" +
+		body
+
+	return smtp.SendMail(m.smtpHost+":"+m.smtpPort, m.auth, m.mailerEmail, []string{to}, []byte(message))
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+var (
+	searchHosts = []string{"google.", "bing.com", "duckduckgo.com", "yahoo.", "baidu.com", "yandex.", "ecosia.org"}
+	socialHosts = []string{"facebook.com", "fb.me", "t.co", "twitter.com", "x.com", "linkedin.com", "lnkd.in", "instagram.com", "reddit.com", "youtube.com", "tiktok.com", "pinterest.", "threads.net"}
+	emailHosts  = []string{"mail.google.com", "outlook.live.com", "outlook.office.com", "mail.yahoo.com"}
+)
+
+// ReferrerChannel sorts a referrer into direct, search, social, email or
+// referral traffic.
+func ReferrerChannel(referrer string) string {
+	if referrer == "" {
+		return "direct"
+	}
+	parsedReferrer, err := url.Parse(referrer)
+	if err != nil || parsedReferrer.Hostname() == "" {
+		return "direct"
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsedReferrer.Hostname()), "www.")
+	switch {
+	case matchesHost(host, emailHosts):
+		return "email"
+	case matchesHost(host, searchHosts):
+		return "search"
+	case matchesHost(host, socialHosts):
+		return "social"
+	}
+	return "referral"
+}
+
+// matchesHost treats entries ending in a dot as any top level domain.
+func matchesHost(host string, hosts []string) bool {
+	for _, candidate := range hosts {
+		if strings.HasSuffix(candidate, ".") {
+			if strings.HasPrefix(host, candidate) || strings.Contains(host, "."+candidate) {
+				return true
+			}
+			continue
+		}
+		if host == candidate || strings.HasSuffix(host, "."+candidate) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA1 vectors of RFC 6238, cut to our six digits.
+func TestVerifyTOTP(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		name   string
+		code   string
+		at     time.Time
+		wantOk bool
+	}{
+		{name: "rfc vector 59", code: "287082", at: time.Unix(59, 0), wantOk: true},
+		{name: "rfc vector 1111111109", code: "081804", at: time.Unix(1111111109, 0), wantOk: true},
+		{name: "rfc vector 1234567890", code: "005924", at: time.Unix(1234567890, 0), wantOk: true},
+		{name: "previous step is accepted", code: "081804", at: time.Unix(1111111109+30, 0), wantOk: true},
+		{name: "next step is accepted", code: "081804", at: time.Unix(1111111109-30, 0), wantOk: true},
+		{name: "two steps away is refused", code: "081804", at: time.Unix(1111111109+60, 0)},
+		{name: "wrong code", code: "000000", at: time.Unix(59, 0)},
+		{name: "short code", code: "28708", at: time.Unix(59, 0)},
+		{name: "eight digit code", code: "94287082", at: time.Unix(59, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := VerifyTOTP(secret, tt.code, tt.at)
+			if ok != tt.wantOk {
+				t.Fatalf("VerifyTOTP = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && (step < tt.at.Unix()/totpPeriod-totpSkew || step > tt.at.Unix()/totpPeriod+totpSkew) {
+				t.Errorf("step = %d, out of the accepted window", step)
+			}
+		})
+	}
+}
+
+func TestVerifyTOTPRejectsInvalidSecret(t *testing.T) {
+	if _, ok := VerifyTOTP("not base32!", "287082", time.Unix(59, 0)); ok {
+		t.Error("an invalid secret must not verify")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes, %v", secret, len(key), err)
+	}
+	uri := TOTPProvisioningURI("urllite", "owner@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/urllite:owner@example.com?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("provisioning uri = %q", uri)
+	}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseUserAgent classifies a user agent into a device type (mobile, tablet,
+// desktop) and an operating system (ios, android, windows, macos, linux).
+// Unknown values are returned as empty strings.
+func ParseUserAgent(userAgent string) (string, string) {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return "", ""
+	}
+
+	var os string
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		os = "ios"
+	case strings.Contains(ua, "android"):
+		os = "android"
+	case strings.Contains(ua, "windows"):
+		os = "windows"
+	case strings.Contains(ua, "mac os x"), strings.Contains(ua, "macintosh"):
+		os = "macos"
+	case strings.Contains(ua, "cros"):
+		os = "chromeos"
+	case strings.Contains(ua, "linux"):
+		os = "linux"
+	}
+
+	device := "desktop"
+	switch {
+	case strings.Contains(ua, "ipad"), strings.Contains(ua, "tablet"),
+		os == "android" && !strings.Contains(ua, "mobile"):
+		device = "tablet"
+	case strings.Contains(ua, "mobile"), strings.Contains(ua, "iphone"), strings.Contains(ua, "ipod"):
+		device = "mobile"
+	}
+
+	return device, os
+}
+
+// PreferredLanguage returns the language tag with the highest quality from an
+// Accept-Language header, lowercased, e.g. "pt-br".
+func PreferredLanguage(acceptLanguage string) string {
+	var preferred string
+	bestQuality := -1.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > bestQuality {
+			preferred, bestQuality = tag, quality
+		}
+	}
+	return preferred
+}
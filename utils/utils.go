@@ -2,6 +2,8 @@ package utils
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"math/big"
 	"time"
 )
@@ -35,3 +37,22 @@ func base62encodedString(n int64) string {
 
 	return string(encoded)
 }
+
+func GenerateRandomString(length int) (string, error) {
+	charsetLength := big.NewInt(int64(len(base62Charset)))
+	result := make([]byte, length)
+	for i := range result {
+		n, err := rand.Int(rand.Reader, charsetLength)
+		if err != nil {
+			return "", err
+		}
+		result[i] = base62Charset[n.Int64()]
+	}
+
+	return string(result), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}